@@ -1,24 +1,31 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
 	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/metrics"
 	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/rollback"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
 )
 
 func main() {
 	logger := logging.NewLogger("info", false)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), buildTracingConfig())
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	dockerConfig := deployment.DockerConfig{
 		ServiceName:   os.Getenv("DOCKER_SERVICE_NAME"),
 		Registry:      os.Getenv("DOCKER_REGISTRY"),
@@ -29,14 +36,8 @@ func main() {
 		CustomArgs:    parseMapFromEnv("DOCKER_CUSTOM_ARGS"),
 	}
 
-	dockerStrat := deployment.NewDockerStrategy(dockerConfig)
-	dockerRollback := rollback.NewService(buildRollbackConfig(), dockerStrat, logger)
+	var dockerStrat deployment.Strategy = deployment.NewDockerStrategy(dockerConfig)
 
-	if err := dockerRollback.Rollback(os.Getenv("ROLLBACK_FROM_VERSION")); err != nil {
-		log.Printf("Docker rollback failed: %v", err)
-	}
-
-	clientset := setupKubernetesClient()
 	k8sConfig := deployment.KubernetesConfig{
 		Namespace:     os.Getenv("K8S_NAMESPACE"),
 		Deployment:    os.Getenv("K8S_DEPLOYMENT"),
@@ -45,13 +46,65 @@ func main() {
 		Annotations:   parseMapFromEnv("K8S_ANNOTATIONS"),
 		Strategy:      os.Getenv("K8S_STRATEGY"),
 		Context:       os.Getenv("K8S_CONTEXT"),
+		ConfigPath:    os.Getenv("K8S_CONFIG_PATH"),
+		InCluster:     os.Getenv("K8S_IN_CLUSTER") == "true",
+	}
+
+	var k8sStrat deployment.Strategy
+	var fleet *rollback.Fleet
+	if contexts := parseListFromEnv("K8S_CONTEXTS"); len(contexts) > 0 {
+		fleet = rollback.NewFleet(buildRollbackConfig(), rollback.FleetConfig{
+			CanaryClusters: getEnvInt("K8S_CANARY_CLUSTERS", 1),
+			WaveSize:       getEnvInt("K8S_WAVE_SIZE", 0),
+			MaxConcurrency: getEnvInt("K8S_MAX_CONCURRENCY", 0),
+		}, logger)
+		for _, kubeContext := range contexts {
+			clusterConfig := k8sConfig
+			clusterConfig.Context = kubeContext
+			fleet.Add(rollback.FleetCluster{
+				Name:     kubeContext,
+				Strategy: deployment.NewKubernetesStrategy(setupKubernetesClient(clusterConfig), clusterConfig),
+			})
+		}
+		k8sStrat = fleet
+	} else {
+		k8sStrat = deployment.NewKubernetesStrategy(setupKubernetesClient(k8sConfig), k8sConfig)
+	}
+
+	rollbackConfig := buildRollbackConfig()
+	rollbackConfig.Hooks = buildHooks(k8sConfig)
+	if addr := os.Getenv("METRICS_ADDR"); addr != "" {
+		go serveMetrics(addr, rollbackConfig)
 	}
 
-	k8sStrat := deployment.NewKubernetesStrategy(clientset, k8sConfig)
-	k8sRollback := rollback.NewService(buildRollbackConfig(), k8sStrat, logger)
+	fromVersion := os.Getenv("ROLLBACK_FROM_VERSION")
+	toVersion := os.Getenv("ROLLBACK_TO_VERSION")
+	mode := rollback.GroupMode(getEnv("ROLLBACK_GROUP_MODE", string(rollback.GroupParallel)))
 
-	if err := k8sRollback.Rollback(os.Getenv("ROLLBACK_FROM_VERSION")); err != nil {
-		log.Printf("K8s rollback failed: %v", err)
+	group := rollback.NewGroup(rollbackConfig, mode, logger)
+	group.Add(rollback.GroupTarget{Name: "docker", Strategy: dockerStrat, From: fromVersion, To: toVersion})
+	group.Add(rollback.GroupTarget{Name: "kubernetes", Strategy: k8sStrat, From: fromVersion, To: toVersion})
+
+	report, err := group.Rollback()
+	for _, result := range report.Results {
+		log.Printf("%s: %s -> %s attempts=%d skipped=%t err=%v compensated=%t",
+			result.Name, result.From, result.To, result.Attempts, result.Skipped, result.Err, result.Compensated)
+	}
+	if fleet != nil && fleet.LastReport() != nil {
+		log.Printf("Kubernetes fleet summary:\n%s", fleet.LastReport().Summary())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if closeErr := group.Close(ctx); closeErr != nil {
+		log.Printf("Failed to deliver notifications: %v", closeErr)
+	}
+	if shutdownErr := shutdownTracing(ctx); shutdownErr != nil {
+		log.Printf("Failed to flush traces: %v", shutdownErr)
+	}
+	cancel()
+
+	if err != nil {
+		log.Fatalf("Group rollback failed: %v", err)
 	}
 }
 
@@ -61,34 +114,111 @@ func buildRollbackConfig() rollback.RollbackConfig {
 	config.DryRun = getEnvBool("ROLLBACK_DRY_RUN", false)
 	config.LogLevel = os.Getenv("ROLLBACK_LOG_LEVEL")
 	config.HealthCheck.URL = os.Getenv("HEALTH_CHECK_URL")
+	config.MetricsEnabled = getEnvBool("METRICS_ENABLED", false)
+
+	config.Notifications.Enabled = getEnvBool("NOTIFY_ENABLED", config.Notifications.Enabled)
+	if channels := parseListFromEnv("NOTIFY_CHANNELS"); len(channels) > 0 {
+		config.Notifications.Channels = channels
+	}
+	config.Notifications.Webhook = os.Getenv("NOTIFY_WEBHOOK_URL")
+	config.Notifications.Slack.WebhookURL = os.Getenv("SLACK_WEBHOOK_URL")
+	config.Notifications.Slack.Channel = os.Getenv("SLACK_CHANNEL")
+	config.Notifications.TeamsWebhook = os.Getenv("TEAMS_WEBHOOK_URL")
+	config.Notifications.Email.Addr = os.Getenv("SMTP_ADDR")
+	config.Notifications.Email.From = getEnv("SMTP_FROM", "stable-galaxy@localhost")
+	config.Notifications.Email.To = parseListFromEnv("SMTP_TO")
+	config.Notifications.Email.Username = os.Getenv("SMTP_USERNAME")
+	config.Notifications.Email.Password = os.Getenv("SMTP_PASSWORD")
+	config.Notifications.PagerDuty.RoutingKey = os.Getenv("PAGERDUTY_ROUTING_KEY")
+
+	// The CLI links no SQL driver, so it can only read the schema version
+	// from a file. Use rollback.NewSQLSchemaInspector from Go to query the
+	// database directly.
+	if path := os.Getenv("SCHEMA_VERSION_FILE"); path != "" {
+		config.Schema.Inspector = rollback.NewFileSchemaInspector(path)
+	}
+	policy, err := rollback.ParseSchemaPolicy(getEnv("SCHEMA_POLICY", string(config.Schema.Policy)))
+	if err != nil {
+		log.Fatalf("Invalid SCHEMA_POLICY: %v", err)
+	}
+	config.Schema.Policy = policy
+	config.Schema.Versions = parseSchemaVersions(parseListFromEnv("SCHEMA_VERSIONS"))
 	return config
 }
 
-func setupKubernetesClient() *kubernetes.Clientset {
-	var config *rest.Config
-	var err error
+func parseSchemaVersions(items []string) map[string]rollback.VersionMetadata {
+	versions := make(map[string]rollback.VersionMetadata)
+	for _, item := range items {
+		version, schema, ok := strings.Cut(item, "=")
+		if !ok {
+			log.Fatalf("Invalid SCHEMA_VERSIONS entry %q, want version=schema", item)
+		}
+		minSchema, maxSchema, _ := strings.Cut(schema, "..")
+		versions[strings.TrimSpace(version)] = rollback.VersionMetadata{
+			SchemaVersion:    strings.TrimSpace(minSchema),
+			MaxSchemaVersion: strings.TrimSpace(maxSchema),
+		}
+	}
+	return versions
+}
 
-	if os.Getenv("K8S_IN_CLUSTER") == "true" {
-		config, err = rest.InClusterConfig()
-	} else {
-		kubeconfig := os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			kubeconfig = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+func buildTracingConfig() tracing.Config {
+	ratio, err := strconv.ParseFloat(os.Getenv("TRACING_SAMPLE_RATIO"), 64)
+	if err != nil {
+		ratio = 1
+	}
+	return tracing.Config{
+		Exporter:    os.Getenv("TRACING_EXPORTER"),
+		Endpoint:    os.Getenv("TRACING_ENDPOINT"),
+		Insecure:    getEnvBool("TRACING_INSECURE", false),
+		Headers:     parseMapFromEnv("TRACING_HEADERS"),
+		FilePath:    os.Getenv("TRACING_FILE"),
+		ServiceName: getEnv("TRACING_SERVICE_NAME", "stable-galaxy"),
+		SampleRatio: ratio,
+	}
+}
+
+func buildHooks(k8sConfig deployment.KubernetesConfig) []rollback.HookRegistration {
+	specs, err := rollback.HookSpecsFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load hooks: %v", err)
+	}
+
+	options := rollback.HookOptions{Executor: deployment.NewExecutor()}
+	for _, spec := range specs {
+		if spec.Type == rollback.HookTypeKubernetesJob {
+			options.Kubernetes = setupKubernetesClient(k8sConfig)
+			break
 		}
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
 	}
 
+	hooks, err := rollback.BuildHooks(specs, options)
 	if err != nil {
-		log.Fatalf("Failed to create k8s config: %v", err)
+		log.Fatalf("Failed to configure hooks: %v", err)
+	}
+	return hooks
+}
+
+func serveMetrics(addr string, config rollback.RollbackConfig) {
+	registry := config.MetricsRegistry
+	if registry == nil {
+		registry = metrics.DefaultRegistry
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry.Handler())
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Metrics server stopped: %v", err)
 	}
+}
 
-	clientset, err := kubernetes.NewForConfig(config)
+func setupKubernetesClient(config deployment.KubernetesConfig) *kubernetes.Clientset {
+	clientset, err := deployment.NewKubernetesClientset(config)
 	if err != nil {
 		log.Fatalf("Failed to create k8s clientset: %v", err)
 	}
-
 	return clientset
 }
+
 func parseMapFromEnv(prefix string) map[string]string {
 	result := make(map[string]string)
 	for _, env := range os.Environ() {
@@ -101,6 +231,23 @@ func parseMapFromEnv(prefix string) map[string]string {
 	return result
 }
 
+func parseListFromEnv(key string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func getEnv(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
 func getEnvInt(key string, defaultVal int) int {
 	val := os.Getenv(key)
 	if val == "" {
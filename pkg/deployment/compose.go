@@ -0,0 +1,266 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultComposeVersionLabel = "org.opencontainers.image.version"
+
+type ComposeConfig struct {
+	ProjectName    string
+	ProjectDir     string
+	ComposeFiles   []string
+	OverrideFile   string
+	ServiceName    string
+	Registry       string
+	ImageTemplate  string
+	VersionLabel   string
+	ComposeCommand []string
+}
+
+type ComposeStrategy struct {
+	config   ComposeConfig
+	executor Executor
+}
+
+func NewComposeStrategy(config ComposeConfig) *ComposeStrategy {
+	return NewComposeStrategyWithExecutor(config, NewExecutor())
+}
+
+func NewComposeStrategyWithExecutor(config ComposeConfig, executor Executor) *ComposeStrategy {
+	if len(config.ComposeFiles) == 0 {
+		config.ComposeFiles = []string{"docker-compose.yml"}
+	}
+	if config.OverrideFile == "" {
+		config.OverrideFile = "docker-compose.override.yml"
+	}
+	if config.VersionLabel == "" {
+		config.VersionLabel = defaultComposeVersionLabel
+	}
+	if len(config.ComposeCommand) == 0 {
+		config.ComposeCommand = []string{"docker", "compose"}
+	}
+	return &ComposeStrategy{
+		config:   config,
+		executor: executor,
+	}
+}
+
+func (c *ComposeStrategy) StrategyName() string {
+	return "compose"
+}
+
+func (c *ComposeStrategy) buildImageTag(version string) string {
+	if c.config.ImageTemplate != "" {
+		return fmt.Sprintf(c.config.ImageTemplate, version)
+	}
+	if c.config.Registry != "" {
+		return fmt.Sprintf("%s/%s:%s", c.config.Registry, c.config.ServiceName, version)
+	}
+	return fmt.Sprintf("%s:%s", c.config.ServiceName, version)
+}
+
+func (c *ComposeStrategy) resolvePath(path string) string {
+	if filepath.IsAbs(path) || c.config.ProjectDir == "" {
+		return path
+	}
+	return filepath.Join(c.config.ProjectDir, path)
+}
+
+func (c *ComposeStrategy) overridePath() string {
+	return c.resolvePath(c.config.OverrideFile)
+}
+
+func (c *ComposeStrategy) previousOverridePath() string {
+	return c.overridePath() + ".previous"
+}
+
+func (c *ComposeStrategy) composeCommand(args ...string) []string {
+	cmdArgs := append([]string{}, c.config.ComposeCommand[1:]...)
+	if c.config.ProjectName != "" {
+		cmdArgs = append(cmdArgs, "-p", c.config.ProjectName)
+	}
+	for _, file := range c.config.ComposeFiles {
+		cmdArgs = append(cmdArgs, "-f", c.resolvePath(file))
+	}
+	if _, err := os.Stat(c.overridePath()); err == nil {
+		cmdArgs = append(cmdArgs, "-f", c.overridePath())
+	}
+	return append(cmdArgs, args...)
+}
+
+func (c *ComposeStrategy) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := CommandContext(ctx, c.executor, c.config.ComposeCommand[0], args...)
+	if c.config.ProjectDir != "" {
+		cmd.Dir = c.config.ProjectDir
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", c.config.ComposeCommand[0], strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+func (c *ComposeStrategy) up(ctx context.Context) error {
+	_, err := c.run(ctx, c.composeCommand("up", "-d", "--no-deps", c.config.ServiceName)...)
+	return err
+}
+
+func readComposeFile(path string) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return doc, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func serviceImage(doc map[string]interface{}, service string) string {
+	services, _ := doc["services"].(map[string]interface{})
+	svc, _ := services[service].(map[string]interface{})
+	image, _ := svc["image"].(string)
+	return image
+}
+
+func setServiceImage(doc map[string]interface{}, service, image string) {
+	services, ok := doc["services"].(map[string]interface{})
+	if !ok {
+		services = make(map[string]interface{})
+		doc["services"] = services
+	}
+	svc, ok := services[service].(map[string]interface{})
+	if !ok {
+		svc = make(map[string]interface{})
+		services[service] = svc
+	}
+	svc["image"] = image
+}
+
+func (c *ComposeStrategy) writeOverride(version string) error {
+	path := c.overridePath()
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	doc, err := readComposeFile(path)
+	if err != nil {
+		return err
+	}
+	setServiceImage(doc, c.config.ServiceName, c.buildImageTag(version))
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	if current != nil {
+		if err := writeFileAtomic(c.previousOverridePath(), current); err != nil {
+			return fmt.Errorf("failed to keep previous override: %w", err)
+		}
+	}
+	return writeFileAtomic(path, data)
+}
+
+func (c *ComposeStrategy) swapOverrides() error {
+	path := c.overridePath()
+	previous := c.previousOverridePath()
+	tmp := path + ".swap"
+
+	if err := os.Rename(path, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(previous, path); err != nil {
+		_ = os.Rename(tmp, path)
+		return err
+	}
+	return os.Rename(tmp, previous)
+}
+
+func (c *ComposeStrategy) previousMatches(version string) bool {
+	if _, err := os.Stat(c.previousOverridePath()); err != nil {
+		return false
+	}
+	doc, err := readComposeFile(c.previousOverridePath())
+	if err != nil {
+		return false
+	}
+	return serviceImage(doc, c.config.ServiceName) == c.buildImageTag(version)
+}
+
+func (c *ComposeStrategy) Rollback(from, to string) error {
+	return c.RollbackContext(context.Background(), from, to)
+}
+
+func (c *ComposeStrategy) RollbackContext(ctx context.Context, from, to string) error {
+	if c.previousMatches(to) {
+		if err := c.swapOverrides(); err != nil {
+			return fmt.Errorf("failed to restore previous override: %w", err)
+		}
+	} else if err := c.writeOverride(to); err != nil {
+		return err
+	}
+	return c.up(ctx)
+}
+
+func (c *ComposeStrategy) Deploy(version string) error {
+	return c.DeployContext(context.Background(), version)
+}
+
+func (c *ComposeStrategy) DeployContext(ctx context.Context, version string) error {
+	if err := c.writeOverride(version); err != nil {
+		return err
+	}
+	return c.up(ctx)
+}
+
+func (c *ComposeStrategy) GetCurrentVersion() (string, error) {
+	return c.GetCurrentVersionContext(context.Background())
+}
+
+func (c *ComposeStrategy) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	output, err := c.run(ctx, c.composeCommand("ps", "-q", c.config.ServiceName)...)
+	if err != nil {
+		return "", err
+	}
+
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no running containers found for service %s", c.config.ServiceName)
+	}
+
+	format := fmt.Sprintf(`{{index .Config.Labels %q}}|{{.Config.Image}}`, c.config.VersionLabel)
+	output, err = CommandContext(ctx, c.executor, "docker", "inspect", "--format", format, ids[0]).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker inspect %s: %w", ids[0], err)
+	}
+
+	label, image, _ := strings.Cut(strings.TrimSpace(string(output)), "|")
+	if label != "" && label != "<no value>" {
+		return label, nil
+	}
+	return ParseVersionFromImage(image), nil
+}
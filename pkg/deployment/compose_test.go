@@ -0,0 +1,118 @@
+package deployment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readOverrideImage(t *testing.T, path string) string {
+	doc, err := readComposeFile(path)
+	if err != nil {
+		t.Fatalf("readComposeFile() error = %v", err)
+	}
+	return serviceImage(doc, "api")
+}
+
+func TestComposeStrategy_Deploy(t *testing.T) {
+	executor := &mockExecutor{}
+	dir := t.TempDir()
+	c := NewComposeStrategyWithExecutor(ComposeConfig{ProjectName: "edge", ProjectDir: dir, ServiceName: "api", Registry: "registry.example.com"}, executor)
+
+	override := filepath.Join(dir, "docker-compose.override.yml")
+	existing := "services:\n  api:\n    image: registry.example.com/api:v1.0.0\n    environment:\n      MODE: edge\n"
+	if err := os.WriteFile(override, []byte(existing), 0o644); err != nil {
+		t.Fatalf("failed to write override: %v", err)
+	}
+
+	if err := c.Deploy("v1.1.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+
+	if got := readOverrideImage(t, override); got != "registry.example.com/api:v1.1.0" {
+		t.Errorf("override image = %s, want registry.example.com/api:v1.1.0", got)
+	}
+	if got := readOverrideImage(t, override+".previous"); got != "registry.example.com/api:v1.0.0" {
+		t.Errorf("previous override image = %s, want registry.example.com/api:v1.0.0", got)
+	}
+
+	data, _ := os.ReadFile(override)
+	if !strings.Contains(string(data), "MODE: edge") {
+		t.Errorf("override lost unrelated keys:\n%s", data)
+	}
+
+	if len(executor.commands) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(executor.commands))
+	}
+	want := "docker compose -p edge -f " + filepath.Join(dir, "docker-compose.yml") + " -f " + override + " up -d --no-deps api"
+	if executor.commands[0] != want {
+		t.Errorf("command = %s, want %s", executor.commands[0], want)
+	}
+}
+
+func TestComposeStrategy_RollbackRestoresPreviousOverride(t *testing.T) {
+	executor := &mockExecutor{}
+	dir := t.TempDir()
+	c := NewComposeStrategyWithExecutor(ComposeConfig{ProjectName: "edge", ProjectDir: dir, ServiceName: "api", Registry: "registry.example.com"}, executor)
+	override := filepath.Join(dir, "docker-compose.override.yml")
+
+	if err := c.Deploy("v1.0.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	if err := c.Deploy("v1.1.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	if err := c.Rollback("v1.1.0", "v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	if got := readOverrideImage(t, override); got != "registry.example.com/api:v1.0.0" {
+		t.Errorf("override image = %s, want registry.example.com/api:v1.0.0", got)
+	}
+	if got := readOverrideImage(t, override+".previous"); got != "registry.example.com/api:v1.1.0" {
+		t.Errorf("previous override image = %s, want registry.example.com/api:v1.1.0", got)
+	}
+}
+
+func TestComposeStrategy_GetCurrentVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		inspect  string
+		expected string
+	}{
+		{
+			name:     "version label",
+			inspect:  "v1.2.0|registry.example.com/api:latest\n",
+			expected: "v1.2.0",
+		},
+		{
+			name:     "falls back to image tag",
+			inspect:  "<no value>|registry.example.com/api:v1.1.0\n",
+			expected: "v1.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &mockExecutor{outputs: map[string]string{
+				"docker compose": "abc123\n",
+				"docker inspect": tt.inspect,
+			}}
+			dir := t.TempDir()
+			c := NewComposeStrategyWithExecutor(ComposeConfig{ProjectName: "edge", ProjectDir: dir, ServiceName: "api", Registry: "registry.example.com"}, executor)
+
+			version, err := c.GetCurrentVersion()
+			if err != nil {
+				t.Fatalf("GetCurrentVersion() error = %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("GetCurrentVersion() = %v, want %v", version, tt.expected)
+			}
+			want := "docker compose -p edge -f " + filepath.Join(dir, "docker-compose.yml") + " ps -q api"
+			if executor.commands[0] != want {
+				t.Errorf("command = %s, want %s without a missing override file", executor.commands[0], want)
+			}
+		})
+	}
+}
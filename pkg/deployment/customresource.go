@@ -0,0 +1,329 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const defaultImagePath = "{.spec.template.spec.containers[0].image}"
+
+// ImagePath, ReadyPath and HistoryPath are kubectl-style JSONPath
+// expressions. An empty ReadyPath treats the resource as ready as soon as
+// its status.observedGeneration catches up. HistoryPath points at the
+// revision history the resource keeps itself, such as
+// {.status.history[*].image}; when empty, rollbacks are not checked against
+// it.
+type CustomResourceConfig struct {
+	Resource      schema.GroupVersionResource
+	Namespace     string
+	Name          string
+	ImageTemplate string
+	ImagePath     string
+	ReadyPath     string
+	ReadyValue    string
+	HistoryPath   string
+	ReadyTimeout  time.Duration
+	PollInterval  time.Duration
+}
+
+type CustomResourceStrategy struct {
+	client dynamic.Interface
+	config CustomResourceConfig
+}
+
+func NewCustomResourceStrategy(client dynamic.Interface, config CustomResourceConfig) *CustomResourceStrategy {
+	if config.ImagePath == "" {
+		config.ImagePath = defaultImagePath
+	}
+	if config.ReadyValue == "" {
+		config.ReadyValue = "True"
+	}
+	if config.ReadyTimeout == 0 {
+		config.ReadyTimeout = 5 * time.Minute
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	return &CustomResourceStrategy{
+		client: client,
+		config: config,
+	}
+}
+
+func (c *CustomResourceStrategy) StrategyName() string {
+	return "custom-resource"
+}
+
+func (c *CustomResourceStrategy) buildImage(version string) string {
+	if c.config.ImageTemplate != "" {
+		return fmt.Sprintf(c.config.ImageTemplate, version)
+	}
+	return fmt.Sprintf("%s:%s", c.config.Name, version)
+}
+
+func (c *CustomResourceStrategy) resource() dynamic.ResourceInterface {
+	if c.config.Namespace == "" {
+		return c.client.Resource(c.config.Resource)
+	}
+	return c.client.Resource(c.config.Resource).Namespace(c.config.Namespace)
+}
+
+func (c *CustomResourceStrategy) get(ctx context.Context) (*unstructured.Unstructured, error) {
+	return c.resource().Get(ctx, c.config.Name, metav1.GetOptions{})
+}
+
+func findPath(obj map[string]interface{}, path string) ([]interface{}, error) {
+	jp := jsonpath.New("path").AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	results, err := jp.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0)
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+	return values, nil
+}
+
+func setPath(obj map[string]interface{}, path string, value interface{}) error {
+	parser, err := jsonpath.Parse("path", path)
+	if err != nil {
+		return fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	if len(parser.Root.Nodes) != 1 {
+		return fmt.Errorf("path %q must be a single expression", path)
+	}
+	list, ok := parser.Root.Nodes[0].(*jsonpath.ListNode)
+	if !ok || len(list.Nodes) == 0 {
+		return fmt.Errorf("path %q must be a single expression", path)
+	}
+
+	var current interface{} = obj
+	for i, node := range list.Nodes {
+		last := i == len(list.Nodes)-1
+		switch node := node.(type) {
+		case *jsonpath.FieldNode:
+			fields, ok := current.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("field %q is not an object in path %q", node.Value, path)
+			}
+			if last {
+				fields[node.Value] = value
+				return nil
+			}
+			next, ok := fields[node.Value]
+			if !ok || next == nil {
+				if _, isArray := list.Nodes[i+1].(*jsonpath.ArrayNode); isArray {
+					return fmt.Errorf("list %q missing in path %q", node.Value, path)
+				}
+				next = make(map[string]interface{})
+				fields[node.Value] = next
+			}
+			current = next
+		case *jsonpath.ArrayNode:
+			if !node.Params[0].Known || !node.Params[1].Derived {
+				return fmt.Errorf("path %q may only use single list indexes", path)
+			}
+			index := node.Params[0].Value
+			items, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(items) {
+				return fmt.Errorf("index %d out of range in path %q", index, path)
+			}
+			if last {
+				items[index] = value
+				return nil
+			}
+			current = items[index]
+		default:
+			return fmt.Errorf("path %q may only use fields and list indexes", path)
+		}
+	}
+	return nil
+}
+
+func (c *CustomResourceStrategy) image(obj *unstructured.Unstructured) (string, error) {
+	values, err := findPath(obj.Object, c.config.ImagePath)
+	if err != nil {
+		return "", err
+	}
+	for _, value := range values {
+		if image, ok := value.(string); ok && image != "" {
+			return image, nil
+		}
+	}
+	return "", fmt.Errorf("image not found at %s in %s/%s", c.config.ImagePath, c.config.Resource.Resource, c.config.Name)
+}
+
+func (c *CustomResourceStrategy) history(obj *unstructured.Unstructured) ([]string, error) {
+	if c.config.HistoryPath == "" {
+		return nil, nil
+	}
+	values, err := findPath(obj.Object, c.config.HistoryPath)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]string, 0, len(values))
+	for _, value := range values {
+		entry := fmt.Sprint(value)
+		if strings.Contains(entry, ":") {
+			entry = ParseVersionFromImage(entry)
+		}
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+func (c *CustomResourceStrategy) isReady(obj *unstructured.Unstructured) (bool, error) {
+	if observed, found := observedGeneration(obj); found && observed < obj.GetGeneration() {
+		return false, nil
+	}
+
+	if c.config.ReadyPath == "" {
+		return true, nil
+	}
+
+	values, err := findPath(obj.Object, c.config.ReadyPath)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range values {
+		if fmt.Sprint(value) == c.config.ReadyValue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// observedGeneration reads status.observedGeneration, which Argo Rollouts
+// reports as a string rather than an integer.
+func observedGeneration(obj *unstructured.Unstructured) (int64, bool) {
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, "status", "observedGeneration")
+	if err != nil || !found {
+		return 0, false
+	}
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	case string:
+		observed, err := strconv.ParseInt(v, 10, 64)
+		return observed, err == nil
+	}
+	return 0, false
+}
+
+func (c *CustomResourceStrategy) waitForReady(ctx context.Context) error {
+	deadline := time.Now().Add(c.config.ReadyTimeout)
+	for {
+		obj, err := c.get(ctx)
+		if err != nil {
+			return err
+		}
+		ready, err := c.isReady(obj)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s/%s to become ready", c.config.Resource.Resource, c.config.Name)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.config.PollInterval):
+		}
+	}
+}
+
+func (c *CustomResourceStrategy) apply(ctx context.Context, obj *unstructured.Unstructured, version string) error {
+	if err := setPath(obj.Object, c.config.ImagePath, c.buildImage(version)); err != nil {
+		return err
+	}
+	if _, err := c.resource().Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	return c.waitForReady(ctx)
+}
+
+func (c *CustomResourceStrategy) History(ctx context.Context) ([]string, error) {
+	obj, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.history(obj)
+}
+
+func (c *CustomResourceStrategy) Rollback(from, to string) error {
+	return c.RollbackContext(context.Background(), from, to)
+}
+
+func (c *CustomResourceStrategy) RollbackContext(ctx context.Context, from, to string) error {
+	obj, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	history, err := c.history(obj)
+	if err != nil {
+		return err
+	}
+	if len(history) > 0 {
+		known := false
+		for _, v := range history {
+			if v == to {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("version %s not found in revision history of %s/%s", to, c.config.Resource.Resource, c.config.Name)
+		}
+	}
+
+	return c.apply(ctx, obj, to)
+}
+
+func (c *CustomResourceStrategy) Deploy(version string) error {
+	return c.DeployContext(context.Background(), version)
+}
+
+func (c *CustomResourceStrategy) DeployContext(ctx context.Context, version string) error {
+	obj, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	return c.apply(ctx, obj, version)
+}
+
+func (c *CustomResourceStrategy) GetCurrentVersion() (string, error) {
+	return c.GetCurrentVersionContext(context.Background())
+}
+
+func (c *CustomResourceStrategy) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	obj, err := c.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	image, err := c.image(obj)
+	if err != nil {
+		return "", err
+	}
+	return ParseVersionFromImage(image), nil
+}
@@ -0,0 +1,179 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var rolloutGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+
+var rolloutConfig = CustomResourceConfig{
+	Resource:      rolloutGVR,
+	Namespace:     "default",
+	Name:          "web",
+	ImageTemplate: "registry.example.com/web:%s",
+	ReadyPath:     "{.status.phase}",
+	ReadyValue:    "Healthy",
+	HistoryPath:   "{.status.history[*].image}",
+	ReadyTimeout:  50 * time.Millisecond,
+	PollInterval:  time.Millisecond,
+}
+
+func newTestRollout(image, phase string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata": map[string]interface{}{
+			"name":      "web",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": image},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"phase": phase,
+			"history": []interface{}{
+				map[string]interface{}{"revision": int64(1), "image": "registry.example.com/web:v1.0.0"},
+				map[string]interface{}{"revision": int64(2), "image": "registry.example.com/web:v1.1.0"},
+			},
+		},
+	}}
+}
+
+func TestCustomResourceStrategy_DeployAndRollback(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{rolloutGVR: "RolloutList"}, newTestRollout("registry.example.com/web:v1.0.0", "Healthy"))
+	c := NewCustomResourceStrategy(client, rolloutConfig)
+
+	if err := c.Deploy("v1.1.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+
+	history, err := c.History(context.Background())
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != 2 || history[0] != "v1.0.0" || history[1] != "v1.1.0" {
+		t.Errorf("History() = %v, want [v1.0.0 v1.1.0]", history)
+	}
+
+	if err := c.Rollback("v1.1.0", "v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	obj, err := client.Resource(rolloutGVR).Namespace("default").Get(context.TODO(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting rollout: %v", err)
+	}
+	image, _ := c.image(obj)
+	if image != "registry.example.com/web:v1.0.0" {
+		t.Errorf("image = %v, want registry.example.com/web:v1.0.0", image)
+	}
+
+	version, err := c.GetCurrentVersion()
+	if err != nil {
+		t.Fatalf("GetCurrentVersion() error = %v", err)
+	}
+	if version != "v1.0.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v1.0.0", version)
+	}
+}
+
+func TestCustomResourceStrategy_RollbackUnknownRevision(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{rolloutGVR: "RolloutList"}, newTestRollout("registry.example.com/web:v1.0.0", "Healthy"))
+	c := NewCustomResourceStrategy(client, rolloutConfig)
+
+	if err := c.Rollback("v1.1.0", "v0.5.0"); err == nil {
+		t.Error("Rollback() expected error for version missing from history")
+	}
+}
+
+func TestCustomResourceStrategy_NotReady(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{rolloutGVR: "RolloutList"}, newTestRollout("registry.example.com/web:v1.0.0", "Degraded"))
+	c := NewCustomResourceStrategy(client, rolloutConfig)
+
+	if err := c.Deploy("v1.1.0"); err == nil {
+		t.Error("Deploy() expected readiness timeout")
+	}
+}
+
+func TestCustomResourceStrategy_ObservedGeneration(t *testing.T) {
+	tests := []struct {
+		name     string
+		observed interface{}
+		want     bool
+	}{
+		{"string behind generation", "1", false},
+		{"string caught up", "2", true},
+		{"integer behind generation", int64(1), false},
+		{"integer caught up", int64(2), true},
+		{"missing", nil, true},
+	}
+
+	c := NewCustomResourceStrategy(nil, rolloutConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newTestRollout("registry.example.com/web:v1.1.0", "Healthy")
+			obj.SetGeneration(2)
+			if tt.observed != nil {
+				if err := unstructured.SetNestedField(obj.Object, tt.observed, "status", "observedGeneration"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ready, err := c.isReady(obj)
+			if err != nil {
+				t.Fatalf("isReady() error = %v", err)
+			}
+			if ready != tt.want {
+				t.Errorf("isReady() = %v, want %v with a stale Healthy phase", ready, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "{.spec.template.spec.containers[0].image}"},
+		{path: "{.spec.template.metadata.labels.version}"},
+		{path: "{.spec.template.spec.containers[3].image}", wantErr: true},
+		{path: "{.spec.template.spec.containers[*].image}", wantErr: true},
+		{path: "{.spec.template.spec.containers[?(@.name==\"web\")].image}", wantErr: true},
+		{path: "{.spec.template.spec.containers[x]}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			obj := newTestRollout("registry.example.com/web:v1.0.0", "Healthy")
+			err := setPath(obj.Object, tt.path, "v2")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			values, _ := findPath(obj.Object, tt.path)
+			if len(values) != 1 || values[0] != "v2" {
+				t.Errorf("findPath() after setPath() = %v, want [v2]", values)
+			}
+		})
+	}
+}
@@ -1,8 +1,8 @@
 /*
 Package deployment implements deployment strategies for different platforms.
 
-It provides a common interface for managing deployments across Docker and Kubernetes
-environments, allowing for consistent rollback behavior regardless of the underlying
+It provides a common interface for managing deployments across Docker, Docker Compose
+and Kubernetes environments, allowing for consistent rollback behavior regardless of the underlying
 platform.
 
 Example Docker usage:
@@ -20,5 +20,26 @@ Example Kubernetes usage:
 	    Deployment: "myapp",
 	}
 	strategy := NewKubernetesStrategy(clientset, config)
+
+Example Docker Compose usage:
+
+	config := ComposeConfig{
+	    ProjectDir:  "/srv/edge",
+	    ServiceName: "myapp",
+	    Registry:    "registry.example.com",
+	}
+	strategy := NewComposeStrategy(config)
+
+Example Argo Rollouts usage through the dynamic client:
+
+	config := CustomResourceConfig{
+	    Resource:   schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"},
+	    Namespace:  "default",
+	    Name:       "myapp",
+	    ImagePath:  "{.spec.template.spec.containers[0].image}",
+	    ReadyPath:  "{.status.phase}",
+	    ReadyValue: "Healthy",
+	}
+	strategy := NewCustomResourceStrategy(dynamicClient, config)
 */
 package deployment
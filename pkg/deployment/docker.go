@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 )
@@ -18,12 +19,18 @@ type DockerConfig struct {
 }
 
 type DockerStrategy struct {
-	config DockerConfig
+	config   DockerConfig
+	executor Executor
 }
 
 func NewDockerStrategy(config DockerConfig) *DockerStrategy {
+	return NewDockerStrategyWithExecutor(config, NewExecutor())
+}
+
+func NewDockerStrategyWithExecutor(config DockerConfig, executor Executor) *DockerStrategy {
 	return &DockerStrategy{
-		config: config,
+		config:   config,
+		executor: executor,
 	}
 }
 
@@ -38,7 +45,7 @@ func (d *DockerStrategy) StrategyName() string {
 	return "docker"
 }
 
-func (d *DockerStrategy) buildUpdateCommand(imageTag string) *exec.Cmd {
+func (d *DockerStrategy) buildUpdateCommand(ctx context.Context, imageTag string) *exec.Cmd {
 	args := []string{"service", "update", "--image", imageTag}
 
 	if d.config.NetworkMode != "" {
@@ -63,26 +70,38 @@ func (d *DockerStrategy) buildUpdateCommand(imageTag string) *exec.Cmd {
 
 	args = append(args, d.config.ServiceName)
 
-	return exec.Command("docker", args...)
+	return CommandContext(ctx, d.executor, "docker", args...)
 }
 
 func (d *DockerStrategy) Rollback(from, to string) error {
+	return d.RollbackContext(context.Background(), from, to)
+}
+
+func (d *DockerStrategy) RollbackContext(ctx context.Context, from, to string) error {
 	imageTag := d.buildImageTag(to)
-	cmd := d.buildUpdateCommand(imageTag)
+	cmd := d.buildUpdateCommand(ctx, imageTag)
 	return cmd.Run()
 }
 
 func (d *DockerStrategy) Deploy(version string) error {
+	return d.DeployContext(context.Background(), version)
+}
+
+func (d *DockerStrategy) DeployContext(ctx context.Context, version string) error {
 	imageTag := d.buildImageTag(version)
-	cmd := d.buildUpdateCommand(imageTag)
+	cmd := d.buildUpdateCommand(ctx, imageTag)
 	return cmd.Run()
 }
 
 func (d *DockerStrategy) GetCurrentVersion() (string, error) {
-	cmd := exec.Command("docker", "service", "inspect", "--format", "{{.Spec.TaskTemplate.ContainerSpec.Image}}", d.config.ServiceName)
+	return d.GetCurrentVersionContext(context.Background())
+}
+
+func (d *DockerStrategy) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	cmd := CommandContext(ctx, d.executor, "docker", "service", "inspect", "--format", "{{.Spec.TaskTemplate.ContainerSpec.Image}}", d.config.ServiceName)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", err
 	}
-	return parseVersionFromImage(string(output)), nil
+	return ParseVersionFromImage(string(output)), nil
 }
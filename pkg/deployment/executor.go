@@ -0,0 +1,36 @@
+package deployment
+
+import (
+	"context"
+	"os/exec"
+)
+
+type Executor interface {
+	Command(name string, args ...string) *exec.Cmd
+}
+
+type ContextExecutor interface {
+	Executor
+	CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd
+}
+
+type execExecutor struct{}
+
+func NewExecutor() Executor {
+	return execExecutor{}
+}
+
+func (execExecutor) Command(name string, args ...string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
+
+func (execExecutor) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
+
+func CommandContext(ctx context.Context, executor Executor, name string, args ...string) *exec.Cmd {
+	if ce, ok := executor.(ContextExecutor); ok {
+		return ce.CommandContext(ctx, name, args...)
+	}
+	return executor.Command(name, args...)
+}
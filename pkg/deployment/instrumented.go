@@ -0,0 +1,116 @@
+package deployment
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/metrics"
+)
+
+type InstrumentedStrategy struct {
+	Strategy
+
+	operations     *metrics.Counter
+	durations      *metrics.Histogram
+	currentVersion *metrics.Gauge
+
+	target string
+
+	mu      sync.Mutex
+	current string
+}
+
+func Instrument(strategy Strategy, registry *metrics.Registry) *InstrumentedStrategy {
+	return InstrumentWithTarget(strategy, registry, "")
+}
+
+// InstrumentWithTarget labels the current version with target, so several
+// targets that use the same kind of strategy keep separate series. An empty
+// target falls back to the strategy name.
+func InstrumentWithTarget(strategy Strategy, registry *metrics.Registry, target string) *InstrumentedStrategy {
+	if registry == nil {
+		registry = metrics.DefaultRegistry
+	}
+	if target == "" {
+		target = strategy.StrategyName()
+	}
+	return &InstrumentedStrategy{
+		Strategy: strategy,
+		target:   target,
+		operations: registry.NewCounter("stable_galaxy_strategy_operations",
+			"Deployment strategy operations by result.", "strategy", "operation", "result"),
+		durations: registry.NewHistogram("stable_galaxy_strategy_operation_duration_seconds",
+			"Duration of deployment strategy operations.", nil, "strategy", "operation"),
+		currentVersion: registry.NewGauge("stable_galaxy_current_version_info",
+			"Version currently deployed by each target.", "strategy", "target", "version"),
+	}
+}
+
+func (i *InstrumentedStrategy) observe(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	name := i.Strategy.StrategyName()
+	i.operations.Inc(name, operation, result)
+	i.durations.Observe(time.Since(start).Seconds(), name, operation)
+}
+
+func (i *InstrumentedStrategy) setCurrent(version string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	name := i.Strategy.StrategyName()
+	if i.current != "" && i.current != version {
+		i.currentVersion.Delete(name, i.target, i.current)
+	}
+	i.current = version
+	i.currentVersion.Set(1, name, i.target, version)
+}
+
+func (i *InstrumentedStrategy) Unwrap() Strategy {
+	return i.Strategy
+}
+
+func (i *InstrumentedStrategy) Rollback(from, to string) error {
+	return i.RollbackContext(context.Background(), from, to)
+}
+
+func (i *InstrumentedStrategy) RollbackContext(ctx context.Context, from, to string) error {
+	start := time.Now()
+	err := RollbackWithContext(ctx, i.Strategy, from, to)
+	i.observe("rollback", start, err)
+	if err == nil {
+		i.setCurrent(to)
+	}
+	return err
+}
+
+func (i *InstrumentedStrategy) Deploy(version string) error {
+	return i.DeployContext(context.Background(), version)
+}
+
+func (i *InstrumentedStrategy) DeployContext(ctx context.Context, version string) error {
+	start := time.Now()
+	err := DeployWithContext(ctx, i.Strategy, version)
+	i.observe("deploy", start, err)
+	if err == nil {
+		i.setCurrent(version)
+	}
+	return err
+}
+
+func (i *InstrumentedStrategy) GetCurrentVersion() (string, error) {
+	return i.GetCurrentVersionContext(context.Background())
+}
+
+func (i *InstrumentedStrategy) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	start := time.Now()
+	version, err := CurrentVersionWithContext(ctx, i.Strategy)
+	i.observe("get_current_version", start, err)
+	if err == nil {
+		i.setCurrent(version)
+	}
+	return version, err
+}
@@ -0,0 +1,56 @@
+package deployment
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const InClusterContext = "in-cluster"
+
+func kubeconfigLoadingRules(configPath string) *clientcmd.ClientConfigLoadingRules {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if configPath != "" {
+		rules.ExplicitPath = configPath
+	}
+	return rules
+}
+
+func NewKubernetesRESTConfig(config KubernetesConfig) (*rest.Config, error) {
+	if config.InCluster || config.Context == InClusterContext {
+		return rest.InClusterConfig()
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: config.Context}
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		kubeconfigLoadingRules(config.ConfigPath), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig context %q: %w", config.Context, err)
+	}
+	return restConfig, nil
+}
+
+func NewKubernetesClientset(config KubernetesConfig) (*kubernetes.Clientset, error) {
+	restConfig, err := NewKubernetesRESTConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+func ListKubeContexts(configPath string) ([]string, error) {
+	rawConfig, err := kubeconfigLoadingRules(configPath).Load()
+	if err != nil {
+		return nil, err
+	}
+	contexts := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
@@ -0,0 +1,77 @@
+package deployment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east.example.com
+- name: west
+  cluster:
+    server: https://west.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: prod-west
+  context:
+    cluster: west
+    user: admin
+- name: prod-east
+  context:
+    cluster: east
+    user: admin
+current-context: prod-east
+`
+
+func writeTestKubeconfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	return path
+}
+
+func TestNewKubernetesRESTConfig(t *testing.T) {
+	path := writeTestKubeconfig(t)
+
+	tests := []struct {
+		name     string
+		context  string
+		expected string
+		wantErr  bool
+	}{
+		{name: "current context", expected: "https://east.example.com"},
+		{name: "explicit context", context: "prod-west", expected: "https://west.example.com"},
+		{name: "unknown context", context: "staging", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewKubernetesRESTConfig(KubernetesConfig{ConfigPath: path, Context: tt.context})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewKubernetesRESTConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && config.Host != tt.expected {
+				t.Errorf("Host = %v, want %v", config.Host, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListKubeContexts(t *testing.T) {
+	contexts, err := ListKubeContexts(writeTestKubeconfig(t))
+	if err != nil {
+		t.Fatalf("ListKubeContexts() error = %v", err)
+	}
+	if len(contexts) != 2 || contexts[0] != "prod-east" || contexts[1] != "prod-west" {
+		t.Errorf("ListKubeContexts() = %v, want [prod-east prod-west]", contexts)
+	}
+}
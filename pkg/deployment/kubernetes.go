@@ -25,6 +25,7 @@ type KubernetesConfig struct {
 	Strategy      string
 	ConfigPath    string
 	Context       string
+	InCluster     bool
 	CustomOptions map[string]interface{}
 }
 type KubernetesClientset interface {
@@ -95,35 +96,40 @@ func (k *KubernetesStrategy) updateDeployment(deployment *appsv1.Deployment, ver
 }
 
 func (k *KubernetesStrategy) Rollback(from, to string) error {
-	deployment, err := k.clientset.AppsV1().Deployments(k.config.Namespace).Get(context.TODO(), k.config.Deployment, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
+	return k.RollbackContext(context.Background(), from, to)
+}
 
-	k.updateDeployment(deployment, to)
-	_, err = k.clientset.AppsV1().Deployments(k.config.Namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
-	return err
+func (k *KubernetesStrategy) RollbackContext(ctx context.Context, from, to string) error {
+	return k.DeployContext(ctx, to)
 }
 
 func (k *KubernetesStrategy) Deploy(version string) error {
-	deployment, err := k.clientset.AppsV1().Deployments(k.config.Namespace).Get(context.TODO(), k.config.Deployment, metav1.GetOptions{})
+	return k.DeployContext(context.Background(), version)
+}
+
+func (k *KubernetesStrategy) DeployContext(ctx context.Context, version string) error {
+	deployment, err := k.clientset.AppsV1().Deployments(k.config.Namespace).Get(ctx, k.config.Deployment, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 
 	k.updateDeployment(deployment, version)
-	_, err = k.clientset.AppsV1().Deployments(k.config.Namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
+	_, err = k.clientset.AppsV1().Deployments(k.config.Namespace).Update(ctx, deployment, metav1.UpdateOptions{})
 	return err
 }
 
 func (k *KubernetesStrategy) GetCurrentVersion() (string, error) {
-	deployment, err := k.clientset.AppsV1().Deployments(k.config.Namespace).Get(context.TODO(), k.config.Deployment, metav1.GetOptions{})
+	return k.GetCurrentVersionContext(context.Background())
+}
+
+func (k *KubernetesStrategy) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	deployment, err := k.clientset.AppsV1().Deployments(k.config.Namespace).Get(ctx, k.config.Deployment, metav1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
 
 	if len(deployment.Spec.Template.Spec.Containers) > 0 {
-		return parseVersionFromImage(deployment.Spec.Template.Spec.Containers[0].Image), nil
+		return ParseVersionFromImage(deployment.Spec.Template.Spec.Containers[0].Image), nil
 	}
 	return "", fmt.Errorf("no containers found in deployment")
 }
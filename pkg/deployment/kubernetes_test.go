@@ -78,10 +78,21 @@ func TestKubernetesStrategy_Rollback(t *testing.T) {
 
 type mockExecutor struct {
 	commands []string
+	outputs  map[string]string
+	failOn   string
 	err      error
 }
 
 func (m *mockExecutor) Command(name string, args ...string) *exec.Cmd {
-	m.commands = append(m.commands, name+" "+strings.Join(args, " "))
+	command := name + " " + strings.Join(args, " ")
+	m.commands = append(m.commands, command)
+	if m.err != nil || (m.failOn != "" && strings.HasPrefix(command, m.failOn)) {
+		return exec.Command("false")
+	}
+	for prefix, output := range m.outputs {
+		if strings.HasPrefix(command, prefix) {
+			return exec.Command("printf", "%s", output)
+		}
+	}
 	return exec.Command("echo", "mock")
 }
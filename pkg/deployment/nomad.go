@@ -0,0 +1,361 @@
+package deployment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type NomadConfig struct {
+	Address       string
+	Token         string
+	Namespace     string
+	Region        string
+	JobID         string
+	TaskGroup     string
+	Task          string
+	ImageTemplate string
+	HealthTimeout time.Duration
+	PollInterval  time.Duration
+}
+
+type NomadJobVersion struct {
+	Version    uint64
+	Image      string
+	Stable     bool
+	SubmitTime time.Time
+}
+
+type NomadStrategy struct {
+	config NomadConfig
+	client *http.Client
+}
+
+type nomadDeployment struct {
+	ID                string
+	JobVersion        uint64
+	Status            string
+	StatusDescription string
+}
+
+func NewNomadStrategy(config NomadConfig) *NomadStrategy {
+	return NewNomadStrategyWithClient(config, &http.Client{Timeout: 30 * time.Second})
+}
+
+func NewNomadStrategyWithClient(config NomadConfig, client *http.Client) *NomadStrategy {
+	if config.Address == "" {
+		config.Address = "http://127.0.0.1:4646"
+	}
+	if config.HealthTimeout == 0 {
+		config.HealthTimeout = 5 * time.Minute
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	return &NomadStrategy{
+		config: config,
+		client: client,
+	}
+}
+
+func (n *NomadStrategy) StrategyName() string {
+	return "nomad"
+}
+
+func (n *NomadStrategy) buildImage(version string) string {
+	if n.config.ImageTemplate != "" {
+		return fmt.Sprintf(n.config.ImageTemplate, version)
+	}
+	return fmt.Sprintf("%s:%s", n.config.JobID, version)
+}
+
+func (n *NomadStrategy) endpoint(path string) string {
+	query := url.Values{}
+	if n.config.Namespace != "" {
+		query.Set("namespace", n.config.Namespace)
+	}
+	if n.config.Region != "" {
+		query.Set("region", n.config.Region)
+	}
+	endpoint := strings.TrimRight(n.config.Address, "/") + "/v1/job/" + url.PathEscape(n.config.JobID) + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return endpoint
+}
+
+func (n *NomadStrategy) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, n.endpoint(path), reader)
+	if err != nil {
+		return err
+	}
+	if n.config.Token != "" {
+		req.Header.Set("X-Nomad-Token", n.config.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("nomad %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+func jsonInt(value interface{}) int64 {
+	number, _ := value.(json.Number)
+	n, _ := number.Int64()
+	return n
+}
+
+func createsDeployment(job map[string]interface{}) bool {
+	updates := []interface{}{job["Update"]}
+	groups, _ := job["TaskGroups"].([]interface{})
+	for _, g := range groups {
+		group, _ := g.(map[string]interface{})
+		updates = append(updates, group["Update"])
+	}
+	for _, u := range updates {
+		update, _ := u.(map[string]interface{})
+		if jsonInt(update["MaxParallel"]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *NomadStrategy) getJob(ctx context.Context) (map[string]interface{}, error) {
+	var job map[string]interface{}
+	if err := n.do(ctx, http.MethodGet, "", nil, &job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (n *NomadStrategy) findTask(job map[string]interface{}) (map[string]interface{}, error) {
+	groups, _ := job["TaskGroups"].([]interface{})
+	for _, g := range groups {
+		group, _ := g.(map[string]interface{})
+		if n.config.TaskGroup != "" && group["Name"] != n.config.TaskGroup {
+			continue
+		}
+		tasks, _ := group["Tasks"].([]interface{})
+		for _, t := range tasks {
+			task, _ := t.(map[string]interface{})
+			if n.config.Task != "" && task["Name"] != n.config.Task {
+				continue
+			}
+			return task, nil
+		}
+	}
+	return nil, fmt.Errorf("task %q in group %q not found in job %s", n.config.Task, n.config.TaskGroup, n.config.JobID)
+}
+
+func (n *NomadStrategy) taskImage(job map[string]interface{}) (string, error) {
+	task, err := n.findTask(job)
+	if err != nil {
+		return "", err
+	}
+	config, _ := task["Config"].(map[string]interface{})
+	image, _ := config["image"].(string)
+	if image == "" {
+		return "", fmt.Errorf("task in job %s has no image configured", n.config.JobID)
+	}
+	return image, nil
+}
+
+func (n *NomadStrategy) UpdateImage(ctx context.Context, version string) (uint64, error) {
+	job, err := n.getJob(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	task, err := n.findTask(job)
+	if err != nil {
+		return 0, err
+	}
+	config, ok := task["Config"].(map[string]interface{})
+	if !ok {
+		config = make(map[string]interface{})
+		task["Config"] = config
+	}
+	config["image"] = n.buildImage(version)
+
+	if err := n.do(ctx, http.MethodPost, "", map[string]interface{}{"Job": job}, nil); err != nil {
+		return 0, err
+	}
+
+	updated, err := n.getJob(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(jsonInt(updated["Version"])), nil
+}
+
+func (n *NomadStrategy) ListVersions(ctx context.Context) ([]NomadJobVersion, error) {
+	var resp struct {
+		Versions []map[string]interface{}
+	}
+	if err := n.do(ctx, http.MethodGet, "/versions", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	versions := make([]NomadJobVersion, 0, len(resp.Versions))
+	for _, job := range resp.Versions {
+		stable, _ := job["Stable"].(bool)
+		image, _ := n.taskImage(job)
+		versions = append(versions, NomadJobVersion{
+			Version:    uint64(jsonInt(job["Version"])),
+			Image:      image,
+			Stable:     stable,
+			SubmitTime: time.Unix(0, jsonInt(job["SubmitTime"])),
+		})
+	}
+	return versions, nil
+}
+
+func (n *NomadStrategy) RevertToVersion(ctx context.Context, jobVersion uint64) error {
+	body := map[string]interface{}{
+		"JobID":      n.config.JobID,
+		"JobVersion": jobVersion,
+	}
+	if n.config.Namespace != "" {
+		body["Namespace"] = n.config.Namespace
+	}
+	return n.do(ctx, http.MethodPost, "/revert", body, nil)
+}
+
+func (n *NomadStrategy) WaitForHealthy(ctx context.Context, jobVersion uint64) error {
+	job, err := n.getJob(ctx)
+	if err != nil {
+		return err
+	}
+	waitForDeployment := createsDeployment(job)
+
+	deadline := time.Now().Add(n.config.HealthTimeout)
+	for {
+		var deployment *nomadDeployment
+		if err := n.do(ctx, http.MethodGet, "/deployment", nil, &deployment); err != nil {
+			return err
+		}
+
+		switch {
+		case deployment == nil || deployment.JobVersion < jobVersion:
+			if !waitForDeployment {
+				return nil
+			}
+		case deployment.Status == "successful":
+			return nil
+		case deployment.Status == "failed" || deployment.Status == "cancelled":
+			return fmt.Errorf("nomad deployment %s %s: %s", deployment.ID, deployment.Status, deployment.StatusDescription)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for job %s version %d to become healthy", n.config.JobID, jobVersion)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(n.config.PollInterval):
+		}
+	}
+}
+
+func (n *NomadStrategy) currentJobVersion(ctx context.Context) (uint64, error) {
+	job, err := n.getJob(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(jsonInt(job["Version"])), nil
+}
+
+func (n *NomadStrategy) Rollback(from, to string) error {
+	return n.RollbackContext(context.Background(), from, to)
+}
+
+func (n *NomadStrategy) RollbackContext(ctx context.Context, from, to string) error {
+	versions, err := n.ListVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	image := n.buildImage(to)
+	var match *NomadJobVersion
+	for i := range versions {
+		v := &versions[i]
+		if v.Image != image {
+			continue
+		}
+		if match == nil || (v.Stable && !match.Stable) || (v.Stable == match.Stable && v.Version > match.Version) {
+			match = v
+		}
+	}
+
+	if match == nil {
+		return n.DeployContext(ctx, to)
+	}
+
+	if err := n.RevertToVersion(ctx, match.Version); err != nil {
+		return err
+	}
+	jobVersion, err := n.currentJobVersion(ctx)
+	if err != nil {
+		return err
+	}
+	return n.WaitForHealthy(ctx, jobVersion)
+}
+
+func (n *NomadStrategy) Deploy(version string) error {
+	return n.DeployContext(context.Background(), version)
+}
+
+func (n *NomadStrategy) DeployContext(ctx context.Context, version string) error {
+	jobVersion, err := n.UpdateImage(ctx, version)
+	if err != nil {
+		return err
+	}
+	return n.WaitForHealthy(ctx, jobVersion)
+}
+
+func (n *NomadStrategy) GetCurrentVersion() (string, error) {
+	return n.GetCurrentVersionContext(context.Background())
+}
+
+func (n *NomadStrategy) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	job, err := n.getJob(ctx)
+	if err != nil {
+		return "", err
+	}
+	image, err := n.taskImage(job)
+	if err != nil {
+		return "", err
+	}
+	return ParseVersionFromImage(image), nil
+}
@@ -0,0 +1,247 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeNomad struct {
+	mu               sync.Mutex
+	versions         []map[string]interface{}
+	deploymentStatus string
+	noDeployment     bool
+	reverts          []uint64
+	tokens           []string
+	server           *httptest.Server
+}
+
+func newFakeNomad(t *testing.T, images ...string) *fakeNomad {
+	f := &fakeNomad{deploymentStatus: "successful"}
+	for _, image := range images {
+		f.register(newNomadJob(image))
+	}
+	f.server = httptest.NewServer(f)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeNomad) config() NomadConfig {
+	return NomadConfig{
+		Address:       f.server.URL,
+		Token:         "secret",
+		JobID:         "web",
+		TaskGroup:     "web",
+		Task:          "server",
+		ImageTemplate: "registry.example.com/web:%s",
+		HealthTimeout: time.Second,
+		PollInterval:  time.Millisecond,
+	}
+}
+
+func newNomadJob(image string) map[string]interface{} {
+	return map[string]interface{}{
+		"ID": "web",
+		"TaskGroups": []interface{}{
+			map[string]interface{}{
+				"Name": "web",
+				"Tasks": []interface{}{
+					map[string]interface{}{
+						"Name":   "server",
+						"Driver": "docker",
+						"Config": map[string]interface{}{"image": image},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (f *fakeNomad) register(job map[string]interface{}) {
+	job["Version"] = len(f.versions)
+	job["Stable"] = true
+	job["SubmitTime"] = int64(1700000000123456789) + int64(len(f.versions))
+	f.versions = append(f.versions, job)
+}
+
+func (f *fakeNomad) latest() map[string]interface{} {
+	return f.versions[len(f.versions)-1]
+}
+
+func (f *fakeNomad) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = append(f.tokens, r.Header.Get("X-Nomad-Token"))
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/job/web":
+		_ = json.NewEncoder(w).Encode(f.latest())
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/job/web":
+		var body struct {
+			Job map[string]interface{}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.register(body.Job)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"EvalID": "eval"})
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/job/web/versions":
+		versions := make([]map[string]interface{}, 0, len(f.versions))
+		for i := len(f.versions) - 1; i >= 0; i-- {
+			versions = append(versions, f.versions[i])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"Versions": versions})
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/job/web/revert":
+		var body struct {
+			JobVersion uint64
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.reverts = append(f.reverts, body.JobVersion)
+		data, _ := json.Marshal(f.versions[body.JobVersion])
+		var job map[string]interface{}
+		_ = json.Unmarshal(data, &job)
+		f.register(job)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"EvalID": "eval"})
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/job/web/deployment":
+		if f.noDeployment {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ID": "old", "JobVersion": 0, "Status": "running"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"ID":         "deploy",
+			"JobVersion": len(f.versions) - 1,
+			"Status":     f.deploymentStatus,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestNomadStrategy_Deploy(t *testing.T) {
+	fake := newFakeNomad(t, "registry.example.com/web:v1.0.0")
+	n := NewNomadStrategyWithClient(fake.config(), fake.server.Client())
+
+	if err := n.Deploy("v1.1.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+
+	version, err := n.GetCurrentVersion()
+	if err != nil {
+		t.Fatalf("GetCurrentVersion() error = %v", err)
+	}
+	if version != "v1.1.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v1.1.0", version)
+	}
+	if fake.tokens[0] != "secret" {
+		t.Errorf("X-Nomad-Token = %q, want secret", fake.tokens[0])
+	}
+}
+
+func TestNomadStrategy_RollbackRevertsJobVersion(t *testing.T) {
+	fake := newFakeNomad(t, "registry.example.com/web:v1.0.0", "registry.example.com/web:v1.1.0")
+	n := NewNomadStrategyWithClient(fake.config(), fake.server.Client())
+
+	versions, err := n.ListVersions(context.Background())
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("ListVersions() returned %d versions, want 2", len(versions))
+	}
+	if got := versions[0].SubmitTime.UnixNano(); got != 1700000000123456790 {
+		t.Errorf("SubmitTime = %d, want 1700000000123456790", got)
+	}
+
+	if err := n.Rollback("v1.1.0", "v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	if len(fake.reverts) != 1 || fake.reverts[0] != 0 {
+		t.Errorf("reverts = %v, want [0]", fake.reverts)
+	}
+	version, _ := n.GetCurrentVersion()
+	if version != "v1.0.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v1.0.0", version)
+	}
+}
+
+func TestNomadStrategy_RollbackUnknownVersionDeploys(t *testing.T) {
+	fake := newFakeNomad(t, "registry.example.com/web:v1.1.0")
+	n := NewNomadStrategyWithClient(fake.config(), fake.server.Client())
+
+	if err := n.Rollback("v1.1.0", "v0.9.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if len(fake.reverts) != 0 {
+		t.Errorf("reverts = %v, want none", fake.reverts)
+	}
+	version, _ := n.GetCurrentVersion()
+	if version != "v0.9.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v0.9.0", version)
+	}
+}
+
+func TestNomadStrategy_FailedDeployment(t *testing.T) {
+	fake := newFakeNomad(t, "registry.example.com/web:v1.0.0")
+	fake.deploymentStatus = "failed"
+	n := NewNomadStrategyWithClient(fake.config(), fake.server.Client())
+
+	if err := n.Deploy("v1.1.0"); err == nil {
+		t.Error("Deploy() expected error for failed deployment")
+	}
+}
+
+func TestNomadStrategy_WaitWithoutDeployment(t *testing.T) {
+	tests := []struct {
+		name    string
+		update  bool
+		wantErr bool
+	}{
+		{"job without update block", false, false},
+		{"job with update block", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeNomad(t, "registry.example.com/web:v1.0.0")
+			fake.noDeployment = true
+			if tt.update {
+				fake.latest()["Update"] = map[string]interface{}{"MaxParallel": 1}
+			}
+			config := fake.config()
+			config.HealthTimeout = 50 * time.Millisecond
+			n := NewNomadStrategyWithClient(config, fake.server.Client())
+
+			err := n.Deploy("v1.1.0")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Deploy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNomadStrategy_WaitStopsOnCancel(t *testing.T) {
+	fake := newFakeNomad(t, "registry.example.com/web:v1.0.0")
+	fake.deploymentStatus = "running"
+	n := NewNomadStrategyWithClient(fake.config(), fake.server.Client())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := n.DeployContext(ctx, "v1.1.0")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DeployContext() error = %v, want context deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("DeployContext() returned after %s, want it to stop with the context", elapsed)
+	}
+}
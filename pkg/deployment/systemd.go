@@ -0,0 +1,212 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type SystemdConfig struct {
+	ReleasesDir        string
+	CurrentLink        string
+	UnitName           string
+	KeepReleases       int
+	PreSwitchCommands  []string
+	PostSwitchCommands []string
+}
+
+type SystemdStrategy struct {
+	config   SystemdConfig
+	executor Executor
+}
+
+func NewSystemdStrategy(config SystemdConfig) *SystemdStrategy {
+	return NewSystemdStrategyWithExecutor(config, NewExecutor())
+}
+
+func NewSystemdStrategyWithExecutor(config SystemdConfig, executor Executor) *SystemdStrategy {
+	if config.CurrentLink == "" {
+		config.CurrentLink = filepath.Join(filepath.Dir(filepath.Clean(config.ReleasesDir)), "current")
+	}
+	return &SystemdStrategy{
+		config:   config,
+		executor: executor,
+	}
+}
+
+func (s *SystemdStrategy) StrategyName() string {
+	return "systemd"
+}
+
+func (s *SystemdStrategy) releasePath(version string) string {
+	return filepath.Join(s.config.ReleasesDir, version)
+}
+
+func (s *SystemdStrategy) runCommands(ctx context.Context, commands []string, version string) error {
+	for _, command := range commands {
+		cmd := CommandContext(ctx, s.executor, "sh", "-c", command)
+		cmd.Env = append(os.Environ(),
+			"RELEASE_VERSION="+version,
+			"RELEASE_DIR="+s.releasePath(version),
+			"CURRENT_LINK="+s.config.CurrentLink,
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("command %q failed: %w: %s", command, err, output)
+		}
+	}
+	return nil
+}
+
+func (s *SystemdStrategy) switchLink(target string) error {
+	tmp := s.config.CurrentLink + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.config.CurrentLink); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (s *SystemdStrategy) restart(ctx context.Context) error {
+	if s.config.UnitName == "" {
+		return nil
+	}
+	output, err := CommandContext(ctx, s.executor, "systemctl", "restart", s.config.UnitName).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to restart %s: %w: %s", s.config.UnitName, err, output)
+	}
+	return nil
+}
+
+func (s *SystemdStrategy) activate(ctx context.Context, version string) error {
+	target := s.releasePath(version)
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("release %s not found: %w", version, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("release %s is not a directory", target)
+	}
+
+	if err := s.runCommands(ctx, s.config.PreSwitchCommands, version); err != nil {
+		return fmt.Errorf("pre-switch: %w", err)
+	}
+
+	previous, _ := os.Readlink(s.config.CurrentLink)
+	if err := s.switchLink(target); err != nil {
+		return fmt.Errorf("failed to switch %s to %s: %w", s.config.CurrentLink, target, err)
+	}
+
+	if err := s.restart(ctx); err != nil {
+		return s.restore(ctx, previous, err)
+	}
+
+	if err := s.runCommands(ctx, s.config.PostSwitchCommands, version); err != nil {
+		return s.restore(ctx, previous, fmt.Errorf("post-switch: %w", err))
+	}
+
+	return s.pruneReleases(target)
+}
+
+func (s *SystemdStrategy) restore(ctx context.Context, previous string, cause error) error {
+	if previous == "" {
+		return cause
+	}
+	// Restore even when ctx was cancelled, so the unit is not left on a
+	// half-activated release.
+	ctx = context.WithoutCancel(ctx)
+	if err := s.switchLink(previous); err != nil {
+		return fmt.Errorf("%w (restoring %s also failed: %v)", cause, previous, err)
+	}
+	if err := s.restart(ctx); err != nil {
+		return fmt.Errorf("%w (restarting %s also failed: %v)", cause, previous, err)
+	}
+	return cause
+}
+
+func (s *SystemdStrategy) pruneReleases(current string) error {
+	if s.config.KeepReleases <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.config.ReleasesDir)
+	if err != nil {
+		return err
+	}
+
+	type release struct {
+		path    string
+		modTime int64
+	}
+	releases := make([]release, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		releases = append(releases, release{
+			path:    filepath.Join(s.config.ReleasesDir, entry.Name()),
+			modTime: info.ModTime().UnixNano(),
+		})
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		if releases[i].modTime == releases[j].modTime {
+			return releases[i].path > releases[j].path
+		}
+		return releases[i].modTime > releases[j].modTime
+	})
+
+	kept := 1
+	for _, r := range releases {
+		if r.path == current {
+			continue
+		}
+		if kept < s.config.KeepReleases {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(r.path); err != nil {
+			return fmt.Errorf("failed to prune release %s: %w", r.path, err)
+		}
+	}
+	return nil
+}
+
+func (s *SystemdStrategy) Rollback(from, to string) error {
+	return s.RollbackContext(context.Background(), from, to)
+}
+
+func (s *SystemdStrategy) RollbackContext(ctx context.Context, from, to string) error {
+	return s.activate(ctx, to)
+}
+
+func (s *SystemdStrategy) Deploy(version string) error {
+	return s.DeployContext(context.Background(), version)
+}
+
+func (s *SystemdStrategy) DeployContext(ctx context.Context, version string) error {
+	return s.activate(ctx, version)
+}
+
+func (s *SystemdStrategy) GetCurrentVersion() (string, error) {
+	return s.GetCurrentVersionContext(context.Background())
+}
+
+func (s *SystemdStrategy) GetCurrentVersionContext(context.Context) (string, error) {
+	target, err := os.Readlink(s.config.CurrentLink)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
@@ -0,0 +1,150 @@
+package deployment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeReleases(t *testing.T, root string, versions ...string) string {
+	releases := filepath.Join(root, "releases")
+	base := time.Now().Add(-time.Hour)
+	for i, version := range versions {
+		dir := filepath.Join(releases, version)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create release: %v", err)
+		}
+		modTime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatalf("failed to set release time: %v", err)
+		}
+	}
+	return releases
+}
+
+func TestSystemdStrategy_Deploy(t *testing.T) {
+	executor := &mockExecutor{}
+	root := t.TempDir()
+	s := NewSystemdStrategyWithExecutor(SystemdConfig{
+		ReleasesDir:        writeReleases(t, root, "v1.0.0", "v1.1.0"),
+		UnitName:           "app.service",
+		PreSwitchCommands:  []string{"echo pre"},
+		PostSwitchCommands: []string{"echo post"},
+	}, executor)
+
+	if err := s.Deploy("v1.1.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(root, "current"))
+	if err != nil {
+		t.Fatalf("current link missing: %v", err)
+	}
+	if target != filepath.Join(root, "releases", "v1.1.0") {
+		t.Errorf("current -> %s, want releases/v1.1.0", target)
+	}
+
+	want := []string{"sh -c echo pre", "systemctl restart app.service", "sh -c echo post"}
+	if len(executor.commands) != len(want) {
+		t.Fatalf("commands = %v, want %v", executor.commands, want)
+	}
+	for i := range want {
+		if executor.commands[i] != want[i] {
+			t.Errorf("command[%d] = %s, want %s", i, executor.commands[i], want[i])
+		}
+	}
+
+	version, err := s.GetCurrentVersion()
+	if err != nil {
+		t.Fatalf("GetCurrentVersion() error = %v", err)
+	}
+	if version != "v1.1.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v1.1.0", version)
+	}
+}
+
+func TestSystemdStrategy_RollbackMissingRelease(t *testing.T) {
+	s := NewSystemdStrategyWithExecutor(SystemdConfig{
+		ReleasesDir: writeReleases(t, t.TempDir(), "v1.1.0"),
+		UnitName:    "app.service",
+	}, &mockExecutor{})
+
+	if err := s.Rollback("v1.1.0", "v1.0.0"); err == nil {
+		t.Error("Rollback() expected error for missing release")
+	}
+}
+
+func TestSystemdStrategy_RestoresLinkWhenRestartFails(t *testing.T) {
+	s := NewSystemdStrategyWithExecutor(SystemdConfig{
+		ReleasesDir:        writeReleases(t, t.TempDir(), "v1.0.0", "v1.1.0"),
+		UnitName:           "app.service",
+		PreSwitchCommands:  []string{"echo pre"},
+		PostSwitchCommands: []string{"echo post"},
+	}, &mockExecutor{})
+	if err := s.Deploy("v1.1.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+
+	s.executor = &mockExecutor{err: os.ErrInvalid}
+	s.config.PreSwitchCommands = nil
+	if err := s.Rollback("v1.1.0", "v1.0.0"); err == nil {
+		t.Fatal("Rollback() expected restart error")
+	}
+
+	version, _ := s.GetCurrentVersion()
+	if version != "v1.1.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v1.1.0 after failed restart", version)
+	}
+}
+
+func TestSystemdStrategy_RestoresLinkWhenPostSwitchFails(t *testing.T) {
+	s := NewSystemdStrategyWithExecutor(SystemdConfig{
+		ReleasesDir:        writeReleases(t, t.TempDir(), "v1.0.0", "v1.1.0"),
+		UnitName:           "app.service",
+		PreSwitchCommands:  []string{"echo pre"},
+		PostSwitchCommands: []string{"echo post"},
+	}, &mockExecutor{})
+	if err := s.Deploy("v1.1.0"); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+
+	executor := &mockExecutor{failOn: "sh -c echo post"}
+	s.executor = executor
+	if err := s.Rollback("v1.1.0", "v1.0.0"); err == nil {
+		t.Fatal("Rollback() expected post-switch error")
+	}
+
+	version, _ := s.GetCurrentVersion()
+	if version != "v1.1.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v1.1.0 after failed post-switch", version)
+	}
+	if last := executor.commands[len(executor.commands)-1]; last != "systemctl restart app.service" {
+		t.Errorf("last command = %s, want a restart of the restored release", last)
+	}
+}
+
+func TestSystemdStrategy_PrunesOldReleases(t *testing.T) {
+	root := t.TempDir()
+	s := NewSystemdStrategyWithExecutor(SystemdConfig{
+		ReleasesDir: writeReleases(t, root, "v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0"),
+		UnitName:    "app.service",
+	}, &mockExecutor{})
+	s.config.KeepReleases = 2
+
+	if err := s.Rollback("v1.3.0", "v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	for version, wantExists := range map[string]bool{
+		"v1.0.0": true,
+		"v1.1.0": false,
+		"v1.2.0": false,
+		"v1.3.0": true,
+	} {
+		_, err := os.Stat(filepath.Join(root, "releases", version))
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("release %s exists = %v, want %v", version, exists, wantExists)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package deployment
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
+)
+
+type TracedStrategy struct {
+	Strategy
+
+	tracer trace.Tracer
+}
+
+func Trace(strategy Strategy, provider trace.TracerProvider) *TracedStrategy {
+	return &TracedStrategy{
+		Strategy: strategy,
+		tracer:   tracing.Tracer(provider),
+	}
+}
+
+func (t *TracedStrategy) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("deployment.strategy", t.Strategy.StrategyName()))
+	return t.tracer.Start(ctx, "deployment."+operation, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+}
+
+func (t *TracedStrategy) Unwrap() Strategy {
+	return t.Strategy
+}
+
+func (t *TracedStrategy) Rollback(from, to string) error {
+	return t.RollbackContext(context.Background(), from, to)
+}
+
+func (t *TracedStrategy) RollbackContext(ctx context.Context, from, to string) (err error) {
+	ctx, span := t.start(ctx, "rollback", tracing.Versions(from, to)...)
+	defer func() { tracing.End(span, err) }()
+	return RollbackWithContext(ctx, t.Strategy, from, to)
+}
+
+func (t *TracedStrategy) Deploy(version string) error {
+	return t.DeployContext(context.Background(), version)
+}
+
+func (t *TracedStrategy) DeployContext(ctx context.Context, version string) (err error) {
+	ctx, span := t.start(ctx, "deploy", attribute.String("deployment.version", version))
+	defer func() { tracing.End(span, err) }()
+	return DeployWithContext(ctx, t.Strategy, version)
+}
+
+func (t *TracedStrategy) GetCurrentVersion() (string, error) {
+	return t.GetCurrentVersionContext(context.Background())
+}
+
+func (t *TracedStrategy) GetCurrentVersionContext(ctx context.Context) (version string, err error) {
+	ctx, span := t.start(ctx, "get_current_version")
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attribute.String("deployment.version", version))
+		}
+		tracing.End(span, err)
+	}()
+	return CurrentVersionWithContext(ctx, t.Strategy)
+}
@@ -0,0 +1,151 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextStrategy struct {
+	spans []trace.SpanContext
+	err   error
+}
+
+func (c *contextStrategy) Rollback(from, to string) error {
+	return c.RollbackContext(context.Background(), from, to)
+}
+
+func (c *contextStrategy) RollbackContext(ctx context.Context, _, _ string) error {
+	c.spans = append(c.spans, trace.SpanContextFromContext(ctx))
+	return c.err
+}
+
+func (c *contextStrategy) Deploy(version string) error {
+	return c.DeployContext(context.Background(), version)
+}
+
+func (c *contextStrategy) DeployContext(ctx context.Context, _ string) error {
+	c.spans = append(c.spans, trace.SpanContextFromContext(ctx))
+	return c.err
+}
+
+func (c *contextStrategy) GetCurrentVersion() (string, error) {
+	return c.GetCurrentVersionContext(context.Background())
+}
+
+func (c *contextStrategy) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	c.spans = append(c.spans, trace.SpanContextFromContext(ctx))
+	return "v1.0.0", c.err
+}
+
+func (c *contextStrategy) StrategyName() string {
+	return "context"
+}
+
+func TestTracedStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, s *TracedStrategy) error
+		err      error
+		wantSpan string
+	}{
+		{"rollback", func(ctx context.Context, s *TracedStrategy) error { return s.RollbackContext(ctx, "v2", "v1") }, nil, "deployment.rollback"},
+		{"deploy", func(ctx context.Context, s *TracedStrategy) error { return s.DeployContext(ctx, "v2") }, nil, "deployment.deploy"},
+		{"current version", func(ctx context.Context, s *TracedStrategy) error {
+			_, err := s.GetCurrentVersionContext(ctx)
+			return err
+		}, nil, "deployment.get_current_version"},
+		{"failed rollback", func(ctx context.Context, s *TracedStrategy) error { return s.RollbackContext(ctx, "v2", "v1") }, errors.New("boom"), "deployment.rollback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			inner := &contextStrategy{err: tt.err}
+			traced := Trace(inner, provider)
+
+			ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+			err := tt.call(ctx, traced)
+			parent.End()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("call error = %v, want %v", err, tt.err)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 2 || spans[0].Name() != tt.wantSpan {
+				t.Fatalf("ended spans = %v, want %s then parent", spans, tt.wantSpan)
+			}
+			span := spans[0]
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("%s parent = %v, want %v", tt.wantSpan, span.Parent().SpanID(), parent.SpanContext().SpanID())
+			}
+			if len(inner.spans) != 1 || inner.spans[0].SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("strategy saw span %v, want %v", inner.spans, span.SpanContext().SpanID())
+			}
+			wantCode := codes.Unset
+			if tt.err != nil {
+				wantCode = codes.Error
+			}
+			if span.Status().Code != wantCode {
+				t.Errorf("%s status = %v, want %v", tt.wantSpan, span.Status().Code, wantCode)
+			}
+		})
+	}
+}
+
+func TestInstrumentedStrategyPropagatesContext(t *testing.T) {
+	inner := &contextStrategy{}
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+
+	if err := DeployWithContext(ctx, Instrument(inner, nil), "v2"); err != nil {
+		t.Fatalf("DeployWithContext() error = %v", err)
+	}
+	if len(inner.spans) != 1 || inner.spans[0].SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("strategy saw span %v, want %v", inner.spans, span.SpanContext().SpanID())
+	}
+}
+
+func TestStrategiesImplementContextStrategy(t *testing.T) {
+	strategies := []Strategy{
+		NewComposeStrategy(ComposeConfig{}),
+		NewSystemdStrategy(SystemdConfig{}),
+		NewNomadStrategy(NomadConfig{}),
+		NewCustomResourceStrategy(nil, CustomResourceConfig{}),
+	}
+	for _, strategy := range strategies {
+		if _, ok := strategy.(ContextStrategy); !ok {
+			t.Errorf("%s strategy does not implement ContextStrategy", strategy.StrategyName())
+		}
+	}
+}
+
+func TestWrappedStrategies(t *testing.T) {
+	inner := &contextStrategy{}
+	tests := []struct {
+		name             string
+		strategy         Strategy
+		wantInstrumented bool
+		wantTraced       bool
+	}{
+		{"plain", inner, false, false},
+		{"instrumented", Instrument(inner, nil), true, false},
+		{"traced", Trace(inner, nil), false, true},
+		{"traced and instrumented", Trace(Instrument(inner, nil), nil), true, true},
+	}
+	for _, tt := range tests {
+		if got := IsInstrumented(tt.strategy); got != tt.wantInstrumented {
+			t.Errorf("IsInstrumented(%s) = %v, want %v", tt.name, got, tt.wantInstrumented)
+		}
+		if got := IsTraced(tt.strategy); got != tt.wantTraced {
+			t.Errorf("IsTraced(%s) = %v, want %v", tt.name, got, tt.wantTraced)
+		}
+	}
+}
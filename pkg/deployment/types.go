@@ -1,8 +1,66 @@
 package deployment
 
+import "context"
+
 type Strategy interface {
 	Rollback(from, to string) error
 	Deploy(version string) error
 	GetCurrentVersion() (string, error)
 	StrategyName() string
 }
+
+type ContextStrategy interface {
+	Strategy
+	RollbackContext(ctx context.Context, from, to string) error
+	DeployContext(ctx context.Context, version string) error
+	GetCurrentVersionContext(ctx context.Context) (string, error)
+}
+
+func RollbackWithContext(ctx context.Context, strategy Strategy, from, to string) error {
+	if cs, ok := strategy.(ContextStrategy); ok {
+		return cs.RollbackContext(ctx, from, to)
+	}
+	return strategy.Rollback(from, to)
+}
+
+func DeployWithContext(ctx context.Context, strategy Strategy, version string) error {
+	if cs, ok := strategy.(ContextStrategy); ok {
+		return cs.DeployContext(ctx, version)
+	}
+	return strategy.Deploy(version)
+}
+
+func CurrentVersionWithContext(ctx context.Context, strategy Strategy) (string, error) {
+	if cs, ok := strategy.(ContextStrategy); ok {
+		return cs.GetCurrentVersionContext(ctx)
+	}
+	return strategy.GetCurrentVersion()
+}
+
+func IsInstrumented(strategy Strategy) bool {
+	return wraps(strategy, func(s Strategy) bool {
+		_, ok := s.(*InstrumentedStrategy)
+		return ok
+	})
+}
+
+func IsTraced(strategy Strategy) bool {
+	return wraps(strategy, func(s Strategy) bool {
+		_, ok := s.(*TracedStrategy)
+		return ok
+	})
+}
+
+func wraps(strategy Strategy, match func(Strategy) bool) bool {
+	for strategy != nil {
+		if match(strategy) {
+			return true
+		}
+		wrapper, ok := strategy.(interface{ Unwrap() Strategy })
+		if !ok {
+			return false
+		}
+		strategy = wrapper.Unwrap()
+	}
+	return false
+}
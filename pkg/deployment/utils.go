@@ -2,10 +2,13 @@ package deployment
 
 import "strings"
 
-func parseVersionFromImage(image string) string {
-    parts := strings.Split(image, ":")
-    if len(parts) > 1 {
-        return parts[len(parts)-1]
-    }
-    return "latest"
+func ParseVersionFromImage(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	parts := strings.Split(image, ":")
+	if len(parts) > 1 && !strings.Contains(parts[len(parts)-1], "/") {
+		return parts[len(parts)-1]
+	}
+	return "latest"
 }
@@ -0,0 +1,24 @@
+package deployment
+
+import "testing"
+
+func TestParseVersionFromImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx:1.25", "1.25"},
+		{"nginx", "latest"},
+		{"registry.example.com/shop/web:v2", "v2"},
+		{"registry.example.com:5000/shop/web", "latest"},
+		{"registry.example.com:5000/shop/web:v2", "v2"},
+		{"registry/web:v2@sha256:abc", "v2"},
+		{"registry/web@sha256:abc", "latest"},
+	}
+
+	for _, tt := range tests {
+		if got := ParseVersionFromImage(tt.image); got != tt.want {
+			t.Errorf("ParseVersionFromImage(%q) = %q, want %q", tt.image, got, tt.want)
+		}
+	}
+}
@@ -12,6 +12,9 @@ const (
 	ErrorTypeHealthCheck   ErrorType = "HealthCheckError"
 	ErrorTypeConfiguration ErrorType = "ConfigurationError"
 	ErrorTypeNetwork       ErrorType = "NetworkError"
+	ErrorTypeLock          ErrorType = "LockError"
+	ErrorTypeHook          ErrorType = "HookError"
+	ErrorTypeSchema        ErrorType = "SchemaError"
 )
 
 type RollbackError struct {
@@ -28,6 +31,10 @@ func (e *RollbackError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Type, e.Message)
 }
 
+func (e *RollbackError) Unwrap() error {
+	return e.Cause
+}
+
 func NewValidationError(msg string, cause error) *RollbackError {
 	return &RollbackError{
 		Type:    ErrorTypeValidation,
@@ -52,3 +59,29 @@ func NewHealthCheckError(msg string, cause error) *RollbackError {
 		Cause:   cause,
 	}
 }
+
+func NewLockError(msg string, cause error) *RollbackError {
+	return &RollbackError{
+		Type:    ErrorTypeLock,
+		Message: msg,
+		Cause:   cause,
+	}
+}
+
+func NewHookError(msg string, cause error, meta map[string]interface{}) *RollbackError {
+	return &RollbackError{
+		Type:    ErrorTypeHook,
+		Message: msg,
+		Cause:   cause,
+		Meta:    meta,
+	}
+}
+
+func NewSchemaError(msg string, cause error, meta map[string]interface{}) *RollbackError {
+	return &RollbackError{
+		Type:    ErrorTypeSchema,
+		Message: msg,
+		Cause:   cause,
+		Meta:    meta,
+	}
+}
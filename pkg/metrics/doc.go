@@ -0,0 +1,16 @@
+/*
+Package metrics provides a small registry of counters, gauges and histograms
+exposed in the OpenMetrics text format.
+
+The rollback service and deployment strategies register their metrics here
+when RollbackConfig.MetricsEnabled is set.
+
+Basic usage:
+
+	registry := metrics.NewRegistry()
+	deploys := registry.NewCounter("deploys", "Deployments started.", "strategy")
+	deploys.Inc("docker")
+
+	http.Handle("/metrics", registry.Handler())
+*/
+package metrics
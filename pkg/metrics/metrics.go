@@ -0,0 +1,338 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+var DefaultRegistry = NewRegistry()
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+type family interface {
+	name() string
+	kind() metricType
+	write(w *bufio.Writer)
+}
+
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+func (r *Registry) register(name string, kind metricType, create func() family) family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[name]; ok {
+		if existing.kind() != kind {
+			panic(fmt.Sprintf("metric %s already registered as %s", name, existing.kind()))
+		}
+		return existing
+	}
+	f := create()
+	r.families[name] = f
+	return f
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return r.register(name, typeCounter, func() family {
+		return &Counter{series: newSeries(name, help, labels)}
+	}).(*Counter)
+}
+
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return r.register(name, typeGauge, func() family {
+		return &Gauge{series: newSeries(name, help, labels)}
+	}).(*Gauge)
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return r.register(name, typeHistogram, func() family {
+		if len(buckets) == 0 {
+			buckets = DefaultBuckets
+		}
+		sorted := append([]float64(nil), buckets...)
+		sort.Float64s(sorted)
+		return &Histogram{
+			series:     newSeries(name, help, labels),
+			buckets:    sorted,
+			histograms: make(map[string]*histogramValue),
+		}
+	}).(*Histogram)
+}
+
+func (r *Registry) WriteOpenMetrics(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name() < families[j].name()
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteOpenMetrics(w)
+	})
+}
+
+type series struct {
+	mu     sync.Mutex
+	family string
+	help   string
+	labels []string
+	keys   map[string][]string
+	values map[string]float64
+}
+
+func newSeries(name, help string, labels []string) series {
+	return series{
+		family: name,
+		help:   help,
+		labels: labels,
+		keys:   make(map[string][]string),
+		values: make(map[string]float64),
+	}
+}
+
+func (s *series) name() string {
+	return s.family
+}
+
+func (s *series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", s.family, len(s.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	if _, ok := s.keys[key]; !ok {
+		s.keys[key] = append([]string(nil), values...)
+	}
+	return key
+}
+
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *series) header(w *bufio.Writer, kind metricType) {
+	fmt.Fprintf(w, "# TYPE %s %s\n", s.family, kind)
+	if s.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", s.family, escapeHelp(s.help))
+	}
+}
+
+func (s *series) labelString(values []string, extra ...string) string {
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, value := range values {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", s.labels[i], escapeLabel(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extra[i], escapeLabel(extra[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+type Counter struct {
+	series
+}
+
+func (c *Counter) kind() metricType {
+	return typeCounter
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("counter %s cannot decrease", c.family))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[c.key(labelValues)] += value
+}
+
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[strings.Join(labelValues, "\xff")]
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, typeCounter)
+	for _, key := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s_total%s %s\n", c.family, c.labelString(c.keys[key]), formatFloat(c.values[key]))
+	}
+}
+
+type Gauge struct {
+	series
+}
+
+func (g *Gauge) kind() metricType {
+	return typeGauge
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.key(labelValues)] = value
+}
+
+func (g *Gauge) Add(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.key(labelValues)] += value
+}
+
+func (g *Gauge) Delete(labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := strings.Join(labelValues, "\xff")
+	delete(g.keys, key)
+	delete(g.values, key)
+}
+
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.values[strings.Join(labelValues, "\xff")]
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w, typeGauge)
+	for _, key := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.family, g.labelString(g.keys[key]), formatFloat(g.values[key]))
+	}
+}
+
+type histogramValue struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type Histogram struct {
+	series
+	buckets    []float64
+	histograms map[string]*histogramValue
+}
+
+func (h *Histogram) kind() metricType {
+	return typeHistogram
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := h.key(labelValues)
+	hv, ok := h.histograms[key]
+	if !ok {
+		hv = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.histograms[key] = hv
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			hv.counts[i]++
+		}
+	}
+	hv.count++
+	hv.sum += value
+}
+
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hv, ok := h.histograms[strings.Join(labelValues, "\xff")]; ok {
+		return hv.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, typeHistogram)
+	for _, key := range h.sortedKeys() {
+		values := h.keys[key]
+		hv := h.histograms[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.family, h.labelString(values, "le", formatFloat(bound)), hv.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.family, h.labelString(values, "le", "+Inf"), hv.count)
+		fmt.Fprintf(w, "%s_count%s %d\n", h.family, h.labelString(values), hv.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.family, h.labelString(values), formatFloat(hv.sum))
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(value string) string {
+	return helpEscaper.Replace(value)
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("app_requests", "Requests served.", "code")
+	requests.Inc("200")
+	requests.Add(2, "500")
+	version := r.NewGauge("app_version_info", "Running version.", "version")
+	version.Set(1, `v1 "beta"`)
+	latency := r.NewHistogram("app_latency_seconds", "Request latency.", []float64{0.5, 0.1}, "route")
+	latency.Observe(0.05, "/")
+	latency.Observe(0.3, "/")
+	latency.Observe(2, "/")
+
+	var b strings.Builder
+	if err := r.WriteOpenMetrics(&b); err != nil {
+		t.Fatalf("WriteOpenMetrics() error = %v", err)
+	}
+
+	want := `# TYPE app_latency_seconds histogram
+# HELP app_latency_seconds Request latency.
+app_latency_seconds_bucket{route="/",le="0.1"} 1
+app_latency_seconds_bucket{route="/",le="0.5"} 2
+app_latency_seconds_bucket{route="/",le="+Inf"} 3
+app_latency_seconds_count{route="/"} 3
+app_latency_seconds_sum{route="/"} 2.35
+# TYPE app_requests counter
+# HELP app_requests Requests served.
+app_requests_total{code="200"} 1
+app_requests_total{code="500"} 2
+# TYPE app_version_info gauge
+# HELP app_version_info Running version.
+app_version_info{version="v1 \"beta\""} 1
+# EOF
+`
+	if b.String() != want {
+		t.Errorf("WriteOpenMetrics() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRegistryReuse(t *testing.T) {
+	r := NewRegistry()
+	first := r.NewCounter("jobs", "", "queue")
+	second := r.NewCounter("jobs", "", "queue")
+	first.Inc("default")
+	if got := second.Value("default"); got != 1 {
+		t.Errorf("Value() on re-registered counter = %v, want 1", got)
+	}
+
+	gauge := r.NewGauge("current", "", "version")
+	gauge.Set(1, "v1")
+	gauge.Delete("v1")
+	if got := gauge.Value("v1"); got != 0 {
+		t.Errorf("Value() after Delete = %v, want 0", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewGauge() with a counter name did not panic")
+		}
+	}()
+	r.NewGauge("jobs", "")
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("hits", "Hits.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "hits_total 1\n") || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("body = %q, want the hits counter and a trailing EOF", body)
+	}
+}
@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
+)
+
+type SignalProvider interface {
+	Name() string
+	Signals(ctx context.Context, version string) ([]Signal, error)
+}
+
+type Collector struct {
+	service  *Service
+	provider MetricsProvider
+	signals  []SignalProvider
+	interval time.Duration
+	logger   *logging.Logger
+	tracer   trace.Tracer
+}
+
+func NewCollector(service *Service, provider MetricsProvider, interval time.Duration, logger *logging.Logger) *Collector {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if logger == nil {
+		logger = logging.NewLogger("info", false)
+	}
+	return &Collector{
+		service:  service,
+		provider: provider,
+		interval: interval,
+		logger:   logger,
+		tracer:   tracing.Tracer(nil),
+	}
+}
+
+func (c *Collector) SetTracerProvider(provider trace.TracerProvider) {
+	c.tracer = tracing.Tracer(provider)
+}
+
+func (c *Collector) AddSignalProvider(provider SignalProvider) {
+	c.signals = append(c.signals, provider)
+}
+
+func (c *Collector) CollectOnce(ctx context.Context) error {
+	failed := make([]string, 0)
+	for _, version := range c.service.Versions() {
+		if err := c.collect(ctx, version.Number); err != nil {
+			c.logger.Warn().Err(err).Str("version", version.Number).Msg("Failed to collect metrics")
+			failed = append(failed, version.Number)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to collect metrics for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (c *Collector) collect(ctx context.Context, version string) (err error) {
+	ctx, span := c.tracer.Start(ctx, "monitor.collect", trace.WithAttributes(attribute.String("monitor.version", version)))
+	defer func() {
+		if status, statusErr := c.service.CheckHealth(version); statusErr == nil {
+			span.SetAttributes(attribute.String("monitor.status", string(status)))
+		}
+		tracing.End(span, err)
+	}()
+
+	// Metrics and each signal provider are collected independently, so a
+	// failed query or a broken provider cannot hide the signals of the others.
+	errs := make([]error, 0)
+	if c.provider != nil {
+		if err := c.collectMetrics(ctx, version); err != nil {
+			errs = append(errs, fmt.Errorf("metrics: %w", err))
+		}
+	}
+	for _, provider := range c.signals {
+		if err := c.collectSignals(ctx, provider, version); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Collector) collectMetrics(ctx context.Context, version string) (err error) {
+	ctx, span := c.tracer.Start(ctx, "monitor.metrics", trace.WithAttributes(attribute.String("monitor.version", version)))
+	defer func() { tracing.End(span, err) }()
+
+	metrics, err := c.provider.Collect(ctx, version)
+	if err != nil {
+		return err
+	}
+	return c.service.UpdateMetrics(version, metrics)
+}
+
+func (c *Collector) collectSignals(ctx context.Context, provider SignalProvider, version string) (err error) {
+	ctx, span := c.tracer.Start(ctx, "monitor.signals", trace.WithAttributes(
+		attribute.String("monitor.version", version),
+		attribute.String("monitor.provider", provider.Name()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	signals, err := provider.Signals(ctx, version)
+	if err != nil {
+		return fmt.Errorf("%s signals: %w", provider.Name(), err)
+	}
+	span.SetAttributes(attribute.Int("monitor.signals", len(signals)))
+	return c.service.UpdateSignals(version, provider.Name(), signals)
+}
+
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		_ = c.CollectOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultComparisonConfidence = 0.95
+	defaultComparisonSamples    = 5
+)
+
+type ComparisonResult string
+
+const (
+	ComparisonPass         ComparisonResult = "pass"
+	ComparisonFail         ComparisonResult = "fail"
+	ComparisonInconclusive ComparisonResult = "inconclusive"
+)
+
+type ComparisonConfig struct {
+	Confidence float64
+	MinSamples int
+	Metrics    []MetricName
+}
+
+type MetricComparison struct {
+	Metric           MetricName
+	Result           ComparisonResult
+	U                float64
+	Z                float64
+	PValue           float64
+	Confidence       float64
+	CandidateMedian  float64
+	BaselineMedian   float64
+	CandidateSamples int
+	BaselineSamples  int
+}
+
+type Comparison struct {
+	Candidate  string
+	Baseline   string
+	Result     ComparisonResult
+	Confidence float64
+	Metrics    []MetricComparison
+	ComparedAt time.Time
+}
+
+func (c Comparison) Passed() bool {
+	return c.Result == ComparisonPass
+}
+
+func (c Comparison) Failed() bool {
+	return c.Result == ComparisonFail
+}
+
+func (c Comparison) Err() error {
+	if !c.Failed() {
+		return nil
+	}
+	failed := make([]string, 0)
+	for _, m := range c.Metrics {
+		if m.Result == ComparisonFail {
+			failed = append(failed, fmt.Sprintf("%s (%.1f%% confidence)", m.Metric, m.Confidence*100))
+		}
+	}
+	return fmt.Errorf("version %s regressed against %s: %s", c.Candidate, c.Baseline, strings.Join(failed, ", "))
+}
+
+func (s *Service) Compare(candidate, baseline string) (Comparison, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, version := range []string{candidate, baseline} {
+		if _, exists := s.versions[version]; !exists {
+			return Comparison{}, fmt.Errorf("version %s not found", version)
+		}
+	}
+
+	config := s.config.Comparison
+	if config.Confidence <= 0 || config.Confidence >= 1 {
+		config.Confidence = defaultComparisonConfidence
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = defaultComparisonSamples
+	}
+	if len(config.Metrics) == 0 {
+		config.Metrics = AllMetrics
+	}
+
+	now := time.Now()
+	candidateSamples := s.config.windowSamples(s.windows[candidate], now)
+	baselineSamples := s.config.windowSamples(s.windows[baseline], now)
+
+	comparison := Comparison{
+		Candidate:  candidate,
+		Baseline:   baseline,
+		Result:     ComparisonPass,
+		Confidence: config.Confidence,
+		Metrics:    make([]MetricComparison, 0, len(config.Metrics)),
+		ComparedAt: now,
+	}
+	for _, metric := range config.Metrics {
+		result := compareMetric(metric, sampleValues(candidateSamples, metric), sampleValues(baselineSamples, metric), config)
+		comparison.Metrics = append(comparison.Metrics, result)
+
+		switch {
+		case result.Result == ComparisonFail:
+			comparison.Result = ComparisonFail
+		case result.Result == ComparisonInconclusive && comparison.Result == ComparisonPass:
+			comparison.Result = ComparisonInconclusive
+		}
+	}
+	return comparison, nil
+}
+
+func sampleValues(samples []Sample, metric MetricName) []float64 {
+	values := make([]float64, len(samples))
+	for i := range samples {
+		values[i] = metricValue(&samples[i].Metrics, metric)
+	}
+	return values
+}
+
+func compareMetric(metric MetricName, candidate, baseline []float64, config ComparisonConfig) MetricComparison {
+	result := MetricComparison{
+		Metric:           metric,
+		Result:           ComparisonInconclusive,
+		CandidateMedian:  median(candidate),
+		BaselineMedian:   median(baseline),
+		CandidateSamples: len(candidate),
+		BaselineSamples:  len(baseline),
+	}
+	if len(candidate) < config.MinSamples || len(baseline) < config.MinSamples {
+		return result
+	}
+
+	result.U, result.Z, result.PValue = mannWhitneyGreater(candidate, baseline)
+	result.Confidence = 1 - result.PValue
+	if result.PValue < 1-config.Confidence {
+		result.Result = ComparisonFail
+	} else {
+		result.Result = ComparisonPass
+	}
+	return result
+}
+
+// mannWhitneyGreater tests whether x tends to be larger than y, using the
+// normal approximation with tie and continuity corrections. It returns the U
+// statistic of x, the z score and the one-sided p-value.
+func mannWhitneyGreater(x, y []float64) (float64, float64, float64) {
+	type observation struct {
+		value float64
+		fromX bool
+	}
+	n1, n2 := float64(len(x)), float64(len(y))
+	observations := make([]observation, 0, len(x)+len(y))
+	for _, v := range x {
+		observations = append(observations, observation{value: v, fromX: true})
+	}
+	for _, v := range y {
+		observations = append(observations, observation{value: v})
+	}
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].value < observations[j].value
+	})
+
+	var rankSumX, ties float64
+	for i := 0; i < len(observations); {
+		j := i
+		for j < len(observations) && observations[j].value == observations[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if observations[k].fromX {
+				rankSumX += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	u := rankSumX - n1*(n1+1)/2
+	n := n1 + n2
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return u, 0, 1
+	}
+	z := (u - n1*n2/2 - 0.5) / math.Sqrt(variance)
+	return u, z, 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,122 @@
+package monitor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMannWhitneyGreater(t *testing.T) {
+	tests := []struct {
+		name  string
+		x     []float64
+		y     []float64
+		wantU float64
+		wantP float64
+	}{
+		{"x larger", []float64{4, 5, 6, 7, 8}, []float64{1, 2, 3, 4, 5}, 23, 0.0178},
+		{"x smaller", []float64{1, 2, 3}, []float64{4, 5, 6}, 0, 0.9855},
+		{"all tied", []float64{1, 1, 1}, []float64{1, 1, 1}, 4.5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _, p := mannWhitneyGreater(tt.x, tt.y)
+			if u != tt.wantU {
+				t.Errorf("mannWhitneyGreater() U = %v, want %v", u, tt.wantU)
+			}
+			if math.Abs(p-tt.wantP) > 0.001 {
+				t.Errorf("mannWhitneyGreater() p = %.4f, want %.4f", p, tt.wantP)
+			}
+		})
+	}
+}
+
+func recordSeries(s *Service, version string, n int, base time.Time, gen func(i int) Metrics) {
+	for i := 0; i < n; i++ {
+		_ = s.RecordSample(version, Sample{Time: base.Add(time.Duration(i) * time.Second), Metrics: gen(i)})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	noisy := func(center float64, i int) float64 {
+		return center * (0.9 + 0.2*float64(i%7)/6)
+	}
+	seconds := func(v float64) time.Duration {
+		return time.Duration(v * float64(time.Second))
+	}
+	base := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		candidate func(i int) Metrics
+		samples   int
+		want      ComparisonResult
+		wantFail  MetricName
+	}{
+		{
+			name: "same distribution",
+			candidate: func(i int) Metrics {
+				return Metrics{ErrorRate: noisy(0.02, i*3), Latency: seconds(noisy(0.2, i*5))}
+			},
+			samples: 30,
+			want:    ComparisonPass,
+		},
+		{
+			name: "latency regression",
+			candidate: func(i int) Metrics {
+				return Metrics{ErrorRate: noisy(0.02, i*3), Latency: seconds(noisy(0.35, i))}
+			},
+			samples:  30,
+			want:     ComparisonFail,
+			wantFail: MetricLatency,
+		},
+		{
+			name: "too few samples",
+			candidate: func(int) Metrics {
+				return Metrics{ErrorRate: 0.5}
+			},
+			samples: 3,
+			want:    ComparisonInconclusive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(MonitorConfig{
+				Window:     5 * time.Minute,
+				Comparison: ComparisonConfig{Metrics: []MetricName{MetricErrorRate, MetricLatency}},
+			})
+			_ = s.AddVersion("baseline")
+			_ = s.AddVersion("canary")
+			recordSeries(s, "baseline", 30, base, func(i int) Metrics {
+				return Metrics{ErrorRate: noisy(0.02, i), Latency: seconds(noisy(0.2, i))}
+			})
+			recordSeries(s, "canary", tt.samples, base, tt.candidate)
+
+			comparison, err := s.Compare("canary", "baseline")
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if comparison.Result != tt.want {
+				t.Errorf("Compare() = %v, want %v (%+v)", comparison.Result, tt.want, comparison.Metrics)
+			}
+			if (comparison.Err() != nil) != (tt.want == ComparisonFail) {
+				t.Errorf("Comparison.Err() = %v, want error only on fail", comparison.Err())
+			}
+			for _, m := range comparison.Metrics {
+				if tt.wantFail != "" && m.Metric == tt.wantFail {
+					if m.Result != ComparisonFail || m.Confidence < 0.95 {
+						t.Errorf("%s comparison = %v with confidence %.3f, want fail above 0.95", m.Metric, m.Result, m.Confidence)
+					}
+				}
+			}
+		})
+	}
+
+	s := NewService(MonitorConfig{})
+	_ = s.AddVersion("baseline")
+	if _, err := s.Compare("missing", "baseline"); err == nil {
+		t.Error("Compare() with unknown version error = nil, want error")
+	}
+}
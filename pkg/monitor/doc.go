@@ -4,9 +4,18 @@ Package monitor provides health checking and monitoring capabilities.
 It includes functionality for checking application health, collecting metrics,
 and monitoring deployment status across different versions.
 
+A Service is safe for concurrent use: collectors may call UpdateMetrics from
+several goroutines while health is checked elsewhere. Read APIs such as
+GetVersion and Versions return snapshots that callers may modify freely.
+
 Basic usage:
 
-	monitor := monitor.New(config)
-	health := monitor.CheckHealth("v1.0.0")
+	svc := monitor.NewService(config)
+	svc.AddVersion("v1.0.0")
+	svc.UpdateMetrics("v1.0.0", &monitor.Metrics{CPUUsage: 42})
+	status, err := svc.CheckHealth("v1.0.0")
+
+Prometheus collection, pod and Swarm signals, canary comparison, SLOs, error
+tracking and log scanning are described in the repository README.
 */
 package monitor
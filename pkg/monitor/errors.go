@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxErrors           = 100
+	defaultCriticalErrorWindow = 5 * time.Minute
+
+	SignalCriticalErrors = "critical_errors"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityError    = "error"
+	SeverityCritical = "critical"
+)
+
+var fingerprintPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{12,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+type ErrorQuery struct {
+	Severities []string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+func (q ErrorQuery) matches(e Error) bool {
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Timestamp.After(q.Until) {
+		return false
+	}
+	if len(q.Severities) == 0 {
+		return true
+	}
+	for _, severity := range q.Severities {
+		if strings.EqualFold(severity, e.Severity) {
+			return true
+		}
+	}
+	return false
+}
+
+func Fingerprint(message, severity string) string {
+	normalized := strings.ToLower(strings.TrimSpace(message))
+	for _, p := range fingerprintPatterns {
+		normalized = p.pattern.ReplaceAllString(normalized, p.replacement)
+	}
+	sum := sha1.Sum([]byte(strings.ToLower(severity) + "|" + normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *Service) recordError(v *Version, message, severity string, at time.Time) {
+	fingerprint := Fingerprint(message, severity)
+	for i, existing := range v.Errors {
+		if existing.Fingerprint != fingerprint {
+			continue
+		}
+		existing.Message = message
+		existing.Timestamp = at
+		existing.Count++
+		existing.occurrences = s.trackOccurrence(existing, at)
+		copy(v.Errors[i:], v.Errors[i+1:])
+		v.Errors[len(v.Errors)-1] = existing
+		s.pruneErrors(v, at)
+		return
+	}
+
+	e := Error{
+		Message:     message,
+		Timestamp:   at,
+		Severity:    severity,
+		Fingerprint: fingerprint,
+		Count:       1,
+		FirstSeen:   at,
+	}
+	e.occurrences = s.trackOccurrence(e, at)
+	v.Errors = append(v.Errors, e)
+	s.pruneErrors(v, at)
+}
+
+func (s *Service) criticalErrorWindow() time.Duration {
+	if s.config.CriticalErrorWindow > 0 {
+		return s.config.CriticalErrorWindow
+	}
+	return defaultCriticalErrorWindow
+}
+
+func (s *Service) trackOccurrence(e Error, at time.Time) []time.Time {
+	if s.config.CriticalErrors <= 0 || !strings.EqualFold(e.Severity, SeverityCritical) {
+		return nil
+	}
+	cutoff := at.Add(-s.criticalErrorWindow())
+	kept := make([]time.Time, 0, len(e.occurrences)+1)
+	for _, seen := range e.occurrences {
+		if !seen.Before(cutoff) {
+			kept = append(kept, seen)
+		}
+	}
+	return append(kept, at)
+}
+
+func (s *Service) pruneErrors(v *Version, now time.Time) {
+	drop := 0
+	if s.config.ErrorRetention > 0 {
+		cutoff := now.Add(-s.config.ErrorRetention)
+		for drop < len(v.Errors) && v.Errors[drop].Timestamp.Before(cutoff) {
+			drop++
+		}
+	}
+
+	max := s.config.MaxErrors
+	if max <= 0 {
+		max = defaultMaxErrors
+	}
+	if len(v.Errors)-drop > max {
+		drop = len(v.Errors) - max
+	}
+	if drop > 0 {
+		v.Errors = append(v.Errors[:0], v.Errors[drop:]...)
+	}
+}
+
+func (s *Service) RecordError(version, message, severity string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, exists := s.versions[version]
+	if !exists {
+		return fmt.Errorf("version %s not found", version)
+	}
+	if severity == "" {
+		severity = SeverityError
+	}
+
+	s.recordError(v, message, severity, time.Now())
+	v.Status = s.evaluate(v).Status
+	return nil
+}
+
+func (s *Service) Errors(version string, query ErrorQuery) ([]Error, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, exists := s.versions[version]
+	if !exists {
+		return nil, fmt.Errorf("version %s not found", version)
+	}
+
+	errs := make([]Error, 0)
+	for i := len(v.Errors) - 1; i >= 0; i-- {
+		if !query.matches(v.Errors[i]) {
+			continue
+		}
+		errs = append(errs, v.Errors[i])
+		if query.Limit > 0 && len(errs) == query.Limit {
+			break
+		}
+	}
+	return errs, nil
+}
+
+func (s *Service) criticalErrorSignal(v *Version, now time.Time) (Signal, bool) {
+	if s.config.CriticalErrors <= 0 {
+		return Signal{}, false
+	}
+	window := s.criticalErrorWindow()
+
+	cutoff := now.Add(-window)
+	count := 0
+	for _, e := range v.Errors {
+		if !strings.EqualFold(e.Severity, SeverityCritical) {
+			continue
+		}
+		for _, seen := range e.occurrences {
+			if !seen.Before(cutoff) {
+				count++
+			}
+		}
+	}
+	if count < s.config.CriticalErrors {
+		return Signal{}, false
+	}
+	return Signal{
+		Source:     "errors",
+		Name:       SignalCriticalErrors,
+		Status:     StatusError,
+		Value:      float64(count),
+		Message:    fmt.Sprintf("%d critical errors in the last %s", count, window),
+		ObservedAt: now,
+	}, true
+}
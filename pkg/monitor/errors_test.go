@@ -0,0 +1,167 @@
+package monitor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      [2]string
+		wantEqual bool
+	}{
+		{"numbers differ", [2]string{"timeout after 30s on 10.0.0.1", "error"}, [2]string{"timeout after 45s on 10.0.0.2", "error"}, true},
+		{"request ids differ", [2]string{"request 3f2b8c1e-0d4a-4b7e-9c2f-5a6b7c8d9e0f failed", "error"}, [2]string{"request 11111111-2222-3333-4444-555555555555 failed", "error"}, true},
+		{"whitespace and case", [2]string{"Connection  refused", "error"}, [2]string{"connection refused", "ERROR"}, true},
+		{"severity differs", [2]string{"connection refused", "error"}, [2]string{"connection refused", "critical"}, false},
+		{"message differs", [2]string{"connection refused", "error"}, [2]string{"connection reset", "error"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Fingerprint(tt.a[0], tt.a[1])
+			b := Fingerprint(tt.b[0], tt.b[1])
+			if (a == b) != tt.wantEqual {
+				t.Errorf("Fingerprint(%q) = %s, Fingerprint(%q) = %s, want equal %v", tt.a[0], a, tt.b[0], b, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestRecordErrorDeduplicates(t *testing.T) {
+	s := NewService(MonitorConfig{MaxErrors: 3})
+	_ = s.AddVersion("v1")
+
+	for i := 0; i < 1000; i++ {
+		_ = s.RecordError("v1", fmt.Sprintf("request %d failed", i), SeverityError)
+	}
+	errs, _ := s.Errors("v1", ErrorQuery{})
+	if len(errs) != 1 || errs[0].Count != 1000 || errs[0].Message != "request 999 failed" {
+		t.Fatalf("Errors() = %+v, want one record counted 1000 times", errs)
+	}
+
+	for _, msg := range []string{"disk full", "connection refused", "panic: nil map"} {
+		_ = s.RecordError("v1", msg, SeverityCritical)
+	}
+	errs, _ = s.Errors("v1", ErrorQuery{})
+	if len(errs) != 3 || errs[0].Message != "panic: nil map" || errs[2].Message != "disk full" {
+		t.Errorf("Errors() = %+v, want the 3 newest records, newest first", errs)
+	}
+
+	if err := s.RecordError("missing", "boom", SeverityError); err == nil {
+		t.Error("RecordError() for unknown version error = nil, want error")
+	}
+}
+
+func TestErrorQuery(t *testing.T) {
+	now := time.Now()
+	s := NewService(MonitorConfig{})
+	_ = s.AddVersion("v1")
+
+	s.mu.Lock()
+	v := s.versions["v1"]
+	s.recordError(v, "slow response", SeverityWarning, now.Add(-time.Hour))
+	s.recordError(v, "connection refused", SeverityError, now.Add(-30*time.Minute))
+	s.recordError(v, "out of memory", SeverityCritical, now.Add(-10*time.Minute))
+	s.recordError(v, "disk full", SeverityCritical, now.Add(-time.Minute))
+	s.mu.Unlock()
+
+	tests := []struct {
+		name  string
+		query ErrorQuery
+		want  []string
+	}{
+		{"all", ErrorQuery{}, []string{"disk full", "out of memory", "connection refused", "slow response"}},
+		{"critical", ErrorQuery{Severities: []string{SeverityCritical}}, []string{"disk full", "out of memory"}},
+		{"time range", ErrorQuery{Since: now.Add(-45 * time.Minute), Until: now.Add(-5 * time.Minute)}, []string{"out of memory", "connection refused"}},
+		{"limit", ErrorQuery{Limit: 1}, []string{"disk full"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := s.Errors("v1", tt.query)
+			if err != nil {
+				t.Fatalf("Errors() error = %v", err)
+			}
+			got := make([]string, len(errs))
+			for i, e := range errs {
+				got[i] = e.Message
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Errors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorRetention(t *testing.T) {
+	now := time.Now()
+	s := NewService(MonitorConfig{ErrorRetention: time.Hour})
+	_ = s.AddVersion("v1")
+
+	s.mu.Lock()
+	v := s.versions["v1"]
+	s.recordError(v, "old failure", SeverityError, now.Add(-2*time.Hour))
+	s.recordError(v, "recent failure", SeverityError, now)
+	s.mu.Unlock()
+
+	errs, _ := s.Errors("v1", ErrorQuery{})
+	if len(errs) != 1 || errs[0].Message != "recent failure" {
+		t.Errorf("Errors() = %+v, want only the error inside the retention window", errs)
+	}
+}
+
+func TestCriticalErrorThreshold(t *testing.T) {
+	s := NewService(MonitorConfig{
+		CPUThreshold:     100,
+		MemoryThreshold:  100,
+		ErrorThreshold:   1,
+		LatencyThreshold: time.Second,
+		CriticalErrors:   3,
+	})
+	_ = s.AddVersion("v1")
+
+	steps := []struct {
+		message  string
+		severity string
+		want     Status
+	}{
+		{"connection refused", SeverityError, StatusHealthy},
+		{"out of memory in worker 1", SeverityCritical, StatusHealthy},
+		{"out of memory in worker 2", SeverityCritical, StatusHealthy},
+		{"panic: nil map", SeverityCritical, StatusError},
+	}
+
+	for _, step := range steps {
+		_ = s.RecordError("v1", step.message, step.severity)
+		if got, _ := s.CheckHealth("v1"); got != step.want {
+			t.Errorf("CheckHealth() after %q = %v, want %v", step.message, got, step.want)
+		}
+	}
+}
+
+func TestCriticalErrorsOutsideWindow(t *testing.T) {
+	now := time.Now()
+	s := NewService(MonitorConfig{CriticalErrors: 3, CriticalErrorWindow: 5 * time.Minute})
+	_ = s.AddVersion("v1")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.versions["v1"]
+	s.recordError(v, "out of memory in worker 1", SeverityCritical, now.Add(-time.Hour))
+	s.recordError(v, "out of memory in worker 2", SeverityCritical, now.Add(-30*time.Minute))
+	s.recordError(v, "out of memory in worker 3", SeverityCritical, now.Add(-time.Minute))
+	s.recordError(v, "out of memory in worker 4", SeverityCritical, now)
+
+	if signal, ok := s.criticalErrorSignal(v, now); ok {
+		t.Errorf("criticalErrorSignal() = %+v, want no signal for two errors inside the window", signal)
+	}
+
+	s.recordError(v, "out of memory in worker 5", SeverityCritical, now)
+	signal, ok := s.criticalErrorSignal(v, now)
+	if !ok || signal.Value != 3 {
+		t.Errorf("criticalErrorSignal() = %+v, %v, want 3 errors", signal, ok)
+	}
+}
@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	defaultWarningScore  = 0.25
+	defaultCriticalScore = 0.5
+)
+
+func metricValue(metrics *Metrics, metric MetricName) float64 {
+	switch metric {
+	case MetricCPU:
+		return metrics.CPUUsage
+	case MetricMemory:
+		return metrics.MemoryUsage
+	case MetricErrorRate:
+		return metrics.ErrorRate
+	case MetricLatency:
+		return metrics.Latency.Seconds()
+	default:
+		return 0
+	}
+}
+
+func (c MonitorConfig) threshold(metric MetricName) Threshold {
+	if t, ok := c.Thresholds[metric]; ok {
+		return t
+	}
+
+	var critical float64
+	switch metric {
+	case MetricCPU:
+		critical = c.CPUThreshold
+	case MetricMemory:
+		critical = c.MemoryThreshold
+	case MetricErrorRate:
+		critical = c.ErrorThreshold
+	case MetricLatency:
+		critical = c.LatencyThreshold.Seconds()
+	}
+	return Threshold{Critical: critical, Weight: 1}
+}
+
+func (c MonitorConfig) legacyThreshold(metric MetricName) bool {
+	_, ok := c.Thresholds[metric]
+	return !ok
+}
+
+func newBreach(metric MetricName, level Status, value, threshold float64) Breach {
+	b := Breach{
+		Metric:    metric,
+		Level:     level,
+		Value:     value,
+		Threshold: threshold,
+		Excess:    value - threshold,
+	}
+	if threshold != 0 {
+		b.Ratio = value / threshold
+	}
+	return b
+}
+
+func (c MonitorConfig) checkMetric(metric MetricName, value float64) (Breach, bool) {
+	t := c.threshold(metric)
+	criticalEnabled := t.Critical > 0 || c.legacyThreshold(metric)
+	if criticalEnabled && value > t.Critical {
+		return newBreach(metric, StatusError, value, t.Critical), true
+	}
+	if t.Warning > 0 && value > t.Warning {
+		return newBreach(metric, StatusWarning, value, t.Warning), true
+	}
+	return Breach{}, false
+}
+
+func levelScore(level Status) float64 {
+	switch level {
+	case StatusError:
+		return 1
+	case StatusWarning:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+func statusRank(status Status) int {
+	switch status {
+	case StatusError:
+		return 2
+	case StatusWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (c MonitorConfig) aggregate(breaches []Breach) (Status, float64) {
+	levels := make(map[MetricName]Status, len(breaches))
+	for _, b := range breaches {
+		if statusRank(b.Level) > statusRank(levels[b.Metric]) {
+			levels[b.Metric] = b.Level
+		}
+	}
+
+	var score, totalWeight float64
+	for _, metric := range AllMetrics {
+		weight := c.threshold(metric).Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		totalWeight += weight
+		score += weight * levelScore(levels[metric])
+	}
+	if totalWeight > 0 {
+		score /= totalWeight
+	}
+
+	if c.Aggregation == AggregateWeightedScore {
+		warning, critical := c.WarningScore, c.CriticalScore
+		if warning <= 0 {
+			warning = defaultWarningScore
+		}
+		if critical <= 0 {
+			critical = defaultCriticalScore
+		}
+		switch {
+		case score >= critical:
+			return StatusError, score
+		case score >= warning:
+			return StatusWarning, score
+		default:
+			return StatusHealthy, score
+		}
+	}
+
+	status := StatusHealthy
+	for _, level := range levels {
+		if statusRank(level) > statusRank(status) {
+			status = level
+		}
+	}
+	return status, score
+}
+
+func (c MonitorConfig) evaluateMetrics(version string, metrics *Metrics) Verdict {
+	breaches := make([]Breach, 0)
+	for _, metric := range AllMetrics {
+		if b, breached := c.checkMetric(metric, metricValue(metrics, metric)); breached {
+			breaches = append(breaches, b)
+		}
+	}
+	sort.SliceStable(breaches, func(i, j int) bool {
+		return statusRank(breaches[i].Level) > statusRank(breaches[j].Level)
+	})
+
+	status, score := c.aggregate(breaches)
+	return Verdict{
+		Version:     version,
+		Status:      status,
+		Score:       score,
+		Breaches:    breaches,
+		Metrics:     *metrics,
+		EvaluatedAt: time.Now(),
+	}
+}
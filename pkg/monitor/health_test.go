@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func tieredConfig(aggregation Aggregation) MonitorConfig {
+	return MonitorConfig{
+		Aggregation: aggregation,
+		Thresholds: map[MetricName]Threshold{
+			MetricCPU:       {Warning: 70, Critical: 90},
+			MetricMemory:    {Warning: 70, Critical: 90},
+			MetricErrorRate: {Warning: 0.01, Critical: 0.05, Weight: 3},
+			MetricLatency:   {Warning: 0.3, Critical: 1},
+		},
+	}
+}
+
+func TestEvaluateTieredThresholds(t *testing.T) {
+	tests := []struct {
+		name        string
+		aggregation Aggregation
+		metrics     Metrics
+		wantStatus  Status
+		wantBreach  []MetricName
+	}{
+		{
+			name:        "healthy",
+			aggregation: AggregateAnyBreach,
+			metrics:     Metrics{CPUUsage: 50, ErrorRate: 0.001, Latency: 100 * time.Millisecond},
+			wantStatus:  StatusHealthy,
+		},
+		{
+			name:        "warning only",
+			aggregation: AggregateAnyBreach,
+			metrics:     Metrics{CPUUsage: 75, Latency: 100 * time.Millisecond},
+			wantStatus:  StatusWarning,
+			wantBreach:  []MetricName{MetricCPU},
+		},
+		{
+			name:        "critical wins over warning",
+			aggregation: AggregateAnyBreach,
+			metrics:     Metrics{CPUUsage: 75, Latency: 2 * time.Second},
+			wantStatus:  StatusError,
+			wantBreach:  []MetricName{MetricLatency, MetricCPU},
+		},
+		{
+			name:        "weighted light metrics are a warning",
+			aggregation: AggregateWeightedScore,
+			metrics:     Metrics{CPUUsage: 95, MemoryUsage: 95},
+			wantStatus:  StatusWarning,
+			wantBreach:  []MetricName{MetricCPU, MetricMemory},
+		},
+		{
+			name:        "weighted heavy metric is critical",
+			aggregation: AggregateWeightedScore,
+			metrics:     Metrics{ErrorRate: 0.1},
+			wantStatus:  StatusError,
+			wantBreach:  []MetricName{MetricErrorRate},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(tieredConfig(tt.aggregation))
+			_ = s.AddVersion("v1.0.0")
+			metrics := tt.metrics
+			_ = s.UpdateMetrics("v1.0.0", &metrics)
+
+			verdict, err := s.Evaluate("v1.0.0")
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if verdict.Status != tt.wantStatus {
+				t.Errorf("Evaluate().Status = %v, want %v (score %.2f)", verdict.Status, tt.wantStatus, verdict.Score)
+			}
+			if len(verdict.Breaches) != len(tt.wantBreach) {
+				t.Fatalf("Evaluate().Breaches = %+v, want %v", verdict.Breaches, tt.wantBreach)
+			}
+			for i, metric := range tt.wantBreach {
+				if verdict.Breaches[i].Metric != metric {
+					t.Errorf("breach %d metric = %v, want %v", i, verdict.Breaches[i].Metric, metric)
+				}
+			}
+
+			status, _ := s.CheckHealth("v1.0.0")
+			if status != tt.wantStatus {
+				t.Errorf("CheckHealth() = %v, want %v", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestBreachExcess(t *testing.T) {
+	s := NewService(tieredConfig(AggregateAnyBreach))
+	_ = s.AddVersion("v1.0.0")
+	_ = s.UpdateMetrics("v1.0.0", &Metrics{CPUUsage: 99})
+
+	verdict, _ := s.Evaluate("v1.0.0")
+	if len(verdict.Breaches) != 1 {
+		t.Fatalf("expected 1 breach, got %+v", verdict.Breaches)
+	}
+	b := verdict.Breaches[0]
+	if b.Level != StatusError || b.Threshold != 90 || b.Excess != 9 || math.Abs(b.Ratio-1.1) > 1e-9 {
+		t.Errorf("breach = %+v, want critical 99 over 90", b)
+	}
+}
@@ -0,0 +1,307 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+)
+
+const (
+	SignalRestarts      = "restarts"
+	SignalCrashLoop     = "crash_loop"
+	SignalOOMKilled     = "oom_killed"
+	SignalReadiness     = "readiness"
+	SignalWarningEvents = "warning_events"
+)
+
+type PodHealthConfig struct {
+	Namespace          string
+	Deployment         string
+	Container          string
+	RestartWarning     int32
+	RestartCritical    int32
+	MinReadyRatio      float64
+	CriticalReadyRatio float64
+	EventWindow        time.Duration
+	EventWarning       int
+	EventCritical      int
+}
+
+type PodHealthProvider struct {
+	clientset kubernetes.Interface
+	config    PodHealthConfig
+
+	mu       sync.Mutex
+	restarts map[string]map[string]int32
+}
+
+func NewPodHealthProvider(clientset kubernetes.Interface, config PodHealthConfig) *PodHealthProvider {
+	if config.Namespace == "" {
+		config.Namespace = "default"
+	}
+	if config.RestartWarning == 0 {
+		config.RestartWarning = 1
+	}
+	if config.RestartCritical == 0 {
+		config.RestartCritical = 5
+	}
+	if config.MinReadyRatio == 0 {
+		config.MinReadyRatio = 1
+	}
+	if config.CriticalReadyRatio == 0 {
+		config.CriticalReadyRatio = 0.5
+	}
+	if config.EventWindow == 0 {
+		config.EventWindow = 10 * time.Minute
+	}
+	if config.EventWarning == 0 {
+		config.EventWarning = 1
+	}
+	if config.EventCritical == 0 {
+		config.EventCritical = 10
+	}
+	return &PodHealthProvider{
+		clientset: clientset,
+		config:    config,
+		restarts:  make(map[string]map[string]int32),
+	}
+}
+
+func (p *PodHealthProvider) Name() string {
+	return "kubernetes"
+}
+
+func (p *PodHealthProvider) runsVersion(spec corev1.PodSpec, version string) bool {
+	for _, container := range spec.Containers {
+		if p.config.Container != "" && container.Name != p.config.Container {
+			continue
+		}
+		return deployment.ParseVersionFromImage(container.Image) == version
+	}
+	return false
+}
+
+func ownedBy(meta metav1.ObjectMeta, kind, name string) bool {
+	for _, ref := range meta.OwnerReferences {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PodHealthProvider) ReplicaSet(ctx context.Context, version string) (*appsv1.ReplicaSet, error) {
+	list, err := p.clientset.AppsV1().ReplicaSets(p.config.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replica sets: %w", err)
+	}
+
+	var found *appsv1.ReplicaSet
+	for i := range list.Items {
+		rs := &list.Items[i]
+		if !ownedBy(rs.ObjectMeta, "Deployment", p.config.Deployment) || !p.runsVersion(rs.Spec.Template.Spec, version) {
+			continue
+		}
+		if found == nil || rs.CreationTimestamp.After(found.CreationTimestamp.Time) {
+			found = rs
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no replica set of deployment %s runs version %s", p.config.Deployment, version)
+	}
+	return found, nil
+}
+
+func (p *PodHealthProvider) Pods(ctx context.Context, version string) ([]corev1.Pod, error) {
+	rs, err := p.ReplicaSet(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	hash := rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+	if hash == "" {
+		return nil, fmt.Errorf("replica set %s has no %s label", rs.Name, appsv1.DefaultDeploymentUniqueLabelKey)
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{appsv1.DefaultDeploymentUniqueLabelKey: hash})
+	list, err := p.clientset.CoreV1().Pods(p.config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return list.Items, nil
+}
+
+func (p *PodHealthProvider) Signals(ctx context.Context, version string) ([]Signal, error) {
+	pods, err := p.Pods(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	signal := func(name string, status Status, value float64, message string) Signal {
+		return Signal{Source: p.Name(), Name: name, Status: status, Value: value, Message: message, ObservedAt: now}
+	}
+
+	if len(pods) == 0 {
+		return []Signal{signal(SignalReadiness, StatusError, 0, fmt.Sprintf("no pods found for version %s", version))}, nil
+	}
+
+	counts := make(map[string]int32)
+	ready := 0
+	crashLooping := make([]string, 0)
+	oomKilled := make([]string, 0)
+	names := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		names[pod.Name] = true
+		if podReady(pod) {
+			ready++
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			counts[pod.Name+"/"+status.Name] = status.RestartCount
+			if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+				crashLooping = append(crashLooping, pod.Name+"/"+status.Name)
+			}
+			if terminatedWith(status.State, "OOMKilled") || terminatedWith(status.LastTerminationState, "OOMKilled") {
+				oomKilled = append(oomKilled, pod.Name+"/"+status.Name)
+			}
+		}
+	}
+
+	restarts := p.newRestarts(version, counts)
+	signals := make([]Signal, 0, 5)
+
+	restartStatus := StatusHealthy
+	switch {
+	case restarts >= p.config.RestartCritical:
+		restartStatus = StatusError
+	case restarts >= p.config.RestartWarning:
+		restartStatus = StatusWarning
+	}
+	signals = append(signals, signal(SignalRestarts, restartStatus, float64(restarts), fmt.Sprintf("%d container restarts since last sample", restarts)))
+
+	if len(crashLooping) > 0 {
+		signals = append(signals, signal(SignalCrashLoop, StatusError, float64(len(crashLooping)),
+			fmt.Sprintf("CrashLoopBackOff: %s", strings.Join(crashLooping, ", "))))
+	}
+	if len(oomKilled) > 0 {
+		signals = append(signals, signal(SignalOOMKilled, StatusError, float64(len(oomKilled)),
+			fmt.Sprintf("OOMKilled: %s", strings.Join(oomKilled, ", "))))
+	}
+
+	ratio := float64(ready) / float64(len(pods))
+	readyStatus := StatusHealthy
+	switch {
+	case ratio < p.config.CriticalReadyRatio:
+		readyStatus = StatusError
+	case ratio < p.config.MinReadyRatio:
+		readyStatus = StatusWarning
+	}
+	signals = append(signals, signal(SignalReadiness, readyStatus, ratio, fmt.Sprintf("%d/%d pods ready", ready, len(pods))))
+
+	events, reasons, err := p.warningEvents(ctx, names, now)
+	if err != nil {
+		return nil, err
+	}
+	eventStatus := StatusHealthy
+	switch {
+	case events >= p.config.EventCritical:
+		eventStatus = StatusError
+	case events >= p.config.EventWarning:
+		eventStatus = StatusWarning
+	}
+	message := fmt.Sprintf("%d warning events in the last %s", events, p.config.EventWindow)
+	if len(reasons) > 0 {
+		message += ": " + strings.Join(reasons, ", ")
+	}
+	signals = append(signals, signal(SignalWarningEvents, eventStatus, float64(events), message))
+
+	return signals, nil
+}
+
+// newRestarts returns how many restarts happened since the previous sample of
+// the version. RestartCount is cumulative over a container's lifetime, so
+// summing it would keep reporting restarts that were already seen. Containers
+// seen for the first time count all their restarts.
+func (p *PodHealthProvider) newRestarts(version string, counts map[string]int32) int32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous := p.restarts[version]
+	var restarts int32
+	for container, count := range counts {
+		if last, ok := previous[container]; ok && count >= last {
+			count -= last
+		}
+		restarts += count
+	}
+	p.restarts[version] = counts
+	return restarts
+}
+
+func (p *PodHealthProvider) warningEvents(ctx context.Context, pods map[string]bool, now time.Time) (int, []string, error) {
+	list, err := p.clientset.CoreV1().Events(p.config.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	cutoff := now.Add(-p.config.EventWindow)
+	count := 0
+	seen := make(map[string]bool)
+	reasons := make([]string, 0)
+	for _, event := range list.Items {
+		if event.Type != corev1.EventTypeWarning || event.InvolvedObject.Kind != "Pod" || !pods[event.InvolvedObject.Name] {
+			continue
+		}
+		if eventTime(event).Before(cutoff) {
+			continue
+		}
+		// Count is cumulative since the event first fired. When that was
+		// before the window, only the latest occurrence is known to be inside
+		// it, so the event counts once.
+		occurrences := int(event.Count)
+		if occurrences == 0 || (!event.FirstTimestamp.IsZero() && event.FirstTimestamp.Time.Before(cutoff)) {
+			occurrences = 1
+		}
+		count += occurrences
+		if !seen[event.Reason] {
+			seen[event.Reason] = true
+			reasons = append(reasons, event.Reason)
+		}
+	}
+	sort.Strings(reasons)
+	return count, reasons, nil
+}
+
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func podReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func terminatedWith(state corev1.ContainerState, reason string) bool {
+	return state.Terminated != nil && state.Terminated.Reason == reason
+}
@@ -0,0 +1,276 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testReplicaSet(name, hash, image string, age time.Duration) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "shop",
+			Labels:            map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			OwnerReferences:   []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: image}}},
+			},
+		},
+	}
+}
+
+type podOption func(*corev1.Pod)
+
+func testPod(name, hash string, ready bool, opts ...podOption) *corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "shop",
+			Labels:    map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+		},
+		Status: corev1.PodStatus{
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: readyStatus}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "web"}},
+		},
+	}
+	for _, opt := range opts {
+		opt(pod)
+	}
+	return pod
+}
+
+func withRestarts(n int32) podOption {
+	return func(pod *corev1.Pod) { pod.Status.ContainerStatuses[0].RestartCount = n }
+}
+
+func withCrashLoop() podOption {
+	return func(pod *corev1.Pod) {
+		pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+	}
+}
+
+func withOOMKill() podOption {
+	return func(pod *corev1.Pod) {
+		pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{Reason: "OOMKilled"}
+	}
+}
+
+func testWarningEvent(name, pod, reason string, age time.Duration, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod, Namespace: "shop"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Count:          count,
+		LastTimestamp:  metav1.NewTime(time.Now().Add(-age)),
+	}
+}
+
+func firstSeen(event *corev1.Event, age time.Duration) *corev1.Event {
+	event.FirstTimestamp = metav1.NewTime(time.Now().Add(-age))
+	return event
+}
+
+func signalsByName(signals []Signal) map[string]Signal {
+	byName := make(map[string]Signal, len(signals))
+	for _, signal := range signals {
+		byName[signal.Name] = signal
+	}
+	return byName
+}
+
+func TestPodHealthSignals(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		version string
+		want    map[string]Status
+		wantErr bool
+	}{
+		{
+			name: "healthy pods",
+			objects: []runtime.Object{
+				testReplicaSet("web-old", "aaa", "registry/web:v1", time.Hour),
+				testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+				testPod("web-bbb-1", "bbb", true),
+				testPod("web-bbb-2", "bbb", true),
+				testPod("web-aaa-1", "aaa", false, withCrashLoop()),
+			},
+			version: "v2",
+			want: map[string]Status{
+				SignalRestarts:      StatusHealthy,
+				SignalReadiness:     StatusHealthy,
+				SignalWarningEvents: StatusHealthy,
+			},
+		},
+		{
+			name: "crash looping and oom killed",
+			objects: []runtime.Object{
+				testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+				testPod("web-bbb-1", "bbb", false, withRestarts(6), withCrashLoop()),
+				testPod("web-bbb-2", "bbb", true, withOOMKill(), withRestarts(1)),
+			},
+			version: "v2",
+			want: map[string]Status{
+				SignalRestarts:      StatusError,
+				SignalCrashLoop:     StatusError,
+				SignalOOMKilled:     StatusError,
+				SignalReadiness:     StatusWarning,
+				SignalWarningEvents: StatusHealthy,
+			},
+		},
+		{
+			name: "warning events inside the window",
+			objects: []runtime.Object{
+				testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+				testPod("web-bbb-1", "bbb", false),
+				testPod("web-bbb-2", "bbb", false),
+				testPod("web-bbb-3", "bbb", true),
+				testWarningEvent("e1", "web-bbb-1", "BackOff", time.Minute, 4),
+				testWarningEvent("e2", "web-bbb-2", "Unhealthy", 2*time.Minute, 1),
+				testWarningEvent("e3", "web-bbb-2", "Unhealthy", time.Hour, 50),
+				testWarningEvent("e4", "other-pod", "Failed", time.Minute, 50),
+			},
+			version: "v2",
+			want: map[string]Status{
+				SignalRestarts:      StatusHealthy,
+				SignalReadiness:     StatusError,
+				SignalWarningEvents: StatusWarning,
+			},
+		},
+		{
+			name: "long-running event fired once inside the window",
+			objects: []runtime.Object{
+				testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+				testPod("web-bbb-1", "bbb", true),
+				firstSeen(testWarningEvent("e1", "web-bbb-1", "Unhealthy", time.Minute, 50), 24*time.Hour),
+			},
+			version: "v2",
+			want: map[string]Status{
+				SignalRestarts:      StatusHealthy,
+				SignalReadiness:     StatusHealthy,
+				SignalWarningEvents: StatusWarning,
+			},
+		},
+		{
+			name: "no pods scheduled",
+			objects: []runtime.Object{
+				testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+			},
+			version: "v2",
+			want:    map[string]Status{SignalReadiness: StatusError},
+		},
+		{
+			name: "unknown version",
+			objects: []runtime.Object{
+				testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+			},
+			version: "v3",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewPodHealthProvider(fake.NewSimpleClientset(tt.objects...), PodHealthConfig{
+				Namespace:  "shop",
+				Deployment: "web",
+			})
+
+			signals, err := provider.Signals(context.Background(), tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Signals() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := signalsByName(signals)
+			if len(got) != len(tt.want) {
+				t.Errorf("Signals() = %+v, want %d signals", signals, len(tt.want))
+			}
+			for name, want := range tt.want {
+				if got[name].Status != want {
+					t.Errorf("signal %s = %v (%s), want %v", name, got[name].Status, got[name].Message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPodHealthRestartsSinceLastSample(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+		testPod("web-bbb-1", "bbb", true, withRestarts(2)),
+	)
+	provider := NewPodHealthProvider(clientset, PodHealthConfig{Namespace: "shop", Deployment: "web"})
+
+	samples := []struct {
+		restartCount int32
+		want         float64
+		wantStatus   Status
+	}{
+		{2, 2, StatusWarning},
+		{2, 0, StatusHealthy},
+		{9, 7, StatusError},
+		{9, 0, StatusHealthy},
+	}
+	for i, sample := range samples {
+		pod := testPod("web-bbb-1", "bbb", true, withRestarts(sample.restartCount))
+		if _, err := clientset.CoreV1().Pods("shop").Update(context.Background(), pod, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		signals, err := provider.Signals(context.Background(), "v2")
+		if err != nil {
+			t.Fatalf("Signals() error = %v", err)
+		}
+		got := signalsByName(signals)[SignalRestarts]
+		if got.Value != sample.want || got.Status != sample.wantStatus {
+			t.Errorf("sample %d: restarts = %v (%v), want %v (%v)", i, got.Value, got.Status, sample.want, sample.wantStatus)
+		}
+	}
+}
+
+func TestSignalsFeedCheckHealth(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+		testPod("web-bbb-1", "bbb", true, withCrashLoop()),
+	)
+
+	s := NewService(MonitorConfig{ErrorThreshold: 0.1, CPUThreshold: 90, MemoryThreshold: 90, LatencyThreshold: time.Second})
+	_ = s.AddVersion("v2")
+	_ = s.UpdateMetrics("v2", &Metrics{ErrorRate: 0.01})
+
+	collector := NewCollector(s, nil, time.Minute, nil)
+	collector.AddSignalProvider(NewPodHealthProvider(clientset, PodHealthConfig{Namespace: "shop", Deployment: "web"}))
+	if err := collector.CollectOnce(context.Background()); err != nil {
+		t.Fatalf("CollectOnce() error = %v", err)
+	}
+
+	verdict, _ := s.Evaluate("v2")
+	if verdict.Status != StatusError {
+		t.Errorf("Evaluate() = %v, want %v", verdict.Status, StatusError)
+	}
+	if len(verdict.Signals) != 1 || verdict.Signals[0].Name != SignalCrashLoop {
+		t.Errorf("Evaluate().Signals = %+v, want only %s", verdict.Signals, SignalCrashLoop)
+	}
+
+	_ = s.UpdateSignals("v2", "kubernetes", nil)
+	if status, _ := s.CheckHealth("v2"); status != StatusHealthy {
+		t.Errorf("CheckHealth() after signals cleared = %v, want %v", status, StatusHealthy)
+	}
+}
@@ -0,0 +1,285 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+)
+
+const (
+	defaultLogLookback   = 5 * time.Minute
+	defaultLogRateWindow = time.Minute
+
+	SignalLogErrors = "log_errors"
+)
+
+var DefaultLogRules = []LogRule{
+	{Name: "panic", Pattern: `panic:`, Severity: SeverityCritical},
+	{Name: "fatal", Pattern: `(?i)\bfatal\b`, Severity: SeverityCritical},
+	{Name: "out_of_memory", Pattern: `(?i)out of memory`, Severity: SeverityCritical},
+	{Name: "connection_refused", Pattern: `(?i)connection refused`, Severity: SeverityError},
+}
+
+type LogRule struct {
+	Name       string
+	Pattern    string
+	Severity   string
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+type LogLine struct {
+	Time   time.Time
+	Source string
+	Text   string
+}
+
+type LogSource interface {
+	Lines(ctx context.Context, version string, since time.Time) ([]LogLine, error)
+}
+
+type LogConfig struct {
+	Rules        []LogRule
+	Lookback     time.Duration
+	WarningRate  float64
+	CriticalRate float64
+}
+
+type compiledRule struct {
+	LogRule
+	pattern *regexp.Regexp
+}
+
+type ruleLimit struct {
+	start time.Time
+	count int
+}
+
+type LogProvider struct {
+	source LogSource
+	config LogConfig
+	rules  []compiledRule
+
+	mu       sync.Mutex
+	lastScan map[string]time.Time
+	limits   map[string]*ruleLimit
+}
+
+func NewLogProvider(source LogSource, config LogConfig) (*LogProvider, error) {
+	if len(config.Rules) == 0 {
+		config.Rules = DefaultLogRules
+	}
+	if config.Lookback <= 0 {
+		config.Lookback = defaultLogLookback
+	}
+	if config.WarningRate <= 0 {
+		config.WarningRate = 1
+	}
+	if config.CriticalRate <= 0 {
+		config.CriticalRate = 10
+	}
+
+	rules := make([]compiledRule, 0, len(config.Rules))
+	for _, rule := range config.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for log rule %s: %w", rule.Name, err)
+		}
+		if rule.Severity == "" {
+			rule.Severity = SeverityError
+		}
+		if rule.RateWindow <= 0 {
+			rule.RateWindow = defaultLogRateWindow
+		}
+		rules = append(rules, compiledRule{LogRule: rule, pattern: pattern})
+	}
+
+	return &LogProvider{
+		source:   source,
+		config:   config,
+		rules:    rules,
+		lastScan: make(map[string]time.Time),
+		limits:   make(map[string]*ruleLimit),
+	}, nil
+}
+
+func (p *LogProvider) Name() string {
+	return "logs"
+}
+
+func (p *LogProvider) allow(version string, rule compiledRule, at time.Time) bool {
+	if rule.RateLimit <= 0 {
+		return true
+	}
+	key := version + "/" + rule.Name
+	limit, ok := p.limits[key]
+	if !ok || at.Sub(limit.start) >= rule.RateWindow {
+		limit = &ruleLimit{start: at}
+		p.limits[key] = limit
+	}
+	limit.count++
+	return limit.count <= rule.RateLimit
+}
+
+func (p *LogProvider) Signals(ctx context.Context, version string) ([]Signal, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	since, ok := p.lastScan[version]
+	if !ok {
+		since = now.Add(-p.config.Lookback)
+	}
+
+	lines, err := p.source.Lines(ctx, version, since)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := 0
+	suppressed := 0
+	events := make([]Error, 0)
+	for _, line := range lines {
+		if line.Time.IsZero() {
+			line.Time = now
+		} else if !line.Time.After(since) {
+			continue
+		}
+		for _, rule := range p.rules {
+			if !rule.pattern.MatchString(line.Text) {
+				continue
+			}
+			matches++
+			if !p.allow(version, rule, line.Time) {
+				suppressed++
+				break
+			}
+			message := fmt.Sprintf("log rule %s matched: %s", rule.Name, strings.TrimSpace(line.Text))
+			if line.Source != "" {
+				message = fmt.Sprintf("log rule %s matched in %s: %s", rule.Name, line.Source, strings.TrimSpace(line.Text))
+			}
+			events = append(events, Error{Message: message, Timestamp: line.Time, Severity: rule.Severity})
+			break
+		}
+	}
+	p.lastScan[version] = now
+
+	rate := float64(matches) / now.Sub(since).Minutes()
+	status := StatusHealthy
+	switch {
+	case rate >= p.config.CriticalRate:
+		status = StatusError
+	case rate >= p.config.WarningRate:
+		status = StatusWarning
+	}
+	message := fmt.Sprintf("%d matching log lines (%.2f/min)", matches, rate)
+	if suppressed > 0 {
+		message += fmt.Sprintf(", %d rate limited", suppressed)
+	}
+
+	return []Signal{{
+		Source:     p.Name(),
+		Name:       SignalLogErrors,
+		Status:     status,
+		Value:      rate,
+		Message:    message,
+		ObservedAt: now,
+		Events:     events,
+	}}, nil
+}
+
+func parseLogLines(data []byte, source string) []LogLine {
+	lines := make([]LogLine, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		line := LogLine{Source: source, Text: text}
+		if prefix, rest, ok := strings.Cut(text, " "); ok {
+			if ts, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+				line.Time = ts
+				line.Text = rest
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+type KubernetesLogSource struct {
+	clientset kubernetes.Interface
+	pods      *PodHealthProvider
+	container string
+}
+
+func NewKubernetesLogSource(clientset kubernetes.Interface, config PodHealthConfig) *KubernetesLogSource {
+	return &KubernetesLogSource{
+		clientset: clientset,
+		pods:      NewPodHealthProvider(clientset, config),
+		container: config.Container,
+	}
+}
+
+func (k *KubernetesLogSource) Lines(ctx context.Context, version string, since time.Time) ([]LogLine, error) {
+	pods, err := k.pods.Pods(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]LogLine, 0)
+	for _, pod := range pods {
+		options := &corev1.PodLogOptions{
+			Container:  k.container,
+			Timestamps: true,
+			SinceTime:  &metav1.Time{Time: since},
+		}
+		data, err := k.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read logs of pod %s: %w", pod.Name, err)
+		}
+		lines = append(lines, parseLogLines(data, pod.Name)...)
+	}
+	return lines, nil
+}
+
+type SwarmLogSource struct {
+	tasks *SwarmHealthProvider
+}
+
+func NewSwarmLogSource(executor deployment.Executor, serviceName string) *SwarmLogSource {
+	return &SwarmLogSource{tasks: NewSwarmHealthProvider(executor, SwarmHealthConfig{ServiceName: serviceName})}
+}
+
+func (s *SwarmLogSource) Lines(ctx context.Context, version string, since time.Time) ([]LogLine, error) {
+	tasks, err := s.tasks.Tasks(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]LogLine, 0)
+	for _, task := range tasks {
+		if !strings.EqualFold(task.DesiredState, "running") {
+			continue
+		}
+		data, err := s.tasks.output(ctx, "docker", "service", "logs", "--raw", "--timestamps", "--since", since.UTC().Format(time.RFC3339), task.ID)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, parseLogLines(data, task.Name)...)
+	}
+	return lines, nil
+}
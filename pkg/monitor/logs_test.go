@@ -0,0 +1,167 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type staticLogSource struct {
+	lines  []LogLine
+	since  []time.Time
+	served bool
+}
+
+func (s *staticLogSource) Lines(_ context.Context, _ string, since time.Time) ([]LogLine, error) {
+	s.since = append(s.since, since)
+	if s.served {
+		return nil, nil
+	}
+	s.served = true
+	return s.lines, nil
+}
+
+func TestLogProviderMatchesRules(t *testing.T) {
+	now := time.Now()
+	source := &staticLogSource{lines: []LogLine{
+		{Time: now.Add(-10 * time.Minute), Source: "web-1", Text: "panic: too old to count"},
+		{Time: now.Add(-2 * time.Minute), Source: "web-1", Text: "GET /healthz 200"},
+		{Time: now.Add(-2 * time.Minute), Source: "web-1", Text: "panic: runtime error: invalid memory address"},
+		{Time: now.Add(-time.Minute), Source: "web-2", Text: "dial tcp 10.0.0.1:5432: connection refused"},
+		{Time: now.Add(-time.Minute), Source: "web-2", Text: "dial tcp 10.0.0.2:5432: Connection refused"},
+		{Time: now.Add(-time.Minute), Source: "web-2", Text: "dial tcp 10.0.0.3:5432: connection refused"},
+	}}
+
+	provider, err := NewLogProvider(source, LogConfig{
+		Rules: []LogRule{
+			{Name: "panic", Pattern: `panic:`, Severity: SeverityCritical},
+			{Name: "refused", Pattern: `(?i)connection refused`, Severity: SeverityError, RateLimit: 2},
+		},
+		WarningRate:  0.5,
+		CriticalRate: 5,
+	})
+	if err != nil {
+		t.Fatalf("NewLogProvider() error = %v", err)
+	}
+
+	signals, err := provider.Signals(context.Background(), "v2")
+	if err != nil {
+		t.Fatalf("Signals() error = %v", err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("Signals() = %+v, want one signal", signals)
+	}
+	signal := signals[0]
+	if signal.Status != StatusWarning || !strings.Contains(signal.Message, "4 matching") || !strings.Contains(signal.Message, "1 rate limited") {
+		t.Errorf("Signals() = %v (%s), want warning with 4 matches and 1 rate limited", signal.Status, signal.Message)
+	}
+	if len(signal.Events) != 3 || signal.Events[0].Severity != SeverityCritical || !strings.Contains(signal.Events[0].Message, "web-1") {
+		t.Errorf("Signals().Events = %+v, want 3 events starting with the critical panic", signal.Events)
+	}
+
+	signals, _ = provider.Signals(context.Background(), "v2")
+	if signals[0].Status != StatusHealthy || len(signals[0].Events) != 0 {
+		t.Errorf("second Signals() = %+v, want healthy with no new events", signals[0])
+	}
+	if !source.since[1].After(source.since[0]) {
+		t.Errorf("second scan since %v, want after first scan %v", source.since[1], source.since[0])
+	}
+
+	if _, err := NewLogProvider(source, LogConfig{Rules: []LogRule{{Name: "bad", Pattern: "("}}}); err == nil {
+		t.Error("NewLogProvider() with invalid pattern error = nil, want error")
+	}
+}
+
+func TestLogProviderRecordsErrors(t *testing.T) {
+	source := &staticLogSource{lines: []LogLine{
+		{Text: "panic: assignment to entry in nil map"},
+		{Text: "panic: assignment to entry in nil map"},
+		{Text: "connection refused"},
+	}}
+	provider, err := NewLogProvider(source, LogConfig{})
+	if err != nil {
+		t.Fatalf("NewLogProvider() error = %v", err)
+	}
+
+	s := NewService(MonitorConfig{CPUThreshold: 100, MemoryThreshold: 100, ErrorThreshold: 1, LatencyThreshold: time.Second, CriticalErrors: 2})
+	_ = s.AddVersion("v2")
+	collector := NewCollector(s, nil, time.Minute, nil)
+	collector.AddSignalProvider(provider)
+	if err := collector.CollectOnce(context.Background()); err != nil {
+		t.Fatalf("CollectOnce() error = %v", err)
+	}
+
+	errs, _ := s.Errors("v2", ErrorQuery{Severities: []string{SeverityCritical}})
+	if len(errs) != 1 || errs[0].Count != 2 {
+		t.Errorf("Errors() = %+v, want one deduplicated critical record counted twice", errs)
+	}
+	if status, _ := s.CheckHealth("v2"); status != StatusError {
+		t.Errorf("CheckHealth() = %v, want %v", status, StatusError)
+	}
+}
+
+func TestParseLogLines(t *testing.T) {
+	data := []byte("2026-01-02T03:04:05.123456789Z panic: boom\nplain line without timestamp\n\n")
+	lines := parseLogLines(data, "web-1")
+	if len(lines) != 2 {
+		t.Fatalf("parseLogLines() = %+v, want 2 lines", lines)
+	}
+	if lines[0].Text != "panic: boom" || lines[0].Time.Year() != 2026 || lines[0].Source != "web-1" {
+		t.Errorf("parseLogLines()[0] = %+v, want timestamp stripped", lines[0])
+	}
+	if lines[1].Text != "plain line without timestamp" || !lines[1].Time.IsZero() {
+		t.Errorf("parseLogLines()[1] = %+v, want text kept without timestamp", lines[1])
+	}
+}
+
+func TestLogSources(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		testReplicaSet("web-new", "bbb", "registry/web:v2", time.Minute),
+		testPod("web-bbb-1", "bbb", true),
+	)
+	k8s := NewKubernetesLogSource(clientset, PodHealthConfig{Namespace: "shop", Deployment: "web"})
+	lines, err := k8s.Lines(context.Background(), "v2", time.Now().Add(-time.Minute))
+	if err != nil || len(lines) != 1 || lines[0].Source != "web-bbb-1" {
+		t.Errorf("KubernetesLogSource.Lines() = %+v, %v, want one line from web-bbb-1", lines, err)
+	}
+
+	executor := &mockExecutor{outputs: map[string]string{
+		"docker service ps": swarmTasks(
+			`{"ID":"t2","Name":"web.1","Image":"registry/web:v2","DesiredState":"Running","CurrentState":"Running 1 minute ago"}`,
+			`{"ID":"t1","Name":"web.1","Image":"registry/web:v2","DesiredState":"Shutdown","CurrentState":"Failed 2 minutes ago"}`,
+		),
+		"docker service logs": "2026-01-02T03:04:05Z panic: boom\n",
+	}}
+	swarm := NewSwarmLogSource(executor, "web")
+	lines, err = swarm.Lines(context.Background(), "v2", time.Now().Add(-time.Minute))
+	if err != nil || len(lines) != 1 || lines[0].Text != "panic: boom" {
+		t.Errorf("SwarmLogSource.Lines() = %+v, %v, want the panic line", lines, err)
+	}
+	last := executor.commands[len(executor.commands)-1]
+	if !strings.HasPrefix(last, "docker service logs --raw --timestamps --since ") || !strings.HasSuffix(last, " t2") {
+		t.Errorf("last command = %q, want service logs for running task t2", last)
+	}
+}
+
+func TestSwarmLogSourceStopsWithContext(t *testing.T) {
+	executor := &mockExecutor{
+		outputs: map[string]string{"docker service ps": swarmTasks(
+			`{"ID":"t2","Name":"web.1","Image":"registry/web:v2","DesiredState":"Running","CurrentState":"Running 1 minute ago"}`,
+		)},
+		hang: "docker service logs",
+	}
+	swarm := NewSwarmLogSource(executor, "web")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := swarm.Lines(ctx, "v2", time.Now().Add(-time.Minute)); err == nil {
+		t.Fatal("Lines() error = nil, want the logs command to be killed")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Lines() returned after %s, want it to stop with the context", elapsed)
+	}
+}
@@ -0,0 +1,215 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var ErrNoData = errors.New("query returned no data")
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type MetricsProvider interface {
+	Collect(ctx context.Context, version string) (*Metrics, error)
+}
+
+var DefaultPrometheusQueries = map[MetricName]string{
+	MetricErrorRate: `sum(rate(http_requests_total{namespace="{{.Namespace}}",version="{{.Version}}",code=~"5.."}[5m])) / sum(rate(http_requests_total{namespace="{{.Namespace}}",version="{{.Version}}"}[5m]))`,
+	MetricLatency:   `histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{namespace="{{.Namespace}}",version="{{.Version}}"}[5m])))`,
+	MetricCPU:       `100 * sum(rate(container_cpu_usage_seconds_total{namespace="{{.Namespace}}",version="{{.Version}}",container!=""}[5m])) / sum(kube_pod_container_resource_limits{namespace="{{.Namespace}}",version="{{.Version}}",resource="cpu"})`,
+	MetricMemory:    `100 * sum(container_memory_working_set_bytes{namespace="{{.Namespace}}",version="{{.Version}}",container!=""}) / sum(kube_pod_container_resource_limits{namespace="{{.Namespace}}",version="{{.Version}}",resource="memory"})`,
+}
+
+type PrometheusConfig struct {
+	Address     string
+	Namespace   string
+	BearerToken string
+	Queries     map[MetricName]string
+	Timeout     time.Duration
+}
+
+type PrometheusProvider struct {
+	config    PrometheusConfig
+	client    *http.Client
+	templates map[MetricName]*template.Template
+}
+
+type promQueryData struct {
+	Version   string
+	Namespace string
+}
+
+type promResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+func NewPrometheusProvider(config PrometheusConfig) (*PrometheusProvider, error) {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return NewPrometheusProviderWithClient(config, &http.Client{Timeout: timeout})
+}
+
+func NewPrometheusProviderWithClient(config PrometheusConfig, client *http.Client) (*PrometheusProvider, error) {
+	if config.Address == "" {
+		config.Address = "http://localhost:9090"
+	}
+	if config.Queries == nil {
+		config.Queries = DefaultPrometheusQueries
+	}
+
+	templates := make(map[MetricName]*template.Template, len(config.Queries))
+	for metric, query := range config.Queries {
+		tmpl, err := template.New(string(metric)).Option("missingkey=error").Parse(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s query template: %w", metric, err)
+		}
+		templates[metric] = tmpl
+	}
+
+	return &PrometheusProvider{
+		config:    config,
+		client:    client,
+		templates: templates,
+	}, nil
+}
+
+// Collect runs every configured query for version. A metric whose query has
+// no data, such as an error ratio without traffic or a container without a
+// CPU limit, is left at zero; ErrNoData is returned only when no query had
+// data at all.
+func (p *PrometheusProvider) Collect(ctx context.Context, version string) (*Metrics, error) {
+	metrics := &Metrics{}
+	queried, absent := 0, 0
+	for _, metric := range AllMetrics {
+		tmpl, ok := p.templates[metric]
+		if !ok {
+			continue
+		}
+
+		var query bytes.Buffer
+		if err := tmpl.Execute(&query, promQueryData{
+			Version:   promEscaper.Replace(version),
+			Namespace: promEscaper.Replace(p.config.Namespace),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to render %s query: %w", metric, err)
+		}
+
+		queried++
+		value, err := p.Query(ctx, query.String())
+		if errors.Is(err, ErrNoData) {
+			absent++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s query for version %s: %w", metric, version, err)
+		}
+
+		switch metric {
+		case MetricCPU:
+			metrics.CPUUsage = value
+		case MetricMemory:
+			metrics.MemoryUsage = value
+		case MetricErrorRate:
+			metrics.ErrorRate = value
+		case MetricLatency:
+			metrics.Latency = time.Duration(value * float64(time.Second))
+		}
+	}
+	if queried > 0 && absent == queried {
+		return nil, fmt.Errorf("version %s: %w", version, ErrNoData)
+	}
+	return metrics, nil
+}
+
+func (p *PrometheusProvider) Query(ctx context.Context, query string) (float64, error) {
+	endpoint := strings.TrimRight(p.config.Address, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	if p.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.BearerToken)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+
+	var result promResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("prometheus returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if result.Status != "success" {
+		return 0, fmt.Errorf("prometheus query failed: %s: %s", result.ErrorType, result.Error)
+	}
+	return promValue(result.Data.ResultType, result.Data.Result)
+}
+
+func promValue(resultType string, raw json.RawMessage) (float64, error) {
+	var sample []interface{}
+	switch resultType {
+	case "scalar":
+		if err := json.Unmarshal(raw, &sample); err != nil {
+			return 0, err
+		}
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &vector); err != nil {
+			return 0, err
+		}
+		switch len(vector) {
+		case 0:
+			return 0, ErrNoData
+		case 1:
+			sample = vector[0].Value
+		default:
+			return 0, fmt.Errorf("query returned %d series, expected 1", len(vector))
+		}
+	default:
+		return 0, fmt.Errorf("unsupported result type %q", resultType)
+	}
+
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("malformed sample %v", sample)
+	}
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("malformed sample value %v", sample[1])
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%w: value is %v", ErrNoData, value)
+	}
+	return value, nil
+}
@@ -0,0 +1,278 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stubPrometheus struct {
+	mu      sync.Mutex
+	results map[string]string
+	queries []string
+	auth    []string
+}
+
+func (s *stubPrometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != "/api/v1/query" {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query().Get("query")
+	s.queries = append(s.queries, query)
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+
+	if strings.Contains(query, "broken") {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "error",
+			"errorType": "bad_data",
+			"error":     "parse error",
+		})
+		return
+	}
+
+	result := make([]interface{}, 0)
+	for prefix, value := range s.results {
+		if strings.HasPrefix(query, prefix) {
+			result = append(result, map[string]interface{}{
+				"metric": map[string]string{},
+				"value":  []interface{}{1700000000.0, value},
+			})
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "vector", "result": result},
+	})
+}
+
+func newStubPrometheus(t *testing.T, results map[string]string) (*stubPrometheus, *httptest.Server) {
+	stub := &stubPrometheus{results: results}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+var testQueries = map[MetricName]string{
+	MetricErrorRate: `errors{version="{{.Version}}",namespace="{{.Namespace}}"}`,
+	MetricLatency:   `latency{version="{{.Version}}"}`,
+	MetricCPU:       `cpu{version="{{.Version}}"}`,
+	MetricMemory:    `memory{version="{{.Version}}"}`,
+}
+
+func TestPrometheusCollect(t *testing.T) {
+	stub, server := newStubPrometheus(t, map[string]string{
+		`errors{version="v2"`:  "0.02",
+		`latency{version="v2"`: "0.250",
+		`cpu{version="v2"`:     "61.5",
+		`memory{version="v2"`:  "12.5",
+	})
+
+	provider, err := NewPrometheusProvider(PrometheusConfig{
+		Address:     server.URL,
+		Namespace:   "shop",
+		BearerToken: "secret",
+		Queries:     testQueries,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+
+	metrics, err := provider.Collect(context.Background(), "v2")
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	want := Metrics{CPUUsage: 61.5, MemoryUsage: 12.5, ErrorRate: 0.02, Latency: 250 * time.Millisecond}
+	if *metrics != want {
+		t.Errorf("Collect() = %+v, want %+v", *metrics, want)
+	}
+
+	found := false
+	for i, query := range stub.queries {
+		if query == `errors{version="v2",namespace="shop"}` {
+			found = true
+		}
+		if stub.auth[i] != "Bearer secret" {
+			t.Errorf("query %d Authorization = %q, want %q", i, stub.auth[i], "Bearer secret")
+		}
+	}
+	if !found {
+		t.Errorf("rendered queries %v, want version and namespace labels filled in", stub.queries)
+	}
+}
+
+func TestPrometheusQueryErrors(t *testing.T) {
+	_, server := newStubPrometheus(t, map[string]string{
+		"dup": "1",
+		"du":  "2",
+		"nan": "NaN",
+		"inf": "+Inf",
+	})
+
+	tests := []struct {
+		name       string
+		queries    map[MetricName]string
+		wantErr    bool
+		wantNoData bool
+	}{
+		{"empty result", map[MetricName]string{MetricCPU: "missing"}, true, true},
+		{"every query empty", map[MetricName]string{MetricCPU: "missing", MetricErrorRate: "nan"}, true, true},
+		{"NaN", map[MetricName]string{MetricCPU: "nan"}, true, true},
+		{"Inf", map[MetricName]string{MetricCPU: "inf"}, true, true},
+		{"api error", map[MetricName]string{MetricCPU: "broken"}, true, false},
+		{"multiple series", map[MetricName]string{MetricCPU: "dup"}, true, false},
+		{"unknown field", map[MetricName]string{MetricCPU: "{{.Cluster}}"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewPrometheusProvider(PrometheusConfig{Address: server.URL, Queries: tt.queries})
+			if err != nil {
+				t.Fatalf("NewPrometheusProvider() error = %v", err)
+			}
+			_, err = provider.Collect(context.Background(), "v1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Collect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrNoData) != tt.wantNoData {
+				t.Errorf("Collect() error = %v, want ErrNoData %v", err, tt.wantNoData)
+			}
+		})
+	}
+
+	if _, err := NewPrometheusProvider(PrometheusConfig{Queries: map[MetricName]string{MetricCPU: "{{"}}); err == nil {
+		t.Error("NewPrometheusProvider() with invalid template error = nil, want error")
+	}
+}
+
+func TestPrometheusCollectSkipsMetricsWithoutData(t *testing.T) {
+	_, server := newStubPrometheus(t, map[string]string{
+		`errors{version="v2"`:  "NaN",
+		`latency{version="v2"`: "0.250",
+		`memory{version="v2"`:  "12.5",
+	})
+	provider, err := NewPrometheusProvider(PrometheusConfig{Address: server.URL, Queries: testQueries})
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+
+	metrics, err := provider.Collect(context.Background(), "v2")
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	want := Metrics{MemoryUsage: 12.5, Latency: 250 * time.Millisecond}
+	if *metrics != want {
+		t.Errorf("Collect() = %+v, want %+v", *metrics, want)
+	}
+}
+
+func TestPrometheusEscapesLabelValues(t *testing.T) {
+	stub, server := newStubPrometheus(t, map[string]string{"cpu": "1"})
+	provider, err := NewPrometheusProvider(PrometheusConfig{
+		Address:   server.URL,
+		Namespace: `shop\prod`,
+		Queries:   map[MetricName]string{MetricCPU: `cpu{namespace="{{.Namespace}}",version="{{.Version}}"}`},
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+
+	if _, err := provider.Collect(context.Background(), `v1"} or vector(1) #`); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	want := `cpu{namespace="shop\\prod",version="v1\"} or vector(1) #"}`
+	if len(stub.queries) != 1 || stub.queries[0] != want {
+		t.Errorf("queries = %v, want %s", stub.queries, want)
+	}
+}
+
+func TestCollectorUpdatesService(t *testing.T) {
+	_, server := newStubPrometheus(t, map[string]string{
+		`errors{version="v1"`: "0.5",
+		`errors{version="v2"`: "0.001",
+	})
+	queries := map[MetricName]string{MetricErrorRate: testQueries[MetricErrorRate]}
+	provider, err := NewPrometheusProvider(PrometheusConfig{Address: server.URL, Queries: queries})
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+
+	s := NewService(MonitorConfig{ErrorThreshold: 0.1, CPUThreshold: 90, MemoryThreshold: 90, LatencyThreshold: time.Second})
+	_ = s.AddVersion("v1")
+	_ = s.AddVersion("v2")
+
+	collector := NewCollector(s, provider, time.Millisecond, nil)
+	if err := collector.CollectOnce(context.Background()); err != nil {
+		t.Fatalf("CollectOnce() error = %v", err)
+	}
+
+	tests := []struct {
+		version string
+		want    Status
+	}{
+		{"v1", StatusError},
+		{"v2", StatusHealthy},
+	}
+	for _, tt := range tests {
+		if got, _ := s.CheckHealth(tt.version); got != tt.want {
+			t.Errorf("CheckHealth(%s) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	collector.Run(ctx)
+	if samples, _ := s.Samples("v1"); len(samples) < 2 {
+		t.Errorf("Samples() after Run = %d, want at least 2", len(samples))
+	}
+}
+
+type stubSignals struct {
+	name    string
+	signals []Signal
+	err     error
+}
+
+func (s *stubSignals) Name() string {
+	return s.name
+}
+
+func (s *stubSignals) Signals(context.Context, string) ([]Signal, error) {
+	return s.signals, s.err
+}
+
+func TestCollectorCollectsSourcesIndependently(t *testing.T) {
+	_, server := newStubPrometheus(t, nil)
+	provider, err := NewPrometheusProvider(PrometheusConfig{Address: server.URL, Queries: map[MetricName]string{MetricErrorRate: "broken"}})
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+
+	s := NewService(MonitorConfig{ErrorThreshold: 0.1, CPUThreshold: 90, MemoryThreshold: 90, LatencyThreshold: time.Second})
+	_ = s.AddVersion("v2")
+
+	collector := NewCollector(s, provider, time.Minute, nil)
+	collector.AddSignalProvider(&stubSignals{name: "broken", err: errors.New("api unavailable")})
+	collector.AddSignalProvider(&stubSignals{name: "kubernetes", signals: []Signal{
+		{Source: "kubernetes", Name: SignalCrashLoop, Status: StatusError, Value: 1},
+	}})
+
+	err = collector.CollectOnce(context.Background())
+	if err == nil {
+		t.Fatal("CollectOnce() error = nil, want the metrics and provider failures")
+	}
+	if got, _ := s.CheckHealth("v2"); got != StatusError {
+		t.Errorf("CheckHealth() = %v, want %v from the healthy provider's signals", got, StatusError)
+	}
+}
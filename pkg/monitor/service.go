@@ -2,6 +2,8 @@ package monitor
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -10,24 +12,55 @@ type MonitorConfig struct {
 	MemoryThreshold  float64
 	ErrorThreshold   float64
 	LatencyThreshold time.Duration
+
+	Thresholds    map[MetricName]Threshold
+	Aggregation   Aggregation
+	WarningScore  float64
+	CriticalScore float64
+
+	Window        time.Duration
+	WindowSamples int
+	MinSamples    int
+	Statistics    map[MetricName]Statistic
+
+	Comparison ComparisonConfig
+	SLOs       []SLO
+
+	MaxErrors           int
+	ErrorRetention      time.Duration
+	CriticalErrors      int
+	CriticalErrorWindow time.Duration
 }
 
 type Service struct {
+	mu       sync.RWMutex
 	versions map[string]*Version
+	windows  map[string]*sampleRing
+	slos     map[string]sloTracker
+	signals  map[string]map[string][]Signal
 	config   MonitorConfig
 }
 
 func NewService(config MonitorConfig) *Service {
 	return &Service{
 		versions: make(map[string]*Version),
+		windows:  make(map[string]*sampleRing),
+		slos:     make(map[string]sloTracker),
+		signals:  make(map[string]map[string][]Signal),
 		config:   config,
 	}
 }
 
 func (s *Service) AddVersion(number string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if _, exists := s.versions[number]; exists {
 		return fmt.Errorf("version %s already exists", number)
 	}
+	if err := s.config.Validate(); err != nil {
+		return fmt.Errorf("invalid monitor config: %w", err)
+	}
 
 	s.versions[number] = &Version{
 		Number:      number,
@@ -36,40 +69,212 @@ func (s *Service) AddVersion(number string) error {
 		LastChecked: time.Now(),
 		Errors:      make([]Error, 0),
 	}
+	s.windows[number] = newSampleRing(s.config.WindowSamples)
+	s.slos[number] = newSLOTracker(s.config.SLOs)
+	s.signals[number] = make(map[string][]Signal)
 	return nil
 }
 
 func (s *Service) CheckHealth(version string) (Status, error) {
+	verdict, err := s.Evaluate(version)
+	if err != nil {
+		return "", err
+	}
+	return verdict.Status, nil
+}
+
+func (s *Service) Evaluate(version string) (Verdict, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	v, exists := s.versions[version]
 	if !exists {
-		return "", fmt.Errorf("version %s not found", version)
+		return Verdict{}, fmt.Errorf("version %s not found", version)
+	}
+	return s.evaluate(v), nil
+}
+
+func (s *Service) evaluate(v *Version) Verdict {
+	verdict := s.evaluateMetrics(v)
+
+	sources := make([]string, 0, len(s.signals[v.Number]))
+	for source := range s.signals[v.Number] {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	verdict.Signals = make([]Signal, 0)
+	for _, source := range sources {
+		for _, signal := range s.signals[v.Number][source] {
+			if statusRank(signal.Status) == 0 {
+				continue
+			}
+			verdict.Signals = append(verdict.Signals, signal)
+			if statusRank(signal.Status) > statusRank(verdict.Status) {
+				verdict.Status = signal.Status
+			}
+		}
+	}
+
+	if signal, ok := s.criticalErrorSignal(v, verdict.EvaluatedAt); ok {
+		verdict.Signals = append(verdict.Signals, signal)
+		verdict.Status = StatusError
+	}
+
+	verdict.SLOs = s.evaluateSLOs(v.Number, verdict.EvaluatedAt)
+	for _, slo := range verdict.SLOs {
+		if statusRank(slo.Status) > statusRank(verdict.Status) {
+			verdict.Status = slo.Status
+		}
+	}
+	return verdict
+}
+
+func (s *Service) evaluateMetrics(v *Version) Verdict {
+	if !s.config.windowed() {
+		verdict := s.config.evaluateMetrics(v.Number, v.Metrics)
+		verdict.Samples = 1
+		return verdict
 	}
 
-	metrics := v.Metrics
-	if metrics.CPUUsage > s.config.CPUThreshold ||
-		metrics.MemoryUsage > s.config.MemoryThreshold ||
-		metrics.ErrorRate > s.config.ErrorThreshold ||
-		metrics.Latency > s.config.LatencyThreshold {
-		return StatusError, nil
+	now := time.Now()
+	samples := s.config.windowSamples(s.windows[v.Number], now)
+	if len(samples) == 0 || len(samples) < s.config.MinSamples {
+		return Verdict{
+			Version:     v.Number,
+			Status:      StatusPending,
+			Breaches:    make([]Breach, 0),
+			Samples:     len(samples),
+			EvaluatedAt: now,
+		}
 	}
 
-	return StatusHealthy, nil
+	aggregated := s.config.aggregateSamples(samples)
+	verdict := s.config.evaluateMetrics(v.Number, &aggregated)
+	verdict.Samples = len(samples)
+	return verdict
 }
 
 func (s *Service) UpdateMetrics(version string, metrics *Metrics) error {
+	if metrics == nil {
+		return fmt.Errorf("metrics for version %s are nil", version)
+	}
+	return s.RecordSample(version, Sample{Time: time.Now(), Metrics: *metrics})
+}
+
+func (s *Service) RecordSample(version string, sample Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	v, exists := s.versions[version]
 	if !exists {
 		return fmt.Errorf("version %s not found", version)
 	}
 
-	v.Metrics = metrics
-	v.LastChecked = time.Now()
+	metrics := sample.Metrics
+	v.Metrics = &metrics
+	v.LastChecked = sample.Time
+	s.windows[version].add(sample)
+	s.slos[version].add(s.config.SLOs, sample)
+	v.Status = s.evaluate(v).Status
+	return nil
+}
 
-	status, err := s.CheckHealth(version)
-	if err != nil {
-		return err
+func (s *Service) UpdateSignals(version, source string, signals []Signal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, exists := s.versions[version]
+	if !exists {
+		return fmt.Errorf("version %s not found", version)
+	}
+
+	now := time.Now()
+	previous := make(map[string]Status, len(s.signals[version][source]))
+	for _, signal := range s.signals[version][source] {
+		previous[signal.Name] = signal.Status
+	}
+	for _, signal := range signals {
+		if statusRank(signal.Status) == 0 || previous[signal.Name] == signal.Status {
+			continue
+		}
+		timestamp := signal.ObservedAt
+		if timestamp.IsZero() {
+			timestamp = now
+		}
+		s.recordError(v, fmt.Sprintf("%s: %s", source, signal.Message), string(signal.Status), timestamp)
 	}
 
-	v.Status = status
+	stored := make([]Signal, len(signals))
+	for i, signal := range signals {
+		for _, event := range signal.Events {
+			if event.Timestamp.IsZero() {
+				event.Timestamp = now
+			}
+			s.recordError(v, event.Message, event.Severity, event.Timestamp)
+		}
+		signal.Events = nil
+		stored[i] = signal
+	}
+	s.signals[version][source] = stored
+	v.LastChecked = now
+	v.Status = s.evaluate(v).Status
 	return nil
 }
+
+func (s *Service) GetVersion(version string) (Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, exists := s.versions[version]
+	if !exists {
+		return Version{}, fmt.Errorf("version %s not found", version)
+	}
+	return v.snapshot(), nil
+}
+
+func (s *Service) GetMetrics(version string) (Metrics, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, exists := s.versions[version]
+	if !exists {
+		return Metrics{}, fmt.Errorf("version %s not found", version)
+	}
+	return *v.Metrics, nil
+}
+
+func (s *Service) Samples(version string) ([]Sample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ring, exists := s.windows[version]
+	if !exists {
+		return nil, fmt.Errorf("version %s not found", version)
+	}
+	return s.config.windowSamples(ring, time.Now()), nil
+}
+
+func (s *Service) Versions() []Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := make([]Version, 0, len(s.versions))
+	for _, v := range s.versions {
+		versions = append(versions, v.snapshot())
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Number < versions[j].Number
+	})
+	return versions
+}
+
+func (v *Version) snapshot() Version {
+	c := *v
+	if v.Metrics != nil {
+		metrics := *v.Metrics
+		c.Metrics = &metrics
+	}
+	c.Errors = append([]Error(nil), v.Errors...)
+	return c
+}
@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestService() *Service {
+	return NewService(MonitorConfig{
+		CPUThreshold:     80,
+		MemoryThreshold:  80,
+		ErrorThreshold:   0.05,
+		LatencyThreshold: 500 * time.Millisecond,
+	})
+}
+
+func TestCheckHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics Metrics
+		want    Status
+	}{
+		{
+			name:    "within thresholds",
+			metrics: Metrics{CPUUsage: 40, MemoryUsage: 50, ErrorRate: 0.01, Latency: 100 * time.Millisecond},
+			want:    StatusHealthy,
+		},
+		{
+			name:    "error rate breach",
+			metrics: Metrics{CPUUsage: 40, MemoryUsage: 50, ErrorRate: 0.2, Latency: 100 * time.Millisecond},
+			want:    StatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService()
+			if err := s.AddVersion("v1.0.0"); err != nil {
+				t.Fatalf("AddVersion() error = %v", err)
+			}
+			metrics := tt.metrics
+			if err := s.UpdateMetrics("v1.0.0", &metrics); err != nil {
+				t.Fatalf("UpdateMetrics() error = %v", err)
+			}
+
+			status, err := s.CheckHealth("v1.0.0")
+			if err != nil {
+				t.Fatalf("CheckHealth() error = %v", err)
+			}
+			if status != tt.want {
+				t.Errorf("CheckHealth() = %v, want %v", status, tt.want)
+			}
+
+			v, _ := s.GetVersion("v1.0.0")
+			if v.Status != tt.want {
+				t.Errorf("Version.Status = %v, want %v", v.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotsAreIsolated(t *testing.T) {
+	s := newTestService()
+	_ = s.AddVersion("v1.0.0")
+
+	metrics := &Metrics{CPUUsage: 10}
+	_ = s.UpdateMetrics("v1.0.0", metrics)
+	metrics.CPUUsage = 99
+
+	v, err := s.GetVersion("v1.0.0")
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	if v.Metrics.CPUUsage != 10 {
+		t.Errorf("stored CPUUsage = %v, want 10 after caller mutation", v.Metrics.CPUUsage)
+	}
+
+	v.Metrics.CPUUsage = 50
+	current, _ := s.GetMetrics("v1.0.0")
+	if current.CPUUsage != 10 {
+		t.Errorf("stored CPUUsage = %v, want 10 after snapshot mutation", current.CPUUsage)
+	}
+}
+
+func TestConcurrentIngestion(t *testing.T) {
+	s := newTestService()
+	versions := []string{"v1.0.0", "v1.1.0", "v1.2.0"}
+	for _, v := range versions {
+		_ = s.AddVersion(v)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				version := versions[(i+j)%len(versions)]
+				if err := s.UpdateMetrics(version, &Metrics{CPUUsage: float64(j % 100)}); err != nil {
+					t.Errorf("UpdateMetrics() error = %v", err)
+				}
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if _, err := s.CheckHealth(versions[(i+j)%len(versions)]); err != nil {
+					t.Errorf("CheckHealth() error = %v", err)
+				}
+				_ = s.Versions()
+				if j%50 == 0 {
+					_ = s.AddVersion(fmt.Sprintf("v2.%d.%d", i, j))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(s.Versions()); got != len(versions)+8*4 {
+		t.Errorf("Versions() returned %d versions, want %d", got, len(versions)+8*4)
+	}
+}
@@ -0,0 +1,324 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	defaultSLOWindow    = 30 * 24 * time.Hour
+	sloBucketsPerWindow = 60
+)
+
+type SLOKind string
+
+const (
+	SLOAvailability SLOKind = "availability"
+	SLOLatency      SLOKind = "latency"
+)
+
+type BurnRateAlert struct {
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	BurnRate    float64
+	Severity    Status
+}
+
+var DefaultBurnRateAlerts = []BurnRateAlert{
+	{LongWindow: time.Hour, ShortWindow: 5 * time.Minute, BurnRate: 14.4, Severity: StatusError},
+	{LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, BurnRate: 6, Severity: StatusError},
+	{LongWindow: 3 * 24 * time.Hour, ShortWindow: 6 * time.Hour, BurnRate: 1, Severity: StatusWarning},
+}
+
+type SLO struct {
+	Name             string
+	Kind             SLOKind
+	Target           float64
+	LatencyThreshold time.Duration
+	Window           time.Duration
+	Alerts           []BurnRateAlert
+}
+
+type BurnRate struct {
+	Window   time.Duration
+	Rate     float64
+	Samples  int
+	Coverage float64
+}
+
+type SLOAlert struct {
+	BurnRateAlert
+	Long   BurnRate
+	Short  BurnRate
+	Firing bool
+}
+
+type SLOStatus struct {
+	Name            string
+	Status          Status
+	BudgetRemaining float64
+	Coverage        float64
+	Alerts          []SLOAlert
+}
+
+func (slo SLO) validate() error {
+	if slo.Name == "" {
+		return fmt.Errorf("SLO needs a name")
+	}
+	if slo.Kind != SLOAvailability && slo.Kind != SLOLatency {
+		return fmt.Errorf("SLO %s has unknown kind %q", slo.Name, slo.Kind)
+	}
+	if slo.Target <= 0 || slo.Target >= 1 {
+		return fmt.Errorf("SLO %s target %v must be between 0 and 1", slo.Name, slo.Target)
+	}
+	if slo.Kind == SLOLatency && slo.LatencyThreshold <= 0 {
+		return fmt.Errorf("latency SLO %s needs a latency threshold", slo.Name)
+	}
+	if slo.Window < 0 {
+		return fmt.Errorf("SLO %s window must not be negative", slo.Name)
+	}
+	for _, alert := range slo.Alerts {
+		if alert.LongWindow <= 0 || alert.ShortWindow <= 0 || alert.BurnRate <= 0 {
+			return fmt.Errorf("SLO %s alerts need positive windows and burn rates", slo.Name)
+		}
+	}
+	return nil
+}
+
+func (c MonitorConfig) Validate() error {
+	names := make(map[string]bool, len(c.SLOs))
+	for _, slo := range c.SLOs {
+		if err := slo.validate(); err != nil {
+			return err
+		}
+		if names[slo.Name] {
+			return fmt.Errorf("SLO %s is configured more than once", slo.Name)
+		}
+		names[slo.Name] = true
+	}
+	return nil
+}
+
+func (slo SLO) window() time.Duration {
+	if slo.Window <= 0 {
+		return defaultSLOWindow
+	}
+	return slo.Window
+}
+
+func (slo SLO) alerts() []BurnRateAlert {
+	if len(slo.Alerts) == 0 {
+		return DefaultBurnRateAlerts
+	}
+	return slo.Alerts
+}
+
+func (slo SLO) windows() []time.Duration {
+	seen := map[time.Duration]bool{slo.window(): true}
+	for _, alert := range slo.alerts() {
+		seen[alert.LongWindow] = true
+		seen[alert.ShortWindow] = true
+	}
+	windows := make([]time.Duration, 0, len(seen))
+	for window := range seen {
+		windows = append(windows, window)
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	return windows
+}
+
+func (slo SLO) badFraction(metrics Metrics) float64 {
+	if slo.Kind == SLOLatency {
+		if metrics.Latency > slo.LatencyThreshold {
+			return 1
+		}
+		return 0
+	}
+	return metrics.ErrorRate
+}
+
+// sloSeries aggregates samples into fixed-width time buckets so that long
+// SLO windows are covered without keeping every sample. Each series keeps
+// sloBucketsPerWindow buckets of its window, which bounds the error of a
+// burn rate to about one bucket.
+type sloSeries struct {
+	window  time.Duration
+	width   time.Duration
+	buckets []sloBucket
+	first   time.Time
+}
+
+type sloBucket struct {
+	index int64
+	bad   float64
+	total int
+}
+
+func newSLOSeries(window time.Duration) *sloSeries {
+	width := window / sloBucketsPerWindow
+	if width < time.Second {
+		width = time.Second
+	}
+	return &sloSeries{window: window, width: width, buckets: make([]sloBucket, sloBucketsPerWindow+2)}
+}
+
+func (s *sloSeries) index(at time.Time) int64 {
+	return at.UnixNano() / int64(s.width)
+}
+
+func (s *sloSeries) add(at time.Time, bad float64) {
+	index := s.index(at)
+	bucket := &s.buckets[index%int64(len(s.buckets))]
+	if bucket.index > index && bucket.total > 0 {
+		return
+	}
+	if bucket.index != index {
+		*bucket = sloBucket{index: index}
+	}
+	bucket.bad += bad
+	bucket.total++
+	if s.first.IsZero() || at.Before(s.first) {
+		s.first = at
+	}
+}
+
+func (s *sloSeries) burnRate(slo SLO, window time.Duration, now time.Time) BurnRate {
+	result := BurnRate{Window: window}
+	if s.first.IsZero() || window <= 0 {
+		return result
+	}
+
+	newest := s.index(now)
+	oldest := s.index(now.Add(-window))
+	if limit := newest - int64(len(s.buckets)) + 1; oldest < limit {
+		oldest = limit
+	}
+
+	var bad float64
+	for _, bucket := range s.buckets {
+		if bucket.total > 0 && bucket.index >= oldest && bucket.index <= newest {
+			bad += bucket.bad
+			result.Samples += bucket.total
+		}
+	}
+
+	covered := now.Sub(s.first)
+	if retained := time.Duration(newest-oldest+1) * s.width; covered > retained {
+		covered = retained
+	}
+	result.Coverage = math.Max(0, math.Min(1, float64(covered)/float64(window)))
+	if result.Samples > 0 {
+		result.Rate = bad / float64(result.Samples) / (1 - slo.Target)
+	}
+	return result
+}
+
+// covered reports whether the data spans the whole window, allowing for one
+// bucket of slack at the edge.
+func (b BurnRate) covered() bool {
+	return b.Samples > 0 && b.Coverage >= 1-1.0/sloBucketsPerWindow
+}
+
+type sloTracker map[string][]*sloSeries
+
+func newSLOTracker(slos []SLO) sloTracker {
+	tracker := make(sloTracker, len(slos))
+	for _, slo := range slos {
+		windows := slo.windows()
+		series := make([]*sloSeries, len(windows))
+		for i, window := range windows {
+			series[i] = newSLOSeries(window)
+		}
+		tracker[slo.Name] = series
+	}
+	return tracker
+}
+
+func (t sloTracker) add(slos []SLO, sample Sample) {
+	for _, slo := range slos {
+		bad := slo.badFraction(sample.Metrics)
+		for _, series := range t[slo.Name] {
+			series.add(sample.Time, bad)
+		}
+	}
+}
+
+func (t sloTracker) burnRate(slo SLO, window time.Duration, now time.Time) BurnRate {
+	series := t[slo.Name]
+	if len(series) == 0 {
+		return BurnRate{Window: window}
+	}
+	chosen := series[len(series)-1]
+	for _, candidate := range series {
+		if candidate.window >= window {
+			chosen = candidate
+			break
+		}
+	}
+	return chosen.burnRate(slo, window, now)
+}
+
+func (slo SLO) evaluate(tracker sloTracker, now time.Time) SLOStatus {
+	budget := tracker.burnRate(slo, slo.window(), now)
+	alerts := slo.alerts()
+
+	status := SLOStatus{
+		Name:            slo.Name,
+		Status:          StatusHealthy,
+		BudgetRemaining: 1 - budget.Rate,
+		Coverage:        budget.Coverage,
+		Alerts:          make([]SLOAlert, 0, len(alerts)),
+	}
+	for _, alert := range alerts {
+		a := SLOAlert{
+			BurnRateAlert: alert,
+			Long:          tracker.burnRate(slo, alert.LongWindow, now),
+			Short:         tracker.burnRate(slo, alert.ShortWindow, now),
+		}
+		a.Firing = a.Long.covered() && a.Short.covered() &&
+			a.Long.Rate >= alert.BurnRate && a.Short.Rate >= alert.BurnRate
+		if a.Firing && statusRank(alert.Severity) > statusRank(status.Status) {
+			status.Status = alert.Severity
+		}
+		status.Alerts = append(status.Alerts, a)
+	}
+	return status
+}
+
+func (s *Service) evaluateSLOs(version string, now time.Time) []SLOStatus {
+	statuses := make([]SLOStatus, 0, len(s.config.SLOs))
+	for _, slo := range s.config.SLOs {
+		statuses = append(statuses, slo.evaluate(s.slos[version], now))
+	}
+	return statuses
+}
+
+func (s *Service) EvaluateSLOs(version string) ([]SLOStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.versions[version]; !exists {
+		return nil, fmt.Errorf("version %s not found", version)
+	}
+	return s.evaluateSLOs(version, time.Now()), nil
+}
+
+func (s *Service) BurnRate(version, slo string, window time.Duration) (BurnRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.versions[version]; !exists {
+		return BurnRate{}, fmt.Errorf("version %s not found", version)
+	}
+	if window <= 0 {
+		return BurnRate{}, fmt.Errorf("burn rate window must be positive")
+	}
+	for _, candidate := range s.config.SLOs {
+		if candidate.Name == slo {
+			return s.slos[version].burnRate(candidate, window, time.Now()), nil
+		}
+	}
+	return BurnRate{}, fmt.Errorf("SLO %s not found", slo)
+}
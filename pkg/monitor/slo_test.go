@@ -0,0 +1,221 @@
+package monitor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func sloService(slos ...SLO) *Service {
+	s := NewService(MonitorConfig{
+		CPUThreshold:     100,
+		MemoryThreshold:  100,
+		ErrorThreshold:   1,
+		LatencyThreshold: 10 * time.Second,
+		WindowSamples:    1000,
+		SLOs:             slos,
+	})
+	_ = s.AddVersion("v1")
+	return s
+}
+
+func recordMinutes(s *Service, now time.Time, minutes int, gen func(minutesAgo int) Metrics) {
+	for m := minutes - 1; m >= 0; m-- {
+		_ = s.RecordSample("v1", Sample{Time: now.Add(-time.Duration(m) * time.Minute), Metrics: gen(m)})
+	}
+}
+
+func TestSLOBurnRateAlerts(t *testing.T) {
+	availability := SLO{Name: "availability", Kind: SLOAvailability, Target: 0.999}
+	latency := SLO{Name: "latency", Kind: SLOLatency, Target: 0.99, LatencyThreshold: 300 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		slo        SLO
+		minutes    int
+		gen        func(minutesAgo int) Metrics
+		want       Status
+		wantFiring []float64
+	}{
+		{
+			name:    "sustained fast burn",
+			slo:     availability,
+			minutes: 6 * 60,
+			gen: func(int) Metrics {
+				return Metrics{ErrorRate: 0.02}
+			},
+			want:       StatusError,
+			wantFiring: []float64{14.4, 6},
+		},
+		{
+			name:    "short spike only",
+			slo:     availability,
+			minutes: 6 * 60,
+			gen: func(m int) Metrics {
+				if m < 5 {
+					return Metrics{ErrorRate: 0.05}
+				}
+				return Metrics{}
+			},
+			want: StatusHealthy,
+		},
+		{
+			name:    "recovered after an old incident",
+			slo:     availability,
+			minutes: 3 * 24 * 60,
+			gen: func(m int) Metrics {
+				if m >= 60 && m < 400 {
+					return Metrics{ErrorRate: 0.1}
+				}
+				return Metrics{}
+			},
+			want:       StatusWarning,
+			wantFiring: []float64{1},
+		},
+		{
+			name:    "long window not covered",
+			slo:     availability,
+			minutes: 6 * 60,
+			gen: func(m int) Metrics {
+				if m >= 60 && m < 400 {
+					return Metrics{ErrorRate: 0.1}
+				}
+				return Metrics{}
+			},
+			want: StatusHealthy,
+		},
+		{
+			name:    "slow burn on latency",
+			slo:     latency,
+			minutes: 6 * 60,
+			gen: func(m int) Metrics {
+				if m%10 == 0 {
+					return Metrics{Latency: time.Second}
+				}
+				return Metrics{Latency: 100 * time.Millisecond}
+			},
+			want:       StatusError,
+			wantFiring: []float64{6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sloService(tt.slo)
+			recordMinutes(s, time.Now(), tt.minutes, tt.gen)
+
+			verdict, err := s.Evaluate("v1")
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if verdict.Status != tt.want {
+				t.Errorf("Evaluate() = %v, want %v (%+v)", verdict.Status, tt.want, verdict.SLOs)
+			}
+
+			firing := make([]float64, 0)
+			for _, alert := range verdict.SLOs[0].Alerts {
+				if alert.Firing {
+					firing = append(firing, alert.BurnRate)
+				}
+			}
+			if len(firing) != len(tt.wantFiring) {
+				t.Fatalf("firing alerts = %v, want %v", firing, tt.wantFiring)
+			}
+			for i := range firing {
+				if firing[i] != tt.wantFiring[i] {
+					t.Errorf("firing alerts = %v, want %v", firing, tt.wantFiring)
+				}
+			}
+		})
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	s := sloService(SLO{Name: "availability", Kind: SLOAvailability, Target: 0.99, Window: 24 * time.Hour})
+	recordMinutes(s, time.Now(), 120, func(m int) Metrics {
+		if m < 30 {
+			return Metrics{ErrorRate: 0.04}
+		}
+		return Metrics{}
+	})
+
+	// Samples are bucketed, so a window may pick up one bucket more or less
+	// at its edge.
+	tests := []struct {
+		window       time.Duration
+		wantRate     float64
+		wantSamples  int
+		wantCoverage float64
+	}{
+		{30 * time.Minute, 4, 30, 1},
+		{time.Hour, 2, 60, 1},
+		{2 * time.Hour, 1, 120, 1},
+		{48 * time.Hour, 1, 120, 2.0 / 48},
+	}
+	for _, tt := range tests {
+		got, err := s.BurnRate("v1", "availability", tt.window)
+		if err != nil {
+			t.Fatalf("BurnRate() error = %v", err)
+		}
+		if math.Abs(got.Rate-tt.wantRate) > tt.wantRate/20 || math.Abs(float64(got.Samples-tt.wantSamples)) > float64(tt.wantSamples)/20 {
+			t.Errorf("BurnRate(%s) = %.2f over %d samples, want %.2f over %d", tt.window, got.Rate, got.Samples, tt.wantRate, tt.wantSamples)
+		}
+		if math.Abs(got.Coverage-tt.wantCoverage) > 0.02 {
+			t.Errorf("BurnRate(%s) coverage = %.3f, want %.3f", tt.window, got.Coverage, tt.wantCoverage)
+		}
+	}
+
+	statuses, _ := s.EvaluateSLOs("v1")
+	if len(statuses) != 1 || math.Abs(statuses[0].BudgetRemaining) > 0.05 || math.Abs(statuses[0].Coverage-2.0/24) > 0.02 {
+		t.Errorf("EvaluateSLOs() = %+v, want the budget spent over 2h of a 24h window", statuses)
+	}
+
+	if _, err := s.BurnRate("v1", "missing", time.Hour); err == nil {
+		t.Error("BurnRate() for unknown SLO error = nil, want error")
+	}
+}
+
+func TestSLOLongWindowUsesBuckets(t *testing.T) {
+	s := sloService(SLO{Name: "availability", Kind: SLOAvailability, Target: 0.999, Window: 7 * 24 * time.Hour})
+	now := time.Now()
+	for m := 7*24*60 - 1; m >= 0; m -= 5 {
+		errorRate := 0.0
+		if m >= 6*24*60 {
+			errorRate = 0.007
+		}
+		_ = s.RecordSample("v1", Sample{Time: now.Add(-time.Duration(m) * time.Minute), Metrics: Metrics{ErrorRate: errorRate}})
+	}
+
+	statuses, _ := s.EvaluateSLOs("v1")
+	if len(statuses) != 1 || statuses[0].Coverage < 0.98 || math.Abs(statuses[0].BudgetRemaining) > 0.05 {
+		t.Errorf("EvaluateSLOs() = %+v, want the 7d budget spent by the first day", statuses)
+	}
+}
+
+func TestInvalidSLOsAreRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		slos []SLO
+	}{
+		{"target", []SLO{{Name: "availability", Kind: SLOAvailability, Target: 99.9}}},
+		{"latency threshold", []SLO{{Name: "latency", Kind: SLOLatency, Target: 0.99}}},
+		{"kind", []SLO{{Name: "throughput", Kind: "throughput", Target: 0.99}}},
+		{"alert window", []SLO{{Name: "availability", Kind: SLOAvailability, Target: 0.99, Alerts: []BurnRateAlert{{BurnRate: 2}}}}},
+		{"duplicate", []SLO{
+			{Name: "availability", Kind: SLOAvailability, Target: 0.99},
+			{Name: "availability", Kind: SLOAvailability, Target: 0.999},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := MonitorConfig{SLOs: tt.slos}
+			if err := config.Validate(); err == nil {
+				t.Error("Validate() error = nil, want error")
+			}
+			if err := NewService(config).AddVersion("v1"); err == nil {
+				t.Error("AddVersion() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+)
+
+const (
+	SignalFailedTasks = "failed_tasks"
+	SignalUnhealthy   = "unhealthy_containers"
+)
+
+type SwarmHealthConfig struct {
+	ServiceName          string
+	MinRunningRatio      float64
+	CriticalRatio        float64
+	FailedWarning        int
+	FailedCritical       int
+	RestartLoopTasks     int
+	CheckContainerHealth bool
+}
+
+type SwarmTask struct {
+	ID           string `json:"ID"`
+	Name         string `json:"Name"`
+	Image        string `json:"Image"`
+	Node         string `json:"Node"`
+	DesiredState string `json:"DesiredState"`
+	CurrentState string `json:"CurrentState"`
+	Error        string `json:"Error"`
+}
+
+type SwarmHealthProvider struct {
+	executor deployment.Executor
+	config   SwarmHealthConfig
+}
+
+func NewSwarmHealthProvider(executor deployment.Executor, config SwarmHealthConfig) *SwarmHealthProvider {
+	if executor == nil {
+		executor = deployment.NewExecutor()
+	}
+	if config.MinRunningRatio == 0 {
+		config.MinRunningRatio = 1
+	}
+	if config.CriticalRatio == 0 {
+		config.CriticalRatio = 0.5
+	}
+	if config.FailedWarning == 0 {
+		config.FailedWarning = 1
+	}
+	if config.FailedCritical == 0 {
+		config.FailedCritical = 3
+	}
+	if config.RestartLoopTasks == 0 {
+		config.RestartLoopTasks = 3
+	}
+	return &SwarmHealthProvider{
+		executor: executor,
+		config:   config,
+	}
+}
+
+func (p *SwarmHealthProvider) Name() string {
+	return "swarm"
+}
+
+func (p *SwarmHealthProvider) output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := deployment.CommandContext(ctx, p.executor, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (p *SwarmHealthProvider) Tasks(ctx context.Context, version string) ([]SwarmTask, error) {
+	out, err := p.output(ctx, "docker", "service", "ps", "--no-trunc", "--format", "{{json .}}", p.config.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]SwarmTask, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var task SwarmTask
+		if err := json.Unmarshal([]byte(line), &task); err != nil {
+			return nil, fmt.Errorf("failed to decode task %q: %w", line, err)
+		}
+		if deployment.ParseVersionFromImage(task.Image) == version {
+			task.Name = strings.TrimLeft(task.Name, "\\_ ")
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, scanner.Err()
+}
+
+func (p *SwarmHealthProvider) desiredReplicas(ctx context.Context) (int, error) {
+	out, err := p.output(ctx, "docker", "service", "inspect", "--format", "{{if .Spec.Mode.Replicated}}{{.Spec.Mode.Replicated.Replicas}}{{end}}", p.config.ServiceName)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(out))
+	if value == "" {
+		return -1, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func taskState(task SwarmTask) string {
+	state, _, _ := strings.Cut(task.CurrentState, " ")
+	return strings.ToLower(state)
+}
+
+func (p *SwarmHealthProvider) Signals(ctx context.Context, version string) ([]Signal, error) {
+	tasks, err := p.Tasks(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := p.desiredReplicas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	signal := func(name string, status Status, value float64, message string) Signal {
+		return Signal{Source: p.Name(), Name: name, Status: status, Value: value, Message: message, ObservedAt: now}
+	}
+
+	running := 0
+	scheduled := 0
+	failed := make([]string, 0)
+	failuresBySlot := make(map[string]int)
+	for _, task := range tasks {
+		if strings.EqualFold(task.DesiredState, "running") {
+			scheduled++
+			if taskState(task) == "running" {
+				running++
+			}
+		}
+		switch taskState(task) {
+		case "failed", "rejected":
+			message := task.Name
+			if task.Error != "" {
+				message += ": " + task.Error
+			}
+			failed = append(failed, message)
+			failuresBySlot[task.Name]++
+		}
+	}
+	if desired < 0 {
+		desired = scheduled
+	}
+
+	signals := make([]Signal, 0, 4)
+
+	ratio := 1.0
+	if desired > 0 {
+		ratio = float64(running) / float64(desired)
+	}
+	runningStatus := StatusHealthy
+	switch {
+	case len(tasks) == 0 || ratio < p.config.CriticalRatio:
+		runningStatus = StatusError
+	case ratio < p.config.MinRunningRatio:
+		runningStatus = StatusWarning
+	}
+	signals = append(signals, signal(SignalReadiness, runningStatus, ratio, fmt.Sprintf("%d/%d tasks running", running, desired)))
+
+	failedStatus := StatusHealthy
+	switch {
+	case len(failed) >= p.config.FailedCritical:
+		failedStatus = StatusError
+	case len(failed) >= p.config.FailedWarning:
+		failedStatus = StatusWarning
+	}
+	message := fmt.Sprintf("%d failed tasks", len(failed))
+	if len(failed) > 0 {
+		message += ": " + strings.Join(failed, "; ")
+	}
+	signals = append(signals, signal(SignalFailedTasks, failedStatus, float64(len(failed)), message))
+
+	looping := make([]string, 0)
+	for slot, failures := range failuresBySlot {
+		if failures >= p.config.RestartLoopTasks {
+			looping = append(looping, fmt.Sprintf("%s (%d failures)", slot, failures))
+		}
+	}
+	if len(looping) > 0 {
+		sort.Strings(looping)
+		signals = append(signals, signal(SignalCrashLoop, StatusError, float64(len(looping)),
+			fmt.Sprintf("restart loop: %s", strings.Join(looping, ", "))))
+	}
+
+	if p.config.CheckContainerHealth {
+		unhealthy, err := p.unhealthyContainers(ctx, version)
+		if err != nil {
+			return nil, err
+		}
+		status := StatusHealthy
+		if len(unhealthy) > 0 {
+			status = StatusError
+		}
+		message := fmt.Sprintf("%d unhealthy containers", len(unhealthy))
+		if len(unhealthy) > 0 {
+			message += ": " + strings.Join(unhealthy, ", ")
+		}
+		signals = append(signals, signal(SignalUnhealthy, status, float64(len(unhealthy)), message))
+	}
+
+	return signals, nil
+}
+
+func (p *SwarmHealthProvider) unhealthyContainers(ctx context.Context, version string) ([]string, error) {
+	out, err := p.output(ctx, "docker", "ps", "--no-trunc",
+		"--filter", "label=com.docker.swarm.service.name="+p.config.ServiceName,
+		"--format", "{{.Names}}\t{{.Image}}\t{{.Status}}")
+	if err != nil {
+		return nil, err
+	}
+
+	unhealthy := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || deployment.ParseVersionFromImage(fields[1]) != version {
+			continue
+		}
+		if strings.Contains(fields[2], "(unhealthy)") {
+			unhealthy = append(unhealthy, fields[0])
+		}
+	}
+	return unhealthy, nil
+}
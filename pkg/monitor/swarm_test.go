@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockExecutor struct {
+	commands []string
+	outputs  map[string]string
+	hang     string
+}
+
+func (m *mockExecutor) Command(name string, args ...string) *exec.Cmd {
+	return m.CommandContext(context.Background(), name, args...)
+}
+
+func (m *mockExecutor) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	command := name + " " + strings.Join(args, " ")
+	m.commands = append(m.commands, command)
+	if m.hang != "" && strings.HasPrefix(command, m.hang) {
+		return exec.CommandContext(ctx, "sleep", "10")
+	}
+	for prefix, output := range m.outputs {
+		if strings.HasPrefix(command, prefix) {
+			return exec.CommandContext(ctx, "printf", "%s", output)
+		}
+	}
+	return exec.CommandContext(ctx, "false")
+}
+
+func swarmTasks(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestSwarmHealthSignals(t *testing.T) {
+	tests := []struct {
+		name     string
+		tasks    string
+		replicas string
+		ps       string
+		want     map[string]Status
+		wantErr  bool
+	}{
+		{
+			name: "all tasks running",
+			tasks: swarmTasks(
+				`{"ID":"t1","Name":"web.1","Image":"registry/web:v2@sha256:abc","DesiredState":"Running","CurrentState":"Running 5 minutes ago"}`,
+				`{"ID":"t2","Name":"web.2","Image":"registry/web:v2@sha256:abc","DesiredState":"Running","CurrentState":"Running 5 minutes ago"}`,
+				`{"ID":"t0","Name":"web.1","Image":"registry/web:v1","DesiredState":"Shutdown","CurrentState":"Failed 1 hour ago","Error":"task: non-zero exit (1)"}`,
+			),
+			replicas: "2\n",
+			ps:       "web.1.t1\tregistry/web:v2\tUp 5 minutes (healthy)\nweb.2.t2\tregistry/web:v2\tUp 5 minutes (healthy)\n",
+			want: map[string]Status{
+				SignalReadiness:   StatusHealthy,
+				SignalFailedTasks: StatusHealthy,
+				SignalUnhealthy:   StatusHealthy,
+			},
+		},
+		{
+			name: "restart loop with unhealthy container",
+			tasks: swarmTasks(
+				`{"ID":"t5","Name":"web.1","Image":"registry/web:v2","DesiredState":"Running","CurrentState":"Starting 2 seconds ago"}`,
+				`{"ID":"t4","Name":" \\_ web.1","Image":"registry/web:v2","DesiredState":"Shutdown","CurrentState":"Failed 20 seconds ago","Error":"task: non-zero exit (137)"}`,
+				`{"ID":"t3","Name":" \\_ web.1","Image":"registry/web:v2","DesiredState":"Shutdown","CurrentState":"Failed 40 seconds ago","Error":"task: non-zero exit (137)"}`,
+				`{"ID":"t2","Name":" \\_ web.1","Image":"registry/web:v2","DesiredState":"Shutdown","CurrentState":"Failed 1 minute ago","Error":"task: non-zero exit (137)"}`,
+				`{"ID":"t1","Name":"web.2","Image":"registry/web:v2","DesiredState":"Running","CurrentState":"Running 5 minutes ago"}`,
+			),
+			replicas: "2\n",
+			ps:       "web.2.t1\tregistry/web:v2\tUp 5 minutes (unhealthy)\n",
+			want: map[string]Status{
+				SignalReadiness:   StatusWarning,
+				SignalFailedTasks: StatusError,
+				SignalCrashLoop:   StatusError,
+				SignalUnhealthy:   StatusError,
+			},
+		},
+		{
+			name: "global service without tasks",
+			tasks: swarmTasks(
+				`{"ID":"t1","Name":"web.node1","Image":"registry/web:v1","DesiredState":"Running","CurrentState":"Running 5 minutes ago"}`,
+			),
+			replicas: "\n",
+			ps:       "",
+			want: map[string]Status{
+				SignalReadiness:   StatusError,
+				SignalFailedTasks: StatusHealthy,
+				SignalUnhealthy:   StatusHealthy,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &mockExecutor{outputs: map[string]string{
+				"docker service ps":      tt.tasks,
+				"docker service inspect": tt.replicas,
+				"docker ps":              tt.ps,
+			}}
+			provider := NewSwarmHealthProvider(executor, SwarmHealthConfig{ServiceName: "web", CheckContainerHealth: true})
+
+			signals, err := provider.Signals(context.Background(), "v2")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Signals() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got := signalsByName(signals)
+			if len(got) != len(tt.want) {
+				t.Errorf("Signals() = %+v, want %d signals", signals, len(tt.want))
+			}
+			for name, want := range tt.want {
+				if got[name].Status != want {
+					t.Errorf("signal %s = %v (%s), want %v", name, got[name].Status, got[name].Message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSwarmSignalsRecordErrors(t *testing.T) {
+	executor := &mockExecutor{outputs: map[string]string{
+		"docker service ps": swarmTasks(
+			`{"ID":"t2","Name":"web.1","Image":"registry/web:v2","DesiredState":"Running","CurrentState":"Running 1 second ago"}`,
+			`{"ID":"t1","Name":"web.1","Image":"registry/web:v2","DesiredState":"Shutdown","CurrentState":"Failed 1 minute ago","Error":"task: non-zero exit (1)"}`,
+		),
+		"docker service inspect": "1",
+	}}
+
+	s := NewService(MonitorConfig{ErrorThreshold: 0.1, CPUThreshold: 90, MemoryThreshold: 90, LatencyThreshold: time.Second})
+	_ = s.AddVersion("v2")
+	collector := NewCollector(s, nil, time.Minute, nil)
+	collector.AddSignalProvider(NewSwarmHealthProvider(executor, SwarmHealthConfig{ServiceName: "web"}))
+
+	for i := 0; i < 3; i++ {
+		if err := collector.CollectOnce(context.Background()); err != nil {
+			t.Fatalf("CollectOnce() error = %v", err)
+		}
+	}
+
+	if status, _ := s.CheckHealth("v2"); status != StatusWarning {
+		t.Errorf("CheckHealth() = %v, want %v", status, StatusWarning)
+	}
+
+	version, _ := s.GetVersion("v2")
+	if len(version.Errors) != 1 {
+		t.Fatalf("Errors = %+v, want 1 error recorded once across collections", version.Errors)
+	}
+	if !strings.Contains(version.Errors[0].Message, "non-zero exit (1)") || version.Errors[0].Severity != string(StatusWarning) {
+		t.Errorf("Errors[0] = %+v, want warning carrying the task error", version.Errors[0])
+	}
+}
+
+func TestSwarmHealthStopsWithContext(t *testing.T) {
+	executor := &mockExecutor{hang: "docker service ps"}
+	provider := NewSwarmHealthProvider(executor, SwarmHealthConfig{ServiceName: "shop_web"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := provider.Signals(ctx, "v2"); err == nil {
+		t.Fatal("Signals() error = nil, want the command to be killed")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Signals() returned after %s, want it to stop with the context", elapsed)
+	}
+}
@@ -1,35 +1,95 @@
 package monitor
 
 import (
-    "time"
+	"time"
 )
 
 type Status string
 
 const (
-    StatusHealthy Status = "healthy"
-    StatusWarning Status = "warning"
-    StatusError   Status = "error"
+	StatusHealthy Status = "healthy"
+	StatusWarning Status = "warning"
+	StatusError   Status = "error"
+	StatusPending Status = "pending"
 )
 
 type Version struct {
-    Number      string
-    Status      Status
-    Metrics     *Metrics
-    LastChecked time.Time
-    Errors      []Error
+	Number      string
+	Status      Status
+	Metrics     *Metrics
+	LastChecked time.Time
+	Errors      []Error
 }
 
 type Metrics struct {
-    CPUUsage    float64
-    MemoryUsage float64
-    ErrorRate   float64
-    Latency     time.Duration
+	CPUUsage    float64
+	MemoryUsage float64
+	ErrorRate   float64
+	Latency     time.Duration
 }
 
 type Error struct {
-    Message   string
-    Timestamp time.Time
-    Severity  string
+	Message     string
+	Timestamp   time.Time
+	Severity    string
+	Fingerprint string
+	Count       int
+	FirstSeen   time.Time
+
+	occurrences []time.Time
 }
 
+type MetricName string
+
+const (
+	MetricCPU       MetricName = "cpu"
+	MetricMemory    MetricName = "memory"
+	MetricErrorRate MetricName = "error_rate"
+	MetricLatency   MetricName = "latency"
+)
+
+var AllMetrics = []MetricName{MetricCPU, MetricMemory, MetricErrorRate, MetricLatency}
+
+type Threshold struct {
+	Warning  float64
+	Critical float64
+	Weight   float64
+}
+
+type Aggregation string
+
+const (
+	AggregateAnyBreach     Aggregation = "any"
+	AggregateWeightedScore Aggregation = "weighted"
+)
+
+type Breach struct {
+	Metric    MetricName
+	Level     Status
+	Value     float64
+	Threshold float64
+	Excess    float64
+	Ratio     float64
+}
+
+type Signal struct {
+	Source     string
+	Name       string
+	Status     Status
+	Value      float64
+	Message    string
+	ObservedAt time.Time
+	Events     []Error
+}
+
+type Verdict struct {
+	Version     string
+	Status      Status
+	Score       float64
+	Breaches    []Breach
+	Signals     []Signal
+	SLOs        []SLOStatus
+	Metrics     Metrics
+	Samples     int
+	EvaluatedAt time.Time
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const defaultWindowSamples = 120
+
+type Statistic string
+
+const (
+	StatMean Statistic = "mean"
+	StatP95  Statistic = "p95"
+	StatMax  Statistic = "max"
+	StatRate Statistic = "rate"
+)
+
+type Sample struct {
+	Time    time.Time
+	Metrics Metrics
+}
+
+type sampleRing struct {
+	samples []Sample
+	start   int
+	size    int
+}
+
+func newSampleRing(capacity int) *sampleRing {
+	if capacity <= 0 {
+		capacity = defaultWindowSamples
+	}
+	return &sampleRing{samples: make([]Sample, capacity)}
+}
+
+func (r *sampleRing) add(sample Sample) {
+	capacity := len(r.samples)
+	if r.size < capacity {
+		r.samples[(r.start+r.size)%capacity] = sample
+		r.size++
+		return
+	}
+	r.samples[r.start] = sample
+	r.start = (r.start + 1) % capacity
+}
+
+func (r *sampleRing) since(cutoff time.Time) []Sample {
+	result := make([]Sample, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		sample := r.samples[(r.start+i)%len(r.samples)]
+		if !sample.Time.Before(cutoff) {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+func (c MonitorConfig) windowed() bool {
+	return c.Window > 0 || c.MinSamples > 0 || len(c.Statistics) > 0
+}
+
+func (c MonitorConfig) statistic(metric MetricName) Statistic {
+	if stat, ok := c.Statistics[metric]; ok {
+		return stat
+	}
+	return StatMean
+}
+
+func (c MonitorConfig) windowSamples(ring *sampleRing, now time.Time) []Sample {
+	if ring == nil {
+		return nil
+	}
+	cutoff := time.Time{}
+	if c.Window > 0 {
+		cutoff = now.Add(-c.Window)
+	}
+	return ring.since(cutoff)
+}
+
+func computeStatistic(stat Statistic, samples []Sample, metric MetricName) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	values := make([]float64, len(samples))
+	for i := range samples {
+		values[i] = metricValue(&samples[i].Metrics, metric)
+	}
+
+	switch stat {
+	case StatMax:
+		max := values[0]
+		for _, v := range values[1:] {
+			max = math.Max(max, v)
+		}
+		return max
+	case StatP95:
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return sorted[rank]
+	case StatRate:
+		elapsed := samples[len(samples)-1].Time.Sub(samples[0].Time).Seconds()
+		if elapsed <= 0 {
+			return 0
+		}
+		return (values[len(values)-1] - values[0]) / elapsed
+	default:
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	}
+}
+
+func (c MonitorConfig) aggregateSamples(samples []Sample) Metrics {
+	return Metrics{
+		CPUUsage:    computeStatistic(c.statistic(MetricCPU), samples, MetricCPU),
+		MemoryUsage: computeStatistic(c.statistic(MetricMemory), samples, MetricMemory),
+		ErrorRate:   computeStatistic(c.statistic(MetricErrorRate), samples, MetricErrorRate),
+		Latency:     time.Duration(computeStatistic(c.statistic(MetricLatency), samples, MetricLatency) * float64(time.Second)),
+	}
+}
@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleRingWrapsAround(t *testing.T) {
+	ring := newSampleRing(3)
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		ring.add(Sample{Time: base.Add(time.Duration(i) * time.Second), Metrics: Metrics{CPUUsage: float64(i)}})
+	}
+
+	samples := ring.since(time.Time{})
+	if len(samples) != 3 {
+		t.Fatalf("since() returned %d samples, want 3", len(samples))
+	}
+	for i, want := range []float64{2, 3, 4} {
+		if samples[i].Metrics.CPUUsage != want {
+			t.Errorf("sample %d CPUUsage = %v, want %v", i, samples[i].Metrics.CPUUsage, want)
+		}
+	}
+}
+
+func TestComputeStatistic(t *testing.T) {
+	base := time.Now()
+	samples := make([]Sample, 0, 20)
+	for i := 1; i <= 20; i++ {
+		samples = append(samples, Sample{
+			Time:    base.Add(time.Duration(i) * time.Second),
+			Metrics: Metrics{CPUUsage: float64(i)},
+		})
+	}
+
+	tests := []struct {
+		stat Statistic
+		want float64
+	}{
+		{StatMean, 10.5},
+		{StatMax, 20},
+		{StatP95, 19},
+		{StatRate, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.stat), func(t *testing.T) {
+			if got := computeStatistic(tt.stat, samples, MetricCPU); got != tt.want {
+				t.Errorf("computeStatistic(%s) = %v, want %v", tt.stat, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowedEvaluation(t *testing.T) {
+	s := NewService(MonitorConfig{
+		Window:     time.Minute,
+		MinSamples: 3,
+		Thresholds: map[MetricName]Threshold{
+			MetricErrorRate: {Critical: 0.05},
+			MetricCPU:       {Critical: 90},
+			MetricMemory:    {Critical: 90},
+			MetricLatency:   {Critical: 1},
+		},
+		Statistics: map[MetricName]Statistic{MetricLatency: StatP95},
+	})
+	_ = s.AddVersion("v1.0.0")
+	now := time.Now()
+
+	_ = s.RecordSample("v1.0.0", Sample{Time: now.Add(-2 * time.Minute), Metrics: Metrics{ErrorRate: 0.9}})
+	_ = s.RecordSample("v1.0.0", Sample{Time: now.Add(-20 * time.Second), Metrics: Metrics{ErrorRate: 0.5}})
+
+	verdict, _ := s.Evaluate("v1.0.0")
+	if verdict.Status != StatusPending || verdict.Samples != 1 {
+		t.Errorf("Evaluate() = %v with %d samples, want pending with 1 sample", verdict.Status, verdict.Samples)
+	}
+
+	_ = s.RecordSample("v1.0.0", Sample{Time: now.Add(-10 * time.Second), Metrics: Metrics{ErrorRate: 0}})
+	_ = s.RecordSample("v1.0.0", Sample{Time: now, Metrics: Metrics{ErrorRate: 0}})
+
+	verdict, _ = s.Evaluate("v1.0.0")
+	if verdict.Samples != 3 {
+		t.Errorf("Evaluate().Samples = %d, want 3", verdict.Samples)
+	}
+	if verdict.Status != StatusError {
+		t.Errorf("Evaluate() = %v, want error for mean error rate %.3f", verdict.Status, verdict.Metrics.ErrorRate)
+	}
+
+	for i := 0; i < 30; i++ {
+		_ = s.UpdateMetrics("v1.0.0", &Metrics{ErrorRate: 0})
+	}
+	verdict, _ = s.Evaluate("v1.0.0")
+	if verdict.Status != StatusHealthy {
+		t.Errorf("Evaluate() = %v, want healthy once the noisy sample is diluted (mean %.3f)", verdict.Status, verdict.Metrics.ErrorRate)
+	}
+}
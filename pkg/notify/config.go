@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+const (
+	ChannelWebhook   = "webhook"
+	ChannelSlack     = "slack"
+	ChannelTeams     = "teams"
+	ChannelEmail     = "email"
+	ChannelPagerDuty = "pagerduty"
+)
+
+type Config struct {
+	Enabled  bool
+	Channels []string
+	Webhook  string
+
+	WebhookHeaders map[string]string
+	Slack          SlackConfig
+	TeamsWebhook   string
+	Email          SMTPConfig
+	PagerDuty      PagerDutyConfig
+	Notifiers      []Notifier
+
+	Templates Templates
+	Retries   int
+	Backoff   time.Duration
+	Timeout   time.Duration
+}
+
+func newChannel(channel string, config Config, client *http.Client) (Notifier, error) {
+	switch channel {
+	case ChannelWebhook:
+		if config.Webhook == "" {
+			return nil, nil
+		}
+		return NewWebhookNotifier(config.Webhook, config.WebhookHeaders, client), nil
+	case ChannelSlack:
+		slack := config.Slack
+		if slack.WebhookURL == "" {
+			slack.WebhookURL = config.Webhook
+		}
+		if slack.WebhookURL == "" {
+			return nil, nil
+		}
+		return NewSlackNotifier(slack, client), nil
+	case ChannelTeams:
+		url := config.TeamsWebhook
+		if url == "" {
+			url = config.Webhook
+		}
+		if url == "" {
+			return nil, nil
+		}
+		return NewTeamsNotifier(url, client), nil
+	case ChannelEmail, "smtp":
+		if config.Email.Addr == "" || len(config.Email.To) == 0 {
+			return nil, nil
+		}
+		return NewSMTPNotifier(config.Email), nil
+	case ChannelPagerDuty:
+		if config.PagerDuty.RoutingKey == "" {
+			return nil, nil
+		}
+		return NewPagerDutyNotifier(config.PagerDuty, client), nil
+	default:
+		return nil, fmt.Errorf("unknown notification channel %q", channel)
+	}
+}
+
+// NewDispatcherFromConfig returns nil when notifications are disabled or no
+// channel resolves to a notifier, so no worker runs that nobody will close.
+func NewDispatcherFromConfig(config Config, logger *logging.Logger) (*Dispatcher, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	if _, err := compileTemplates(config.Templates); err != nil {
+		return nil, err
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	notifiers := make([]Notifier, 0, len(config.Channels)+len(config.Notifiers))
+	for _, channel := range config.Channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		notifier, err := newChannel(channel, config, client)
+		if err != nil {
+			return nil, err
+		}
+		if notifier == nil {
+			if logger != nil {
+				logger.Debug().Str("channel", channel).Msg("Notification channel is not configured, skipping")
+			}
+			continue
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	notifiers = append(notifiers, config.Notifiers...)
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+
+	dispatcher, err := NewDispatcher(DispatcherConfig{
+		Templates: config.Templates,
+		Retries:   config.Retries,
+		Backoff:   config.Backoff,
+		Timeout:   config.Timeout,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	for _, notifier := range notifiers {
+		dispatcher.Add(notifier)
+	}
+	return dispatcher, nil
+}
@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+const (
+	defaultRetries   = 3
+	defaultBackoff   = time.Second
+	defaultTimeout   = 10 * time.Second
+	defaultQueueSize = 100
+)
+
+type DispatcherConfig struct {
+	Templates Templates
+	Retries   int
+	Backoff   time.Duration
+	Timeout   time.Duration
+	QueueSize int
+}
+
+type Dispatcher struct {
+	config    DispatcherConfig
+	templates *compiledTemplates
+	logger    *logging.Logger
+
+	mu        sync.RWMutex
+	notifiers []Notifier
+	closed    bool
+	queue     chan Message
+	done      chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+func NewDispatcher(config DispatcherConfig, logger *logging.Logger) (*Dispatcher, error) {
+	if config.Retries < 0 {
+		config.Retries = 0
+	} else if config.Retries == 0 {
+		config.Retries = defaultRetries
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = defaultBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if logger == nil {
+		logger = logging.NewLogger("info", false)
+	}
+
+	templates, err := compileTemplates(config.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		config:    config,
+		templates: templates,
+		logger:    logger,
+		notifiers: make([]Notifier, 0),
+		queue:     make(chan Message, config.QueueSize),
+		done:      make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	go d.run()
+	return d, nil
+}
+
+func (d *Dispatcher) Add(notifier Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifiers = append(d.notifiers, notifier)
+}
+
+func (d *Dispatcher) Notifiers() []Notifier {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Notifier(nil), d.notifiers...)
+}
+
+func (d *Dispatcher) Notify(event Event) error {
+	if d == nil {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	message, err := d.templates.render(event)
+	if err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return fmt.Errorf("notification dispatcher is closed")
+	}
+	select {
+	case d.queue <- message:
+		return nil
+	default:
+		d.logger.Warn().Str("event", string(event.Type)).Msg("Notification queue full, dropping message")
+		return fmt.Errorf("notification queue full")
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for message := range d.queue {
+		notifiers := d.Notifiers()
+		var wg sync.WaitGroup
+		for _, notifier := range notifiers {
+			wg.Add(1)
+			go func(notifier Notifier) {
+				defer wg.Done()
+				d.deliver(notifier, message)
+			}(notifier)
+		}
+		wg.Wait()
+	}
+}
+
+func (d *Dispatcher) deliver(notifier Notifier, message Message) {
+	var err error
+	for attempt := 0; attempt <= d.config.Retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(d.config.Backoff * time.Duration(1<<(attempt-1)))
+			select {
+			case <-d.ctx.Done():
+				timer.Stop()
+				d.logger.Error().Err(err).Str("notifier", notifier.Name()).Str("event", string(message.Event.Type)).Msg("Dispatcher closed, abandoning notification")
+				return
+			case <-timer.C:
+			}
+		}
+		ctx, cancel := context.WithTimeout(d.ctx, d.config.Timeout)
+		err = notifier.Notify(ctx, message)
+		cancel()
+		if err == nil {
+			d.logger.Debug().Str("notifier", notifier.Name()).Str("event", string(message.Event.Type)).Msg("Notification delivered")
+			return
+		}
+		d.logger.Warn().Err(err).Str("notifier", notifier.Name()).Int("attempt", attempt+1).Msg("Notification delivery failed")
+	}
+	d.logger.Error().Err(err).Str("notifier", notifier.Name()).Str("event", string(message.Event.Type)).Msg("Giving up on notification")
+}
+
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		// Abandon pending retries and in-flight deliveries so the worker
+		// drains the rest of the queue without waiting on backoffs.
+		d.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+type recordingNotifier struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	messages []Message
+}
+
+func (r *recordingNotifier) Name() string {
+	return "recording"
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, message Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("temporarily unavailable")
+	}
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func TestDispatcherRetriesAndDrains(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	dispatcher, err := NewDispatcher(DispatcherConfig{Retries: 2, Backoff: time.Millisecond}, logger)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+
+	flaky := &recordingNotifier{failures: 2}
+	broken := &recordingNotifier{failures: 100}
+	dispatcher.Add(flaky)
+	dispatcher.Add(broken)
+
+	for _, eventType := range []EventType{EventStarted, EventSucceeded} {
+		if err := dispatcher.Notify(Event{Type: eventType, Strategy: "docker", From: "v2", To: "v1"}); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(flaky.messages) != 2 || flaky.messages[0].Event.Type != EventStarted || flaky.messages[1].Event.Type != EventSucceeded {
+		t.Errorf("delivered messages = %+v, want started then succeeded", flaky.messages)
+	}
+	if broken.calls != 6 {
+		t.Errorf("broken notifier calls = %d, want 6 (3 tries per message)", broken.calls)
+	}
+	if err := dispatcher.Notify(Event{Type: EventFailed}); err == nil {
+		t.Error("Notify() after Close() error = nil, want error")
+	}
+}
+
+func TestDispatcherCloseCutsRetriesShort(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	dispatcher, err := NewDispatcher(DispatcherConfig{Retries: 5, Backoff: time.Hour}, logger)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+	broken := &recordingNotifier{failures: 100}
+	dispatcher.Add(broken)
+
+	for _, eventType := range []EventType{EventStarted, EventFailed} {
+		if err := dispatcher.Notify(Event{Type: eventType, Strategy: "docker", From: "v2", To: "v1"}); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-dispatcher.done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher still retrying after Close() gave up")
+	}
+
+	broken.mu.Lock()
+	defer broken.mu.Unlock()
+	if broken.calls > 2 {
+		t.Errorf("broken notifier calls = %d, want at most one per message", broken.calls)
+	}
+}
+
+func TestNilDispatcher(t *testing.T) {
+	var dispatcher *Dispatcher
+	if err := dispatcher.Notify(Event{Type: EventStarted}); err != nil {
+		t.Errorf("Notify() on nil dispatcher error = %v", err)
+	}
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Errorf("Close() on nil dispatcher error = %v", err)
+	}
+}
+
+func TestNewDispatcherFromConfig(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	server, requests := captureServer(t, http.StatusOK)
+
+	tests := []struct {
+		name      string
+		config    Config
+		wantNames []string
+		wantNil   bool
+		wantErr   bool
+	}{
+		{"disabled", Config{Channels: []string{ChannelSlack}, Webhook: server.URL}, nil, true, false},
+		{"default slack without url", Config{Enabled: true, Channels: []string{ChannelSlack}}, nil, true, false},
+		{"slack falls back to webhook", Config{Enabled: true, Channels: []string{"Slack"}, Webhook: server.URL}, []string{"slack"}, false, false},
+		{"every channel", Config{
+			Enabled:      true,
+			Channels:     []string{ChannelWebhook, ChannelTeams, ChannelEmail, ChannelPagerDuty},
+			Webhook:      server.URL,
+			TeamsWebhook: server.URL,
+			Email:        SMTPConfig{Addr: "127.0.0.1:25", To: []string{"ops@example.com"}},
+			PagerDuty:    PagerDutyConfig{RoutingKey: "key"},
+			Notifiers:    []Notifier{&recordingNotifier{}},
+		}, []string{"webhook", "teams", "email", "pagerduty", "recording"}, false, false},
+		{"unknown channel", Config{Enabled: true, Channels: []string{"carrier-pigeon"}}, nil, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher, err := NewDispatcherFromConfig(tt.config, logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDispatcherFromConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer dispatcher.Close(context.Background())
+			if (dispatcher == nil) != tt.wantNil {
+				t.Fatalf("NewDispatcherFromConfig() = %v, want nil %v", dispatcher, tt.wantNil)
+			}
+			if dispatcher == nil {
+				return
+			}
+			names := make([]string, 0)
+			for _, notifier := range dispatcher.Notifiers() {
+				names = append(names, notifier.Name())
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("notifiers = %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("notifiers = %v, want %v", names, tt.wantNames)
+				}
+			}
+		})
+	}
+
+	dispatcher, _ := NewDispatcherFromConfig(Config{Enabled: true, Channels: []string{ChannelSlack}, Webhook: server.URL}, logger)
+	_ = dispatcher.Notify(Event{Type: EventStarted, Strategy: "docker", From: "v2"})
+	_ = dispatcher.Close(context.Background())
+	if req := <-requests; req.body["text"] != "Rollback of docker started" {
+		t.Errorf("slack payload text = %v, want rendered title", req.body["text"])
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Package notify delivers rollback notifications to chat, email and paging
+systems.
+
+A Dispatcher renders each Event through text/template, queues it, and sends
+it to every registered Notifier in the background, retrying failed deliveries
+with exponential backoff. Generic JSON webhooks, Slack incoming webhooks,
+Microsoft Teams cards, SMTP email and PagerDuty Events v2 are supported.
+
+Basic usage:
+
+	dispatcher, err := notify.NewDispatcher(notify.DispatcherConfig{}, logger)
+	dispatcher.Add(notify.NewSlackNotifier(notify.SlackConfig{WebhookURL: url}, nil))
+
+	dispatcher.Notify(notify.Event{Type: notify.EventStarted, Strategy: "docker", From: "v1.1.0", To: "v1.0.0"})
+	defer dispatcher.Close(ctx)
+
+The rollback service builds a dispatcher from RollbackConfig.Notifications,
+sending only to channels that have the settings they need:
+
+	config.Notifications.Channels = []string{"slack", "pagerduty"}
+	config.Notifications.Slack.WebhookURL = "https://hooks.slack.com/services/..."
+	config.Notifications.PagerDuty.RoutingKey = routingKey
+*/
+package notify
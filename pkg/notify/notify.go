@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventStarted   EventType = "started"
+	EventSucceeded EventType = "succeeded"
+	EventFailed    EventType = "failed"
+)
+
+type Event struct {
+	Type       EventType         `json:"type"`
+	RollbackID string            `json:"rollback_id,omitempty"`
+	Strategy   string            `json:"strategy"`
+	From       string            `json:"from_version"`
+	To         string            `json:"to_version,omitempty"`
+	Attempts   int               `json:"attempts,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Duration   time.Duration     `json:"duration,omitempty"`
+	Time       time.Time         `json:"time"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+type Message struct {
+	Event Event  `json:"event"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, message Message) error
+}
+
+var DefaultTemplates = Templates{
+	Title: map[EventType]string{
+		EventStarted:   `Rollback of {{.Strategy}} started`,
+		EventSucceeded: `Rollback of {{.Strategy}} succeeded`,
+		EventFailed:    `Rollback of {{.Strategy}} failed`,
+	},
+	Text: map[EventType]string{
+		EventStarted:   `Rolling back {{.Strategy}} from {{.From}}{{if .To}} to {{.To}}{{end}}.`,
+		EventSucceeded: `Rolled back {{.Strategy}} from {{.From}} to {{.To}} in {{.Duration}}{{if .Attempts}} after {{.Attempts}} attempt(s){{end}}.`,
+		EventFailed:    `Rollback of {{.Strategy}} from {{.From}}{{if .To}} to {{.To}}{{end}} failed{{if .Attempts}} after {{.Attempts}} attempt(s){{end}}: {{.Error}}`,
+	},
+}
+
+type Templates struct {
+	Title map[EventType]string
+	Text  map[EventType]string
+}
+
+type compiledTemplates struct {
+	title map[EventType]*template.Template
+	text  map[EventType]*template.Template
+}
+
+func compileTemplates(templates Templates) (*compiledTemplates, error) {
+	compiled := &compiledTemplates{
+		title: make(map[EventType]*template.Template),
+		text:  make(map[EventType]*template.Template),
+	}
+	for _, eventType := range []EventType{EventStarted, EventSucceeded, EventFailed} {
+		title, ok := templates.Title[eventType]
+		if !ok {
+			title = DefaultTemplates.Title[eventType]
+		}
+		text, ok := templates.Text[eventType]
+		if !ok {
+			text = DefaultTemplates.Text[eventType]
+		}
+
+		var err error
+		if compiled.title[eventType], err = template.New(string(eventType) + "_title").Parse(title); err != nil {
+			return nil, fmt.Errorf("invalid %s title template: %w", eventType, err)
+		}
+		if compiled.text[eventType], err = template.New(string(eventType) + "_text").Parse(text); err != nil {
+			return nil, fmt.Errorf("invalid %s text template: %w", eventType, err)
+		}
+	}
+	return compiled, nil
+}
+
+func (c *compiledTemplates) render(event Event) (Message, error) {
+	titleTmpl, ok := c.title[event.Type]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown event type %q", event.Type)
+	}
+
+	var title, text bytes.Buffer
+	if err := titleTmpl.Execute(&title, event); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s title: %w", event.Type, err)
+	}
+	if err := c.text[event.Type].Execute(&text, event); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s text: %w", event.Type, err)
+	}
+	return Message{
+		Event: event,
+		Title: strings.TrimSpace(title.String()),
+		Text:  strings.TrimSpace(text.String()),
+	}, nil
+}
+
+func Render(templates Templates, event Event) (Message, error) {
+	compiled, err := compileTemplates(templates)
+	if err != nil {
+		return Message{}, err
+	}
+	return compiled.render(event)
+}
+
+func color(eventType EventType) string {
+	switch eventType {
+	case EventSucceeded:
+		return "2EB886"
+	case EventFailed:
+		return "D00000"
+	default:
+		return "439FE0"
+	}
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	event := Event{
+		Type:     EventFailed,
+		Strategy: "docker",
+		From:     "v1.1.0",
+		To:       "v1.0.0",
+		Attempts: 3,
+		Error:    "service update failed",
+		Duration: 2 * time.Second,
+	}
+
+	tests := []struct {
+		name      string
+		templates Templates
+		event     Event
+		wantTitle string
+		wantText  string
+		wantErr   bool
+	}{
+		{
+			name:      "default failed",
+			event:     event,
+			wantTitle: "Rollback of docker failed",
+			wantText:  "Rollback of docker from v1.1.0 to v1.0.0 failed after 3 attempt(s): service update failed",
+		},
+		{
+			name:      "default started without target",
+			event:     Event{Type: EventStarted, Strategy: "kubernetes", From: "v2"},
+			wantTitle: "Rollback of kubernetes started",
+			wantText:  "Rolling back kubernetes from v2.",
+		},
+		{
+			name: "custom text keeps default title",
+			templates: Templates{Text: map[EventType]string{
+				EventFailed: `{{.Strategy}} stuck on {{.From}} ({{.Labels.env}})`,
+			}},
+			event: func() Event {
+				e := event
+				e.Labels = map[string]string{"env": "prod"}
+				return e
+			}(),
+			wantTitle: "Rollback of docker failed",
+			wantText:  "docker stuck on v1.1.0 (prod)",
+		},
+		{
+			name:      "invalid template",
+			templates: Templates{Title: map[EventType]string{EventStarted: `{{.Strategy`}},
+			event:     event,
+			wantErr:   true,
+		},
+		{
+			name:    "unknown event type",
+			event:   Event{Type: "paused"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := Render(tt.templates, tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if message.Title != tt.wantTitle {
+				t.Errorf("Render() title = %q, want %q", message.Title, tt.wantTitle)
+			}
+			if message.Text != tt.wantText {
+				t.Errorf("Render() text = %q, want %q", message.Text, tt.wantText)
+			}
+		})
+	}
+}
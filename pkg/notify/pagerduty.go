@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+type PagerDutyConfig struct {
+	RoutingKey string
+	URL        string
+	Source     string
+}
+
+type PagerDutyNotifier struct {
+	config PagerDutyConfig
+	client *http.Client
+}
+
+func NewPagerDutyNotifier(config PagerDutyConfig, client *http.Client) *PagerDutyNotifier {
+	if config.URL == "" {
+		config.URL = DefaultPagerDutyURL
+	}
+	if config.Source == "" {
+		config.Source = "stable-galaxy"
+	}
+	return &PagerDutyNotifier{config: config, client: httpClient(client)}
+}
+
+func (p *PagerDutyNotifier) Name() string {
+	return "pagerduty"
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Component     string            `json:"component,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+func dedupKey(event Event) string {
+	return fmt.Sprintf("stable-galaxy/%s/%s", event.Strategy, event.From)
+}
+
+func (p *PagerDutyNotifier) Notify(ctx context.Context, message Message) error {
+	event := pagerDutyEvent{
+		RoutingKey:  p.config.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    dedupKey(message.Event),
+	}
+	if message.Event.Type == EventSucceeded {
+		event.EventAction = "resolve"
+		return postJSON(ctx, p.client, p.config.URL, nil, event)
+	}
+
+	severity := "warning"
+	if message.Event.Type == EventFailed {
+		severity = "critical"
+	}
+	details := map[string]string{
+		"from_version": message.Event.From,
+		"to_version":   message.Event.To,
+		"message":      message.Text,
+	}
+	if message.Event.Error != "" {
+		details["error"] = message.Event.Error
+	}
+	if message.Event.RollbackID != "" {
+		details["rollback_id"] = message.Event.RollbackID
+	}
+	event.Payload = &pagerDutyPayload{
+		Summary:       message.Title,
+		Source:        p.config.Source,
+		Severity:      severity,
+		Timestamp:     message.Event.Time.UTC().Format(time.RFC3339),
+		Component:     message.Event.Strategy,
+		CustomDetails: details,
+	}
+	return postJSON(ctx, p.client, p.config.URL, nil, event)
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+func NewSMTPNotifier(config SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{config: config}
+}
+
+func (s *SMTPNotifier) Name() string {
+	return "email"
+}
+
+func (s *SMTPNotifier) buildMessage(message Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.config.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.ReplaceAll(message.Title, "\n", " "))
+	fmt.Fprintf(&b, "Date: %s\r\n", message.Event.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+func (s *SMTPNotifier) Notify(ctx context.Context, message Message) error {
+	if len(s.config.To) == 0 {
+		return fmt.Errorf("no email recipients configured")
+	}
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		host, _, err := net.SplitHostPort(s.config.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %s: %w", s.config.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.config.Addr, auth, s.config.From, s.config.To, s.buildMessage(message))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+type smtpMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func smtpServer(t *testing.T) (string, <-chan smtpMail) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan smtpMail, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+
+		var mail smtpMail
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimSpace(line)
+			switch upper := strings.ToUpper(cmd); {
+			case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				mail.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				mail.to = append(mail.to, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case upper == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				mail.data = data.String()
+				reply("250 OK")
+			case upper == "QUIT":
+				reply("221 Bye")
+				mails <- mail
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), mails
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, mails := smtpServer(t)
+	notifier := NewSMTPNotifier(SMTPConfig{
+		Addr: addr,
+		From: "galaxy@example.com",
+		To:   []string{"ops@example.com", "oncall@example.com"},
+	})
+
+	if err := notifier.Notify(context.Background(), testMessage(EventFailed)); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	mail := <-mails
+	if mail.from != "galaxy@example.com" {
+		t.Errorf("MAIL FROM = %q, want galaxy@example.com", mail.from)
+	}
+	if len(mail.to) != 2 || mail.to[1] != "oncall@example.com" {
+		t.Errorf("RCPT TO = %v, want both recipients", mail.to)
+	}
+	for _, want := range []string{
+		"Subject: Rollback of docker failed\r\n",
+		"To: ops@example.com, oncall@example.com\r\n",
+		"Rollback of docker from v1.1.0 to v1.0.0 failed: boom",
+	} {
+		if !strings.Contains(mail.data, want) {
+			t.Errorf("mail data = %q, want it to contain %q", mail.data, want)
+		}
+	}
+}
+
+func TestSMTPNotifierWithoutRecipients(t *testing.T) {
+	if err := NewSMTPNotifier(SMTPConfig{Addr: "127.0.0.1:25"}).Notify(context.Background(), testMessage(EventStarted)); err == nil {
+		t.Error("Notify() without recipients error = nil, want error")
+	}
+}
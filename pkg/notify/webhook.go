@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultHTTPTimeout = 10 * time.Second
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("notification rejected with status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return nil
+}
+
+func httpClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: defaultHTTPTimeout}
+}
+
+type WebhookNotifier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewWebhookNotifier(url string, headers map[string]string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, headers: headers, client: httpClient(client)}
+}
+
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, message Message) error {
+	return postJSON(ctx, w.client, w.url, w.headers, message)
+}
+
+type SlackConfig struct {
+	WebhookURL string
+	Channel    string
+	Username   string
+	IconEmoji  string
+}
+
+type SlackNotifier struct {
+	config SlackConfig
+	client *http.Client
+}
+
+func NewSlackNotifier(config SlackConfig, client *http.Client) *SlackNotifier {
+	return &SlackNotifier{config: config, client: httpClient(client)}
+}
+
+func (s *SlackNotifier) Name() string {
+	return "slack"
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type slackAttachment struct {
+	Color    string       `json:"color"`
+	Title    string       `json:"title"`
+	Text     string       `json:"text"`
+	Fields   []slackField `json:"fields,omitempty"`
+	Fallback string       `json:"fallback"`
+	Ts       int64        `json:"ts,omitempty"`
+}
+
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, message Message) error {
+	fields := []slackField{
+		{Title: "Strategy", Value: message.Event.Strategy, Short: true},
+		{Title: "From", Value: message.Event.From, Short: true},
+	}
+	if message.Event.To != "" {
+		fields = append(fields, slackField{Title: "To", Value: message.Event.To, Short: true})
+	}
+
+	payload := slackPayload{
+		Text:      message.Title,
+		Channel:   s.config.Channel,
+		Username:  s.config.Username,
+		IconEmoji: s.config.IconEmoji,
+		Attachments: []slackAttachment{{
+			Color:    "#" + color(message.Event.Type),
+			Title:    message.Title,
+			Text:     message.Text,
+			Fields:   fields,
+			Fallback: message.Text,
+			Ts:       message.Event.Time.Unix(),
+		}},
+	}
+	return postJSON(ctx, s.client, s.config.WebhookURL, nil, payload)
+}
+
+type TeamsNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewTeamsNotifier(url string, client *http.Client) *TeamsNotifier {
+	return &TeamsNotifier{url: url, client: httpClient(client)}
+}
+
+func (t *TeamsNotifier) Name() string {
+	return "teams"
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsSection struct {
+	ActivityTitle string      `json:"activityTitle"`
+	Facts         []teamsFact `json:"facts"`
+	Markdown      bool        `json:"markdown"`
+}
+
+type teamsCard struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	ThemeColor string         `json:"themeColor"`
+	Summary    string         `json:"summary"`
+	Title      string         `json:"title"`
+	Text       string         `json:"text"`
+	Sections   []teamsSection `json:"sections"`
+}
+
+func (t *TeamsNotifier) Notify(ctx context.Context, message Message) error {
+	facts := []teamsFact{
+		{Name: "Strategy", Value: message.Event.Strategy},
+		{Name: "From", Value: message.Event.From},
+	}
+	if message.Event.To != "" {
+		facts = append(facts, teamsFact{Name: "To", Value: message.Event.To})
+	}
+	if message.Event.RollbackID != "" {
+		facts = append(facts, teamsFact{Name: "Rollback ID", Value: message.Event.RollbackID})
+	}
+
+	card := teamsCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: color(message.Event.Type),
+		Summary:    message.Title,
+		Title:      message.Title,
+		Text:       message.Text,
+		Sections:   []teamsSection{{ActivityTitle: string(message.Event.Type), Facts: facts, Markdown: true}},
+	}
+	return postJSON(ctx, t.client, t.url, nil, card)
+}
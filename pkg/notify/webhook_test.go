@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type capturedRequest struct {
+	header http.Header
+	body   map[string]interface{}
+}
+
+func captureServer(t *testing.T, status int) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+	requests := make(chan capturedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := make(map[string]interface{})
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("invalid JSON payload %s: %v", data, err)
+		}
+		requests <- capturedRequest{header: r.Header, body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func lookup(value interface{}, path ...interface{}) interface{} {
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = m[k]
+		case int:
+			s, ok := value.([]interface{})
+			if !ok || k >= len(s) {
+				return nil
+			}
+			value = s[k]
+		}
+	}
+	return value
+}
+
+func testMessage(eventType EventType) Message {
+	message, _ := Render(Templates{}, Event{
+		Type:       eventType,
+		RollbackID: "rb-1",
+		Strategy:   "docker",
+		From:       "v1.1.0",
+		To:         "v1.0.0",
+		Error:      "boom",
+		Time:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	})
+	return message
+}
+
+func TestHTTPNotifiers(t *testing.T) {
+	tests := []struct {
+		name      string
+		notifier  func(url string) Notifier
+		eventType EventType
+		want      map[string][]interface{}
+	}{
+		{
+			name:      "webhook",
+			notifier:  func(url string) Notifier { return NewWebhookNotifier(url, map[string]string{"X-Token": "secret"}, nil) },
+			eventType: EventStarted,
+			want: map[string][]interface{}{
+				"Rollback of docker started": {"title"},
+				"v1.1.0":                     {"event", "from_version"},
+			},
+		},
+		{
+			name: "slack",
+			notifier: func(url string) Notifier {
+				return NewSlackNotifier(SlackConfig{WebhookURL: url, Channel: "#deploys"}, nil)
+			},
+			eventType: EventFailed,
+			want: map[string][]interface{}{
+				"Rollback of docker failed": {"text"},
+				"#deploys":                  {"channel"},
+				"#D00000":                   {"attachments", 0, "color"},
+				"v1.0.0":                    {"attachments", 0, "fields", 2, "value"},
+			},
+		},
+		{
+			name:      "teams",
+			notifier:  func(url string) Notifier { return NewTeamsNotifier(url, nil) },
+			eventType: EventSucceeded,
+			want: map[string][]interface{}{
+				"MessageCard":                  {"@type"},
+				"2EB886":                       {"themeColor"},
+				"Rollback of docker succeeded": {"title"},
+				"rb-1":                         {"sections", 0, "facts", 3, "value"},
+			},
+		},
+		{
+			name: "pagerduty trigger",
+			notifier: func(url string) Notifier {
+				return NewPagerDutyNotifier(PagerDutyConfig{RoutingKey: "key", URL: url}, nil)
+			},
+			eventType: EventFailed,
+			want: map[string][]interface{}{
+				"key":                         {"routing_key"},
+				"trigger":                     {"event_action"},
+				"stable-galaxy/docker/v1.1.0": {"dedup_key"},
+				"critical":                    {"payload", "severity"},
+				"boom":                        {"payload", "custom_details", "error"},
+			},
+		},
+		{
+			name: "pagerduty resolve",
+			notifier: func(url string) Notifier {
+				return NewPagerDutyNotifier(PagerDutyConfig{RoutingKey: "key", URL: url}, nil)
+			},
+			eventType: EventSucceeded,
+			want: map[string][]interface{}{
+				"resolve":                     {"event_action"},
+				"stable-galaxy/docker/v1.1.0": {"dedup_key"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := captureServer(t, http.StatusAccepted)
+			if err := tt.notifier(server.URL).Notify(context.Background(), testMessage(tt.eventType)); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			req := <-requests
+			if req.header.Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", req.header.Get("Content-Type"))
+			}
+			for want, path := range tt.want {
+				if got := lookup(req.body, path...); got != want {
+					t.Errorf("payload %v = %v, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierHeadersAndErrors(t *testing.T) {
+	server, requests := captureServer(t, http.StatusOK)
+	if err := NewWebhookNotifier(server.URL, map[string]string{"X-Token": "secret"}, nil).Notify(context.Background(), testMessage(EventStarted)); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if got := (<-requests).header.Get("X-Token"); got != "secret" {
+		t.Errorf("X-Token header = %q, want secret", got)
+	}
+
+	failing, _ := captureServer(t, http.StatusInternalServerError)
+	if err := NewWebhookNotifier(failing.URL, nil, nil).Notify(context.Background(), testMessage(EventStarted)); err == nil {
+		t.Error("Notify() against failing endpoint error = nil, want error")
+	}
+}
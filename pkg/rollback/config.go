@@ -3,6 +3,11 @@ package rollback
 import (
 	"context"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/metrics"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/notify"
 )
 
 type RollbackConfig struct {
@@ -20,6 +25,19 @@ type RollbackConfig struct {
 	PreRollbackHook  func() error
 	PostRollbackHook func() error
 	OnFailureHook    func(error)
+	VerifyRollback   func(version string) error
+	Hooks            []HookRegistration
+
+	StateStore StateStore
+
+	Lock struct {
+		Locker Locker
+		Key    string
+		Holder string
+		TTL    time.Duration
+	}
+
+	Schema SchemaConfig
 
 	ValidateVersion    func(string) bool
 	VersionConstraints struct {
@@ -28,8 +46,10 @@ type RollbackConfig struct {
 		Blacklist  []string
 	}
 
-	MetricsEnabled bool
-	HealthCheck    struct {
+	MetricsEnabled  bool
+	MetricsRegistry *metrics.Registry
+	TracerProvider  trace.TracerProvider
+	HealthCheck     struct {
 		URL           string
 		Timeout       time.Duration
 		RetryAttempts int
@@ -37,11 +57,7 @@ type RollbackConfig struct {
 		CustomHeaders map[string]string
 	}
 
-	Notifications struct {
-		Enabled  bool
-		Channels []string
-		Webhook  string
-	}
+	Notifications notify.Config
 
 	LogLevel    string
 	LogFilePath string
@@ -72,13 +88,12 @@ func DefaultConfig() RollbackConfig {
 			SuccessStatus: 200,
 			CustomHeaders: make(map[string]string),
 		},
-		Notifications: struct {
-			Enabled  bool
-			Channels []string
-			Webhook  string
-		}{
+		Notifications: notify.Config{
 			Enabled:  true,
-			Channels: []string{"slack"},
+			Channels: []string{notify.ChannelSlack},
+		},
+		Schema: SchemaConfig{
+			Policy: SchemaBlock,
 		},
 		LogLevel: "info",
 	}
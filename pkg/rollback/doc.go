@@ -14,5 +14,8 @@ Basic usage:
 	service.RegisterVersion("v1.1.0")
 
 	err := service.Rollback("v1.1.0")
+
+Groups, plans, hooks, metrics, tracing, notifications and schema checks are
+described in the repository README.
 */
 package rollback
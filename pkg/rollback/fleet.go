@@ -0,0 +1,317 @@
+package rollback
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+const DefaultCanaryClusters = 1
+
+// CanaryClusters of 0 means DefaultCanaryClusters; a negative value rolls
+// out without a canary wave.
+type FleetConfig struct {
+	CanaryClusters int
+	WaveSize       int
+	MaxConcurrency int
+}
+
+type FleetCluster struct {
+	Name     string
+	Strategy deployment.Strategy
+}
+
+type ClusterResult struct {
+	TargetResult
+	Wave int
+}
+
+type FleetReport struct {
+	Waves   [][]string
+	Results []ClusterResult
+}
+
+func (r *FleetReport) Succeeded() bool {
+	for _, result := range r.Results {
+		if !result.Succeeded() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *FleetReport) Summary() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "WAVE\tCLUSTER\tSTATUS\tFROM\tTO\tATTEMPTS\tDURATION\tERROR")
+	for _, result := range r.Results {
+		status := "ok"
+		switch {
+		case result.Skipped:
+			status = "skipped"
+		case result.Err != nil:
+			status = "failed"
+		}
+		errMsg := ""
+		if result.Err != nil {
+			errMsg = result.Err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			result.Wave, result.Name, status, result.From, result.To, result.Attempts, result.Duration.Round(time.Millisecond), errMsg)
+	}
+	w.Flush()
+	return b.String()
+}
+
+type Fleet struct {
+	config     RollbackConfig
+	fleet      FleetConfig
+	clusters   []FleetCluster
+	logger     *logging.Logger
+	mu         sync.Mutex
+	lastReport *FleetReport
+	applied    map[string]string
+}
+
+func NewFleet(config RollbackConfig, fleetConfig FleetConfig, logger *logging.Logger) *Fleet {
+	if logger == nil {
+		logger = logging.NewLogger("info", false)
+	}
+	if fleetConfig.CanaryClusters == 0 {
+		fleetConfig.CanaryClusters = DefaultCanaryClusters
+	}
+	return &Fleet{
+		config:   config,
+		fleet:    fleetConfig,
+		clusters: make([]FleetCluster, 0),
+		logger:   logger,
+		applied:  make(map[string]string),
+	}
+}
+
+func (f *Fleet) Add(cluster FleetCluster) {
+	f.clusters = append(f.clusters, cluster)
+}
+
+func (f *Fleet) StrategyName() string {
+	return "fleet"
+}
+
+func (f *Fleet) Waves() [][]string {
+	names := make([]string, len(f.clusters))
+	for i, cluster := range f.clusters {
+		names[i] = cluster.Name
+	}
+
+	waves := make([][]string, 0)
+	canaries := f.fleet.CanaryClusters
+	if canaries > 0 && canaries < len(names) {
+		waves = append(waves, names[:canaries])
+		names = names[canaries:]
+	}
+
+	size := f.fleet.WaveSize
+	if size <= 0 {
+		size = len(names)
+	}
+	for len(names) > 0 {
+		n := size
+		if n > len(names) {
+			n = len(names)
+		}
+		waves = append(waves, names[:n])
+		names = names[n:]
+	}
+	return waves
+}
+
+func (f *Fleet) LastReport() *FleetReport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastReport
+}
+
+// alreadyApplied also checks the live version, since the cluster may have
+// been redeployed since the attempt that recorded it.
+func (f *Fleet) alreadyApplied(ctx context.Context, cluster FleetCluster, version string) bool {
+	f.mu.Lock()
+	applied := f.applied[cluster.Name] == version
+	f.mu.Unlock()
+	if !applied {
+		return false
+	}
+	current, err := deployment.CurrentVersionWithContext(ctx, cluster.Strategy)
+	return err == nil && current == version
+}
+
+func (f *Fleet) markApplied(cluster, version string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.applied[cluster] = version
+}
+
+func (f *Fleet) run(ctx context.Context, from, to string, apply func(ctx context.Context, cluster FleetCluster, from, to string) error) (*FleetReport, error) {
+	waves := f.Waves()
+	report := &FleetReport{Waves: waves, Results: make([]ClusterResult, 0, len(f.clusters))}
+	defer func() {
+		f.mu.Lock()
+		f.lastReport = report
+		f.mu.Unlock()
+	}()
+
+	concurrency := f.fleet.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = len(f.clusters)
+	}
+
+	offset := 0
+	var failed []string
+	var errs []error
+	for w, wave := range waves {
+		results := make([]ClusterResult, len(wave))
+		if len(failed) > 0 || ctx.Err() != nil {
+			for i := range wave {
+				target := GroupTarget{Name: f.clusters[offset+i].Name, Strategy: f.clusters[offset+i].Strategy, From: from, To: to}
+				results[i] = ClusterResult{TargetResult: skippedResult(target), Wave: w + 1}
+			}
+			report.Results = append(report.Results, results...)
+			offset += len(wave)
+			continue
+		}
+
+		f.logger.Info().Int("wave", w+1).Strs("clusters", wave).Msg("Starting fleet wave")
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range wave {
+			wg.Add(1)
+			go func(i int, cluster FleetCluster) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				target := GroupTarget{Name: cluster.Name, Strategy: cluster.Strategy, From: from, To: to}
+				result := TargetResult{Name: cluster.Name, Strategy: cluster.Strategy.StrategyName(), To: to}
+				if f.alreadyApplied(ctx, cluster, to) {
+					f.logger.Info().Str("cluster", cluster.Name).Str("version", to).Msg("Cluster already rolled out by a previous attempt, skipping")
+					result.From = to
+					results[i] = ClusterResult{TargetResult: result, Wave: w + 1}
+					return
+				}
+				resolved, err := resolveTarget(ctx, target)
+				if err != nil {
+					result.Err = err
+					results[i] = ClusterResult{TargetResult: result, Wave: w + 1}
+					return
+				}
+				result.From = resolved.From
+
+				start := time.Now()
+				result.Attempts, result.Err = retryWithBackoff(ctx, f.config.MaxAttempts, f.config.BackoffDuration, func(attempt int) error {
+					f.logger.Info().Str("cluster", cluster.Name).Int("attempt", attempt).Msg("Attempting cluster rollout")
+					return apply(ctx, cluster, resolved.From, to)
+				})
+				result.Duration = time.Since(start)
+				if result.Err != nil {
+					f.logger.Error().Err(result.Err).Str("cluster", cluster.Name).Msg("Cluster rollout failed")
+				} else {
+					f.markApplied(cluster.Name, to)
+				}
+				results[i] = ClusterResult{TargetResult: result, Wave: w + 1}
+			}(i, f.clusters[offset+i])
+		}
+		wg.Wait()
+
+		for _, result := range results {
+			if result.Err != nil {
+				failed = append(failed, result.Name)
+				errs = append(errs, fmt.Errorf("cluster %s: %w", result.Name, result.Err))
+			}
+		}
+		report.Results = append(report.Results, results...)
+		offset += len(wave)
+	}
+
+	if len(failed) == 0 && ctx.Err() != nil {
+		return report, errors.NewDeploymentError("fleet rollout cancelled", context.Cause(ctx), nil)
+	}
+	if len(failed) > 0 {
+		return report, errors.NewDeploymentError("fleet rollout failed", stderrors.Join(errs...), map[string]interface{}{
+			"failed_clusters": failed,
+		})
+	}
+
+	f.mu.Lock()
+	f.applied = make(map[string]string)
+	f.mu.Unlock()
+	return report, nil
+}
+
+func (f *Fleet) RollbackFleet(from, to string) (*FleetReport, error) {
+	return f.rollbackFleet(configContext(f.config), from, to)
+}
+
+func (f *Fleet) rollbackFleet(ctx context.Context, from, to string) (*FleetReport, error) {
+	return f.run(ctx, from, to, func(ctx context.Context, cluster FleetCluster, from, to string) error {
+		return deployment.RollbackWithContext(ctx, cluster.Strategy, from, to)
+	})
+}
+
+func (f *Fleet) DeployFleet(version string) (*FleetReport, error) {
+	return f.deployFleet(configContext(f.config), version)
+}
+
+func (f *Fleet) deployFleet(ctx context.Context, version string) (*FleetReport, error) {
+	return f.run(ctx, "", version, func(ctx context.Context, cluster FleetCluster, _, to string) error {
+		return deployment.DeployWithContext(ctx, cluster.Strategy, to)
+	})
+}
+
+func (f *Fleet) Rollback(from, to string) error {
+	_, err := f.RollbackFleet(from, to)
+	return err
+}
+
+func (f *Fleet) RollbackContext(ctx context.Context, from, to string) error {
+	_, err := f.rollbackFleet(ctx, from, to)
+	return err
+}
+
+func (f *Fleet) Deploy(version string) error {
+	_, err := f.DeployFleet(version)
+	return err
+}
+
+func (f *Fleet) DeployContext(ctx context.Context, version string) error {
+	_, err := f.deployFleet(ctx, version)
+	return err
+}
+
+func (f *Fleet) GetCurrentVersion() (string, error) {
+	return f.GetCurrentVersionContext(configContext(f.config))
+}
+
+func (f *Fleet) GetCurrentVersionContext(ctx context.Context) (string, error) {
+	current := ""
+	for _, cluster := range f.clusters {
+		version, err := deployment.CurrentVersionWithContext(ctx, cluster.Strategy)
+		if err != nil {
+			return "", fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+		if current != "" && version != current {
+			return "", fmt.Errorf("fleet version diverged: %s runs %s, expected %s", cluster.Name, version, current)
+		}
+		current = version
+	}
+	if current == "" {
+		return "", fmt.Errorf("fleet has no clusters")
+	}
+	return current, nil
+}
@@ -0,0 +1,199 @@
+package rollback
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+type concurrencyProbe struct {
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+type probedStrategy struct {
+	*versionedStrategy
+	probe *concurrencyProbe
+}
+
+func (p *probedStrategy) Rollback(from, to string) error {
+	p.probe.mu.Lock()
+	p.probe.active++
+	if p.probe.active > p.probe.maxSeen {
+		p.probe.maxSeen = p.probe.active
+	}
+	p.probe.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	p.probe.mu.Lock()
+	p.probe.active--
+	p.probe.mu.Unlock()
+	return p.versionedStrategy.Rollback(from, to)
+}
+
+func TestFleetWaves(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FleetConfig
+		want   string
+	}{
+		{name: "canary then rest", config: FleetConfig{}, want: "[a] [b c d e]"},
+		{name: "two canaries and waves of two", config: FleetConfig{CanaryClusters: 2, WaveSize: 2}, want: "[a b] [c d] [e]"},
+		{name: "no canary", config: FleetConfig{CanaryClusters: -1}, want: "[a b c d e]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fleet := NewFleet(RollbackConfig{MaxAttempts: 1}, tt.config, logging.NewLogger("error", true))
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				fleet.Add(FleetCluster{Name: name, Strategy: &versionedStrategy{name: "kubernetes", current: "v2"}})
+			}
+			waves := make([]string, 0)
+			for _, wave := range fleet.Waves() {
+				waves = append(waves, "["+strings.Join(wave, " ")+"]")
+			}
+			if got := strings.Join(waves, " "); got != tt.want {
+				t.Errorf("Waves() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFleetRollbackConcurrencyLimit(t *testing.T) {
+	probe := &concurrencyProbe{}
+	strategies := newVersionedStrategies("v2", "", "a", "b", "c", "d", "e")
+	fleet := NewFleet(RollbackConfig{MaxAttempts: 1}, FleetConfig{MaxConcurrency: 2}, logging.NewLogger("error", true))
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		fleet.Add(FleetCluster{Name: name, Strategy: &probedStrategy{versionedStrategy: strategies[name], probe: probe}})
+	}
+
+	report, err := fleet.RollbackFleet("v2", "v1")
+	if err != nil {
+		t.Fatalf("RollbackFleet() error = %v", err)
+	}
+	if !report.Succeeded() {
+		t.Error("report.Succeeded() = false, want true")
+	}
+	if probe.maxSeen > 2 {
+		t.Errorf("max concurrent rollbacks = %d, want <= 2", probe.maxSeen)
+	}
+	for name, strategy := range strategies {
+		if strategy.current != "v1" {
+			t.Errorf("%s version = %s, want v1", name, strategy.current)
+		}
+	}
+	if fleet.LastReport() != report {
+		t.Error("LastReport() does not return the latest report")
+	}
+}
+
+func TestFleetCanaryFailureHaltsRollout(t *testing.T) {
+	strategies := newVersionedStrategies("v2", "a", "a", "b", "c")
+	fleet := NewFleet(RollbackConfig{MaxAttempts: 1}, FleetConfig{}, logging.NewLogger("error", true))
+	for _, name := range []string{"a", "b", "c"} {
+		fleet.Add(FleetCluster{Name: name, Strategy: strategies[name]})
+	}
+
+	report, err := fleet.RollbackFleet("v2", "v1")
+	if err == nil {
+		t.Fatal("RollbackFleet() expected error")
+	}
+
+	for _, result := range report.Results {
+		if result.Name != "a" && !result.Skipped {
+			t.Errorf("cluster %s was not skipped after canary failure", result.Name)
+		}
+	}
+	if strategies["b"].rollbacks != 0 || strategies["c"].rollbacks != 0 {
+		t.Error("later waves ran after canary failure")
+	}
+
+	summary := report.Summary()
+	if !strings.Contains(summary, "failed") || !strings.Contains(summary, "skipped") {
+		t.Errorf("Summary() = \n%s", summary)
+	}
+}
+
+func TestFleetRetrySkipsAppliedClusters(t *testing.T) {
+	strategies := newVersionedStrategies("v2", "b", "a", "b", "c")
+	fleet := NewFleet(RollbackConfig{MaxAttempts: 1}, FleetConfig{}, logging.NewLogger("error", true))
+	for _, name := range []string{"a", "b", "c"} {
+		fleet.Add(FleetCluster{Name: name, Strategy: strategies[name]})
+	}
+
+	_, err := fleet.RollbackFleet("v2", "v1")
+	if err == nil {
+		t.Fatal("RollbackFleet() expected error")
+	}
+	if !strings.Contains(err.Error(), "cluster b: rollback failed") {
+		t.Errorf("RollbackFleet() error = %v, want the cluster error", err)
+	}
+
+	strategies["b"].failRollback = false
+	report, err := fleet.RollbackFleet("v2", "v1")
+	if err != nil {
+		t.Fatalf("RollbackFleet() retry error = %v", err)
+	}
+	if !report.Succeeded() {
+		t.Errorf("report.Succeeded() = false, want true:\n%s", report.Summary())
+	}
+	for name, want := range map[string]int{"a": 1, "b": 2, "c": 1} {
+		if got := strategies[name].rollbacks; got != want {
+			t.Errorf("%s rollbacks = %d, want %d", name, got, want)
+		}
+	}
+
+	if _, err := fleet.RollbackFleet("v2", "v1"); err != nil {
+		t.Fatalf("RollbackFleet() after success error = %v", err)
+	}
+	if strategies["a"].rollbacks != 2 {
+		t.Errorf("a rollbacks = %d, want a fresh run after a successful rollout", strategies["a"].rollbacks)
+	}
+}
+
+func TestFleetRetryRedeploysDriftedClusters(t *testing.T) {
+	strategies := newVersionedStrategies("v2", "b", "a", "b")
+	fleet := NewFleet(RollbackConfig{MaxAttempts: 1}, FleetConfig{}, logging.NewLogger("error", true))
+	for _, name := range []string{"a", "b"} {
+		fleet.Add(FleetCluster{Name: name, Strategy: strategies[name]})
+	}
+
+	if _, err := fleet.RollbackFleet("v2", "v1"); err == nil {
+		t.Fatal("RollbackFleet() expected error")
+	}
+	_ = strategies["a"].Deploy("v2")
+
+	strategies["b"].failRollback = false
+	if _, err := fleet.RollbackFleet("v2", "v1"); err != nil {
+		t.Fatalf("RollbackFleet() retry error = %v", err)
+	}
+	if got := strategies["a"].rollbacks; got != 2 {
+		t.Errorf("a rollbacks = %d, want 2 after the cluster was redeployed", got)
+	}
+	if got := strategies["a"].current; got != "v1" {
+		t.Errorf("a version = %s, want v1", got)
+	}
+}
+
+func TestFleetPassesContextToClusters(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cluster := &versionedStrategy{name: "kubernetes", current: "v2"}
+	fleet := NewFleet(RollbackConfig{MaxAttempts: 1}, FleetConfig{}, logging.NewLogger("error", true))
+	fleet.Add(FleetCluster{Name: "eu-west", Strategy: cluster})
+
+	if err := deployment.RollbackWithContext(ctx, fleet, "v2", "v1"); err == nil {
+		t.Fatal("RollbackWithContext() with a cancelled context error = nil, want error")
+	}
+	if cluster.rollbacks != 0 {
+		t.Errorf("cluster rollbacks = %d, want none after cancellation", cluster.rollbacks)
+	}
+}
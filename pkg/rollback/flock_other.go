@@ -0,0 +1,17 @@
+//go:build !unix
+
+package rollback
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func lockFile(*os.File) error {
+	return fmt.Errorf("file locking is not supported on %s", runtime.GOOS)
+}
+
+func unlockFile(*os.File) error {
+	return nil
+}
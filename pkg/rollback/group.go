@@ -0,0 +1,365 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/notify"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
+)
+
+type GroupMode string
+
+const (
+	GroupParallel   GroupMode = "parallel"
+	GroupSequential GroupMode = "sequential"
+)
+
+type GroupTarget struct {
+	Name     string
+	Strategy deployment.Strategy
+	From     string
+	To       string
+}
+
+type TargetResult struct {
+	Name            string
+	Strategy        string
+	From            string
+	To              string
+	Attempts        int
+	Duration        time.Duration
+	Err             error
+	Skipped         bool
+	Compensated     bool
+	CompensationErr error
+
+	// started is set once the strategy's rollback was called, so only
+	// targets that may have changed are compensated.
+	started bool
+}
+
+func (r TargetResult) Succeeded() bool {
+	return r.Err == nil && !r.Skipped
+}
+
+type GroupReport struct {
+	Mode    GroupMode
+	Results []TargetResult
+}
+
+func (r *GroupReport) Succeeded() bool {
+	return allSucceeded(r.Results)
+}
+
+func (r *GroupReport) Failed() []TargetResult {
+	return failedResults(r.Results)
+}
+
+func allSucceeded(results []TargetResult) bool {
+	for _, result := range results {
+		if !result.Succeeded() {
+			return false
+		}
+	}
+	return true
+}
+
+func failedResults(results []TargetResult) []TargetResult {
+	failed := make([]TargetResult, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+type Group struct {
+	config   RollbackConfig
+	mode     GroupMode
+	targets  []GroupTarget
+	logger   *logging.Logger
+	metrics  *serviceMetrics
+	notifier *notify.Dispatcher
+}
+
+func NewGroup(config RollbackConfig, mode GroupMode, logger *logging.Logger) *Group {
+	if logger == nil {
+		logger = logging.NewLogger("info", false)
+	}
+	if mode == "" {
+		mode = GroupParallel
+	}
+	return &Group{
+		config:   config,
+		mode:     mode,
+		targets:  make([]GroupTarget, 0),
+		logger:   logger,
+		metrics:  configMetrics(config),
+		notifier: newDispatcher(config, logger),
+	}
+}
+
+func (g *Group) Add(target GroupTarget) {
+	if target.Name == "" {
+		target.Name = target.Strategy.StrategyName()
+	}
+	target.Strategy = wrapStrategy(g.config, target.Name, target.Strategy)
+	g.targets = append(g.targets, target)
+}
+
+func retryWithBackoff(ctx context.Context, maxAttempts int, backoff time.Duration, fn func(attempt int) error) (int, error) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = fn(attempt); err == nil {
+			return attempt, nil
+		}
+		if IsVeto(err) || attempt == maxAttempts {
+			return attempt, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, errors.NewDeploymentError("rollback cancelled", context.Cause(ctx), nil)
+		case <-timer.C:
+		}
+	}
+	return maxAttempts, err
+}
+
+func resolveTarget(ctx context.Context, target GroupTarget) (GroupTarget, error) {
+	if target.To == "" {
+		return target, errors.NewValidationError(fmt.Sprintf("target %s has no rollback version", target.Name), nil)
+	}
+	if target.From == "" {
+		current, err := deployment.CurrentVersionWithContext(ctx, target.Strategy)
+		if err != nil {
+			return target, errors.NewDeploymentError("failed to read current version", err, map[string]interface{}{
+				"target":   target.Name,
+				"strategy": target.Strategy.StrategyName(),
+			})
+		}
+		target.From = current
+	}
+	return target, nil
+}
+
+func (g *Group) resolveTargets(ctx context.Context) ([]GroupTarget, error) {
+	resolved := make([]GroupTarget, len(g.targets))
+	for i, target := range g.targets {
+		target, err := resolveTarget(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = target
+	}
+	return resolved, nil
+}
+
+func configContext(config RollbackConfig) context.Context {
+	if config.Context != nil {
+		return config.Context
+	}
+	return context.Background()
+}
+
+func targetAttributes(target GroupTarget) trace.SpanStartOption {
+	attrs := append([]attribute.KeyValue{
+		attribute.String("rollback.target", target.Name),
+		attribute.String("rollback.strategy", target.Strategy.StrategyName()),
+	}, tracing.Versions(target.From, target.To)...)
+	return trace.WithAttributes(attrs...)
+}
+
+func targetEvent(config RollbackConfig, phase HookPhase, target GroupTarget, attempt int, err error) RollbackEvent {
+	return RollbackEvent{
+		Phase:       phase,
+		Strategy:    target.Strategy.StrategyName(),
+		From:        target.From,
+		To:          target.To,
+		Attempt:     attempt,
+		MaxAttempts: config.MaxAttempts,
+		Plan:        target.Name,
+		DryRun:      config.DryRun,
+		Err:         err,
+	}
+}
+
+func rollbackTarget(ctx context.Context, config RollbackConfig, logger *logging.Logger, metrics *serviceMetrics, target GroupTarget) (result TargetResult) {
+	tracer := tracing.Tracer(config.TracerProvider)
+	ctx, span := tracer.Start(ctx, "rollback.target", targetAttributes(target))
+	hooks := newHookRunner(append(legacyHooks(config), config.Hooks...), logger, tracer)
+	result = TargetResult{
+		Name:     target.Name,
+		Strategy: target.Strategy.StrategyName(),
+		From:     target.From,
+		To:       target.To,
+	}
+
+	start := time.Now()
+	metrics.start(result.Strategy)
+	defer func() {
+		metrics.finish(result.Strategy, start, result.Err)
+		result.Duration = time.Since(start)
+		span.SetAttributes(attribute.Int("rollback.attempts", result.Attempts))
+		tracing.End(span, result.Err)
+	}()
+
+	schema := newSchemaGuard(config.Schema, hooks, logger, tracer)
+	if result.Err = schema.ensure(ctx, targetEvent(config, HookPreRollback, target, 0, nil)); result.Err != nil {
+		_ = hooks.run(ctx, targetEvent(config, HookOnFailure, target, 0, result.Err))
+		return result
+	}
+	if result.Err = hooks.run(ctx, targetEvent(config, HookPreRollback, target, 0, nil)); result.Err != nil {
+		return result
+	}
+
+	result.Attempts, result.Err = retryWithBackoff(ctx, config.MaxAttempts, config.BackoffDuration, func(attempt int) error {
+		logger.Info().Str("target", target.Name).Int("attempt", attempt).Str("from", target.From).Str("to", target.To).Msg("Attempting target rollback")
+		attemptCtx, attemptSpan := tracer.Start(ctx, "rollback.attempt", trace.WithAttributes(attribute.Int("rollback.attempt", attempt)))
+		err := hooks.run(attemptCtx, targetEvent(config, HookPreDeploy, target, attempt, nil))
+		if err == nil {
+			result.started = true
+			err = deployment.RollbackWithContext(attemptCtx, target.Strategy, target.From, target.To)
+		}
+		if err == nil {
+			err = hooks.run(attemptCtx, targetEvent(config, HookPostDeploy, target, attempt, nil))
+		}
+		tracing.End(attemptSpan, err)
+		if err != nil {
+			logger.Warn().Err(err).Str("target", target.Name).Int("attempt", attempt).Msg("Target rollback attempt failed")
+		}
+		return err
+	})
+	metrics.attempt(result.Strategy, result.Attempts)
+
+	if result.Err == nil {
+		result.Err = hooks.run(ctx, targetEvent(config, HookPostRollback, target, result.Attempts, nil))
+	} else if !IsVeto(result.Err) {
+		_ = hooks.run(ctx, targetEvent(config, HookOnFailure, target, result.Attempts, result.Err))
+	}
+	return result
+}
+
+func compensateTarget(ctx context.Context, config RollbackConfig, logger *logging.Logger, target GroupTarget, result *TargetResult) {
+	if !result.started {
+		return
+	}
+	ctx, span := tracing.Tracer(config.TracerProvider).Start(ctx, "rollback.compensate", targetAttributes(target))
+	logger.Warn().Str("target", target.Name).Str("version", target.From).Msg("Compensating by re-deploying original version")
+	_, err := retryWithBackoff(ctx, config.MaxAttempts, config.BackoffDuration, func(int) error {
+		return deployment.DeployWithContext(ctx, target.Strategy, target.From)
+	})
+	tracing.End(span, err)
+	result.Compensated = err == nil
+	result.CompensationErr = err
+	if err != nil {
+		logger.Error().Err(err).Str("target", target.Name).Msg("Compensation failed")
+	}
+}
+
+func skippedResult(target GroupTarget) TargetResult {
+	return TargetResult{
+		Name:     target.Name,
+		Strategy: target.Strategy.StrategyName(),
+		From:     target.From,
+		To:       target.To,
+		Skipped:  true,
+	}
+}
+
+func (g *Group) compensate(ctx context.Context, targets []GroupTarget, results []TargetResult) {
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].Skipped || !results[i].started {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			compensateTarget(ctx, g.config, g.logger, targets[i], &results[i])
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (g *Group) Rollback() (_ *GroupReport, err error) {
+	report := &GroupReport{Mode: g.mode}
+	ctx, span := tracing.Tracer(g.config.TracerProvider).Start(configContext(g.config), "rollback.Group", trace.WithAttributes(
+		attribute.String("rollback.group_mode", string(g.mode)),
+		attribute.Int("rollback.targets", len(g.targets)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	targets, err := g.resolveTargets(ctx)
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Failed to resolve group targets")
+		return report, err
+	}
+
+	g.logger.Info().Int("targets", len(targets)).Str("mode", string(g.mode)).Msg("Starting group rollback")
+	for _, target := range targets {
+		sendNotification(g.notifier, g.logger, startedEvent(target))
+	}
+	results := make([]TargetResult, len(targets))
+
+	switch g.mode {
+	case GroupSequential:
+		failed := false
+		for i, target := range targets {
+			if failed {
+				results[i] = skippedResult(target)
+				continue
+			}
+			results[i] = rollbackTarget(ctx, g.config, g.logger, g.metrics, target)
+			failed = results[i].Err != nil
+		}
+	case GroupParallel:
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func(i int, target GroupTarget) {
+				defer wg.Done()
+				results[i] = rollbackTarget(ctx, g.config, g.logger, g.metrics, target)
+			}(i, target)
+		}
+		wg.Wait()
+	default:
+		return report, errors.NewValidationError(fmt.Sprintf("unknown group mode %q", g.mode), nil)
+	}
+
+	report.Results = results
+	failed := report.Failed()
+	if len(failed) == 0 {
+		g.logger.Info().Int("targets", len(targets)).Msg("Group rollback completed successfully")
+		notifyResults(g.notifier, g.logger, results)
+		return report, nil
+	}
+
+	g.compensate(ctx, targets, results)
+	notifyResults(g.notifier, g.logger, results)
+
+	names := make([]string, len(failed))
+	for i, result := range failed {
+		names[i] = result.Name
+	}
+	err = errors.NewDeploymentError("group rollback failed", failed[0].Err, map[string]interface{}{
+		"failed_targets": names,
+		"mode":           string(g.mode),
+	})
+	g.logger.Error().Err(err).Strs("failed_targets", names).Msg("Group rollback failed")
+	return report, err
+}
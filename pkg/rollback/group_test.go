@@ -0,0 +1,213 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+type versionedStrategy struct {
+	mu           sync.Mutex
+	name         string
+	current      string
+	failRollback bool
+	rollbacks    int
+	deploys      []string
+}
+
+func (v *versionedStrategy) Rollback(from, to string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rollbacks++
+	if v.failRollback {
+		return errors.New("rollback failed")
+	}
+	v.current = to
+	return nil
+}
+
+func (v *versionedStrategy) Deploy(version string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.deploys = append(v.deploys, version)
+	v.current = version
+	return nil
+}
+
+func (v *versionedStrategy) GetCurrentVersion() (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.current, nil
+}
+
+func (v *versionedStrategy) StrategyName() string {
+	return v.name
+}
+
+func newVersionedStrategies(current, failing string, names ...string) map[string]*versionedStrategy {
+	strategies := make(map[string]*versionedStrategy, len(names))
+	for _, name := range names {
+		strategies[name] = &versionedStrategy{name: name, current: current, failRollback: name == failing}
+	}
+	return strategies
+}
+
+func TestGroupRollback(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	config := RollbackConfig{MaxAttempts: 2, BackoffDuration: time.Millisecond}
+
+	tests := []struct {
+		name           string
+		mode           GroupMode
+		failDocker     bool
+		wantErr        bool
+		wantDockerRuns int
+		wantK8sRuns    int
+		wantK8sVersion string
+		wantK8sSkipped bool
+	}{
+		{
+			name:           "parallel success",
+			mode:           GroupParallel,
+			wantDockerRuns: 1,
+			wantK8sRuns:    1,
+			wantK8sVersion: "v1.0.0",
+		},
+		{
+			name:           "parallel failure compensates",
+			mode:           GroupParallel,
+			failDocker:     true,
+			wantErr:        true,
+			wantDockerRuns: 2,
+			wantK8sRuns:    1,
+			wantK8sVersion: "v1.1.0",
+		},
+		{
+			name:           "sequential failure skips remaining",
+			mode:           GroupSequential,
+			failDocker:     true,
+			wantErr:        true,
+			wantDockerRuns: 2,
+			wantK8sRuns:    0,
+			wantK8sVersion: "v1.1.0",
+			wantK8sSkipped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docker := &versionedStrategy{name: "docker", current: "v1.1.0", failRollback: tt.failDocker}
+			k8s := &versionedStrategy{name: "kubernetes", current: "v1.1.0"}
+
+			group := NewGroup(config, tt.mode, logger)
+			group.Add(GroupTarget{Strategy: docker, To: "v1.0.0"})
+			group.Add(GroupTarget{Strategy: k8s, To: "v1.0.0"})
+
+			report, err := group.Rollback()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rollback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if report.Succeeded() == tt.wantErr {
+				t.Errorf("report.Succeeded() = %v, want %v", report.Succeeded(), !tt.wantErr)
+			}
+			if docker.rollbacks != tt.wantDockerRuns {
+				t.Errorf("docker rollbacks = %d, want %d", docker.rollbacks, tt.wantDockerRuns)
+			}
+			if k8s.rollbacks != tt.wantK8sRuns {
+				t.Errorf("kubernetes rollbacks = %d, want %d", k8s.rollbacks, tt.wantK8sRuns)
+			}
+			if k8s.current != tt.wantK8sVersion {
+				t.Errorf("kubernetes version = %s, want %s", k8s.current, tt.wantK8sVersion)
+			}
+
+			if len(report.Results) != 2 {
+				t.Fatalf("expected 2 results, got %d", len(report.Results))
+			}
+			k8sResult := report.Results[1]
+			if k8sResult.Skipped != tt.wantK8sSkipped {
+				t.Errorf("kubernetes skipped = %v, want %v", k8sResult.Skipped, tt.wantK8sSkipped)
+			}
+			if k8sResult.From != "v1.1.0" {
+				t.Errorf("kubernetes from = %s, want v1.1.0", k8sResult.From)
+			}
+			if tt.wantErr && !tt.wantK8sSkipped && !k8sResult.Compensated {
+				t.Error("kubernetes target was not compensated")
+			}
+		})
+	}
+}
+
+func TestGroupRollbackRequiresTargetVersion(t *testing.T) {
+	group := NewGroup(RollbackConfig{MaxAttempts: 1}, GroupParallel, logging.NewLogger("error", true))
+	strategy := &versionedStrategy{name: "docker", current: "v1.1.0"}
+	group.Add(GroupTarget{Strategy: strategy})
+
+	if _, err := group.Rollback(); err == nil {
+		t.Error("Rollback() expected validation error")
+	}
+	if strategy.rollbacks != 0 {
+		t.Errorf("rollbacks = %d, want 0", strategy.rollbacks)
+	}
+}
+
+func TestGroupCompensatesOnlyStartedTargets(t *testing.T) {
+	config := RollbackConfig{
+		MaxAttempts:     1,
+		BackoffDuration: time.Millisecond,
+		Hooks: []HookRegistration{{
+			Name:   "guard",
+			Phases: []HookPhase{HookPreDeploy},
+			Hook: HookFunc(func(_ context.Context, event RollbackEvent) error {
+				if event.Plan == "db" {
+					return errors.New("approval service unavailable")
+				}
+				return nil
+			}),
+		}},
+	}
+	api := &versionedStrategy{name: "api", current: "v2"}
+	db := &versionedStrategy{name: "db", current: "v2"}
+
+	group := NewGroup(config, GroupParallel, logging.NewLogger("error", true))
+	group.Add(GroupTarget{Name: "api", Strategy: api, To: "v1"})
+	group.Add(GroupTarget{Name: "db", Strategy: db, To: "v1"})
+
+	report, err := group.Rollback()
+	if err == nil {
+		t.Fatal("Rollback() error = nil, want the db hook failure")
+	}
+	if !report.Results[0].Compensated || len(api.deploys) != 1 {
+		t.Errorf("api compensated = %v deploys = %v, want one compensating deploy", report.Results[0].Compensated, api.deploys)
+	}
+	if report.Results[1].Compensated || len(db.deploys) != 0 {
+		t.Errorf("db compensated = %v deploys = %v, want none before its strategy ran", report.Results[1].Compensated, db.deploys)
+	}
+}
+
+func TestGroupBackoffStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	config := RollbackConfig{MaxAttempts: 5, BackoffDuration: time.Hour, Context: ctx}
+	strategy := &versionedStrategy{name: "docker", current: "v2", failRollback: true}
+
+	group := NewGroup(config, GroupSequential, logging.NewLogger("error", true))
+	group.Add(GroupTarget{Strategy: strategy, To: "v1"})
+
+	done := make(chan *GroupReport, 1)
+	go func() {
+		report, _ := group.Rollback()
+		done <- report
+	}()
+	select {
+	case report := <-done:
+		if report.Results[0].Attempts != 1 || strategy.rollbacks != 1 {
+			t.Errorf("attempts = %d rollbacks = %d, want one attempt before cancellation", report.Results[0].Attempts, strategy.rollbacks)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Rollback() still backing off after the context ended")
+	}
+}
@@ -0,0 +1,227 @@
+package rollback
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
+)
+
+type HookPhase string
+
+const (
+	HookPreRollback  HookPhase = "pre_rollback"
+	HookPreDeploy    HookPhase = "pre_deploy"
+	HookPostDeploy   HookPhase = "post_deploy"
+	HookPreVerify    HookPhase = "pre_verify"
+	HookPostVerify   HookPhase = "post_verify"
+	HookPostRollback HookPhase = "post_rollback"
+	HookOnFailure    HookPhase = "on_failure"
+	HookDownMigrate  HookPhase = "down_migrate"
+)
+
+func (p HookPhase) vetoable() bool {
+	return p == HookPreRollback || p == HookPreDeploy || p == HookPreVerify
+}
+
+type HookFailurePolicy string
+
+const (
+	HookFailureAbort  HookFailurePolicy = "abort"
+	HookFailureIgnore HookFailurePolicy = "ignore"
+)
+
+type RollbackEvent struct {
+	Phase       HookPhase
+	RollbackID  string
+	Strategy    string
+	From        string
+	To          string
+	Attempt     int
+	MaxAttempts int
+	Plan        string
+	DryRun      bool
+	SchemaFrom  string
+	SchemaTo    string
+	Err         error
+	Time        time.Time
+}
+
+type Hook interface {
+	Run(ctx context.Context, event RollbackEvent) error
+}
+
+type HookFunc func(ctx context.Context, event RollbackEvent) error
+
+func (f HookFunc) Run(ctx context.Context, event RollbackEvent) error {
+	return f(ctx, event)
+}
+
+type HookRegistration struct {
+	Name          string
+	Phases        []HookPhase
+	Hook          Hook
+	Timeout       time.Duration
+	FailurePolicy HookFailurePolicy
+}
+
+func (r HookRegistration) handles(phase HookPhase) bool {
+	for _, p := range r.Phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+type VetoError struct {
+	Hook   string
+	Reason string
+}
+
+func (v *VetoError) Error() string {
+	if v.Hook == "" {
+		return "rollback vetoed: " + v.Reason
+	}
+	return fmt.Sprintf("rollback vetoed by hook %s: %s", v.Hook, v.Reason)
+}
+
+func Veto(reason string) error {
+	return &VetoError{Reason: reason}
+}
+
+func IsVeto(err error) bool {
+	var veto *VetoError
+	return stderrors.As(err, &veto)
+}
+
+type hookRunner struct {
+	hooks  []HookRegistration
+	logger *logging.Logger
+	tracer trace.Tracer
+}
+
+func newHookRunner(hooks []HookRegistration, logger *logging.Logger, tracer trace.Tracer) *hookRunner {
+	return &hookRunner{hooks: hooks, logger: logger, tracer: tracer}
+}
+
+func legacyHooks(config RollbackConfig) []HookRegistration {
+	hooks := make([]HookRegistration, 0, 3)
+	if config.PreRollbackHook != nil {
+		hook := config.PreRollbackHook
+		hooks = append(hooks, HookRegistration{
+			Name:   "pre_rollback",
+			Phases: []HookPhase{HookPreRollback},
+			Hook:   HookFunc(func(context.Context, RollbackEvent) error { return hook() }),
+		})
+	}
+	if config.PostRollbackHook != nil {
+		hook := config.PostRollbackHook
+		hooks = append(hooks, HookRegistration{
+			Name:   "post_rollback",
+			Phases: []HookPhase{HookPostRollback},
+			Hook:   HookFunc(func(context.Context, RollbackEvent) error { return hook() }),
+		})
+	}
+	if config.OnFailureHook != nil {
+		hook := config.OnFailureHook
+		hooks = append(hooks, HookRegistration{
+			Name:   "on_failure",
+			Phases: []HookPhase{HookOnFailure},
+			Hook: HookFunc(func(_ context.Context, event RollbackEvent) error {
+				hook(event.Err)
+				return nil
+			}),
+		})
+	}
+	return hooks
+}
+
+func (h *hookRunner) has(phase HookPhase) bool {
+	for _, registration := range h.hooks {
+		if registration.handles(phase) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *hookRunner) call(ctx context.Context, registration HookRegistration, event RollbackEvent) (err error) {
+	ctx, span := h.tracer.Start(ctx, "rollback.hook", trace.WithAttributes(
+		attribute.String("rollback.hook", registration.Name),
+		attribute.String("rollback.hook_phase", string(event.Phase)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if registration.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, registration.Timeout)
+		defer cancel()
+	}
+
+	// The hook runs in its own goroutine so a timeout is enforced even when
+	// the hook ignores ctx. Such a hook keeps running in the background until
+	// it returns; done is buffered so that goroutine never blocks.
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("hook panicked: %v", r)
+			}
+		}()
+		done <- registration.Hook.Run(ctx, event)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("hook timed out: %w", ctx.Err())
+	}
+}
+
+func (h *hookRunner) run(ctx context.Context, event RollbackEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, registration := range h.hooks {
+		if !registration.handles(event.Phase) {
+			continue
+		}
+
+		h.logger.Debug().Str("hook", registration.Name).Str("phase", string(event.Phase)).Msg("Executing hook")
+		err := h.call(ctx, registration, event)
+		if err == nil {
+			continue
+		}
+
+		var veto *VetoError
+		if stderrors.As(err, &veto) && event.Phase.vetoable() {
+			if veto.Hook == "" {
+				veto.Hook = registration.Name
+			}
+			h.logger.Warn().Str("hook", registration.Name).Str("phase", string(event.Phase)).Str("reason", veto.Reason).Msg("Rollback vetoed by hook")
+			return errors.NewHookError(veto.Error(), veto, map[string]interface{}{
+				"hook":  registration.Name,
+				"phase": string(event.Phase),
+			})
+		}
+		if registration.FailurePolicy == HookFailureIgnore || event.Phase == HookOnFailure {
+			h.logger.Warn().Err(err).Str("hook", registration.Name).Str("phase", string(event.Phase)).Msg("Ignoring hook failure")
+			continue
+		}
+		h.logger.Error().Err(err).Str("hook", registration.Name).Str("phase", string(event.Phase)).Msg("Hook failed")
+		return errors.NewHookError(fmt.Sprintf("%s hook %s failed", event.Phase, registration.Name), err, map[string]interface{}{
+			"hook":  registration.Name,
+			"phase": string(event.Phase),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package rollback
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+)
+
+const (
+	HookTypeExec          = "exec"
+	HookTypeHTTP          = "http"
+	HookTypeKubernetesJob = "kubernetes_job"
+
+	defaultHookTimeout = 5 * time.Minute
+)
+
+type HookSpec struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	Phases        []HookPhase       `json:"phases"`
+	Timeout       time.Duration     `json:"-"`
+	IgnoreFailure bool              `json:"ignoreFailure,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+
+	Command      string   `json:"command,omitempty"`
+	Args         []string `json:"args,omitempty"`
+	Dir          string   `json:"dir,omitempty"`
+	VetoExitCode int      `json:"vetoExitCode,omitempty"`
+
+	URL       string            `json:"url,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Secret    string            `json:"secret,omitempty"`
+	SecretEnv string            `json:"secretEnv,omitempty"`
+
+	Namespace      string `json:"namespace,omitempty"`
+	Image          string `json:"image,omitempty"`
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+}
+
+func (s *HookSpec) UnmarshalJSON(data []byte) error {
+	type plain HookSpec
+	aux := struct {
+		*plain
+		Timeout string `json:"timeout"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Timeout != "" {
+		timeout, err := time.ParseDuration(aux.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout for hook %s: %w", s.Name, err)
+		}
+		s.Timeout = timeout
+	}
+	return nil
+}
+
+type HookOptions struct {
+	Executor   deployment.Executor
+	HTTPClient *http.Client
+	Kubernetes kubernetes.Interface
+}
+
+func ParseHookSpecs(data []byte) ([]HookSpec, error) {
+	specs := make([]HookSpec, 0)
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, errors.NewValidationError("invalid hook configuration", err)
+	}
+	return specs, nil
+}
+
+func LoadHookSpecs(path string) ([]HookSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewValidationError("failed to read hook configuration", err)
+	}
+	return ParseHookSpecs(data)
+}
+
+func HookSpecsFromEnv() ([]HookSpec, error) {
+	specs := make([]HookSpec, 0)
+	if path := os.Getenv("ROLLBACK_HOOKS_FILE"); path != "" {
+		fromFile, err := LoadHookSpecs(path)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, fromFile...)
+	}
+	if data := os.Getenv("ROLLBACK_HOOKS"); data != "" {
+		fromEnv, err := ParseHookSpecs([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, fromEnv...)
+	}
+	return specs, nil
+}
+
+var knownPhases = map[HookPhase]bool{
+	HookPreRollback:  true,
+	HookPreDeploy:    true,
+	HookPostDeploy:   true,
+	HookPreVerify:    true,
+	HookPostVerify:   true,
+	HookPostRollback: true,
+	HookOnFailure:    true,
+	HookDownMigrate:  true,
+}
+
+func (s HookSpec) hook(options HookOptions) (Hook, error) {
+	switch s.Type {
+	case HookTypeExec:
+		if s.Command == "" {
+			return nil, fmt.Errorf("exec hook %s has no command", s.Name)
+		}
+		return NewExecHook(ExecHookConfig{
+			Command:      s.Command,
+			Args:         s.Args,
+			Env:          s.Env,
+			Dir:          s.Dir,
+			VetoExitCode: s.VetoExitCode,
+		}, options.Executor), nil
+
+	case HookTypeHTTP:
+		if s.URL == "" {
+			return nil, fmt.Errorf("http hook %s has no url", s.Name)
+		}
+		secret := s.Secret
+		if s.SecretEnv != "" {
+			if secret = os.Getenv(s.SecretEnv); secret == "" {
+				return nil, fmt.Errorf("http hook %s: secret variable %s is not set", s.Name, s.SecretEnv)
+			}
+		}
+		return NewHTTPHook(HTTPHookConfig{
+			URL:     s.URL,
+			Method:  s.Method,
+			Headers: s.Headers,
+			Secret:  secret,
+		}, options.HTTPClient), nil
+
+	case HookTypeKubernetesJob:
+		if s.Image == "" {
+			return nil, fmt.Errorf("kubernetes_job hook %s has no image", s.Name)
+		}
+		if options.Kubernetes == nil {
+			return nil, fmt.Errorf("kubernetes_job hook %s needs a Kubernetes client", s.Name)
+		}
+		config := JobHookConfig{
+			Namespace:          s.Namespace,
+			Name:               s.Name,
+			Image:              s.Image,
+			Args:               s.Args,
+			Env:                s.Env,
+			ServiceAccountName: s.ServiceAccount,
+			TTLAfterFinished:   time.Hour,
+		}
+		if s.Command != "" {
+			if len(s.Args) == 0 {
+				config.Command = []string{"sh", "-c", s.Command}
+			} else {
+				config.Command = []string{s.Command}
+			}
+		}
+		return NewJobHook(options.Kubernetes, config), nil
+
+	default:
+		return nil, fmt.Errorf("hook %s has unknown type %q", s.Name, s.Type)
+	}
+}
+
+func BuildHooks(specs []HookSpec, options HookOptions) ([]HookRegistration, error) {
+	registrations := make([]HookRegistration, 0, len(specs))
+	for _, spec := range specs {
+		if spec.Name == "" {
+			return nil, errors.NewValidationError("hook without a name", nil)
+		}
+		if len(spec.Phases) == 0 {
+			return nil, errors.NewValidationError(fmt.Sprintf("hook %s has no phases", spec.Name), nil)
+		}
+		for _, phase := range spec.Phases {
+			if !knownPhases[phase] {
+				return nil, errors.NewValidationError(fmt.Sprintf("hook %s has unknown phase %q", spec.Name, phase), nil)
+			}
+		}
+
+		hook, err := spec.hook(options)
+		if err != nil {
+			return nil, errors.NewValidationError("invalid hook configuration", err)
+		}
+		policy := HookFailureAbort
+		if spec.IgnoreFailure {
+			policy = HookFailureIgnore
+		}
+		if spec.Timeout <= 0 {
+			spec.Timeout = defaultHookTimeout
+		}
+		registrations = append(registrations, HookRegistration{
+			Name:          spec.Name,
+			Phases:        spec.Phases,
+			Hook:          hook,
+			Timeout:       spec.Timeout,
+			FailurePolicy: policy,
+		})
+	}
+	return registrations, nil
+}
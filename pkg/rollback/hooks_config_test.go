@@ -0,0 +1,310 @@
+package rollback
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
+)
+
+func testHookEvent() RollbackEvent {
+	return RollbackEvent{
+		Phase:       HookPreRollback,
+		RollbackID:  "rb-1",
+		Strategy:    "docker",
+		From:        "v1.1.0",
+		To:          "v1.0.0",
+		Attempt:     1,
+		MaxAttempts: 3,
+		Time:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestExecHook(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		config   ExecHookConfig
+		wantErr  bool
+		wantVeto bool
+	}{
+		{"success", ExecHookConfig{Command: `cat > stdin.json && echo "$ROLLBACK_PHASE $ROLLBACK_FROM_VERSION $ROLLBACK_TO_VERSION $TEAM" > env.txt`, Env: map[string]string{"TEAM": "payments"}, Dir: dir}, false, false},
+		{"failure", ExecHookConfig{Command: "echo migration pending >&2; exit 1"}, true, false},
+		{"veto", ExecHookConfig{Command: "echo change freeze; exit 3", VetoExitCode: 3}, true, true},
+		{"args", ExecHookConfig{Command: "test", Args: []string{"-n", "value"}}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewExecHook(tt.config, nil).Run(context.Background(), testHookEvent())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if IsVeto(err) != tt.wantVeto {
+				t.Errorf("Run() error = %v, want veto %v", err, tt.wantVeto)
+			}
+			if tt.name == "failure" && !strings.Contains(err.Error(), "migration pending") {
+				t.Errorf("Run() error = %v, want command output", err)
+			}
+		})
+	}
+
+	env, _ := os.ReadFile(filepath.Join(dir, "env.txt"))
+	if strings.TrimSpace(string(env)) != "pre_rollback v1.1.0 v1.0.0 payments" {
+		t.Errorf("hook environment = %q", env)
+	}
+	var payload map[string]interface{}
+	data, _ := os.ReadFile(filepath.Join(dir, "stdin.json"))
+	if err := json.Unmarshal(data, &payload); err != nil || payload["rollback_id"] != "rb-1" || payload["strategy"] != "docker" {
+		t.Errorf("hook stdin = %s, %v", data, err)
+	}
+}
+
+func TestExecHookTimeout(t *testing.T) {
+	hooks := newHookRunner([]HookRegistration{{
+		Name:    "sleepy",
+		Phases:  []HookPhase{HookPreRollback},
+		Hook:    NewExecHook(ExecHookConfig{Command: "sleep 5"}, nil),
+		Timeout: 50 * time.Millisecond,
+	}}, logging.NewLogger("error", true), tracing.Tracer(nil))
+
+	start := time.Now()
+	if err := hooks.run(context.Background(), testHookEvent()); err == nil {
+		t.Fatal("run() error = nil, want timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("run() took %v, want the hook to be cut off", elapsed)
+	}
+}
+
+func TestHTTPHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantErr  bool
+		wantVeto bool
+	}{
+		{"accepted", http.StatusNoContent, false, false},
+		{"vetoed", http.StatusConflict, true, true},
+		{"failed", http.StatusInternalServerError, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody []byte
+			var gotHeader http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotBody, _ = io.ReadAll(r.Body)
+				gotHeader = r.Header
+				w.WriteHeader(tt.status)
+				w.Write([]byte("deploy window closed"))
+			}))
+			defer server.Close()
+
+			hook := NewHTTPHook(HTTPHookConfig{URL: server.URL, Secret: "s3cret", Headers: map[string]string{"X-Team": "payments"}}, nil)
+			err := hook.Run(context.Background(), testHookEvent())
+			if (err != nil) != tt.wantErr || IsVeto(err) != tt.wantVeto {
+				t.Fatalf("Run() error = %v, wantErr %v, wantVeto %v", err, tt.wantErr, tt.wantVeto)
+			}
+			if tt.wantVeto && !strings.Contains(err.Error(), "deploy window closed") {
+				t.Errorf("Run() error = %v, want response body as reason", err)
+			}
+
+			timestamp := gotHeader.Get(HookTimestampHeader)
+			if !VerifyHookSignature("s3cret", timestamp, gotHeader.Get(HookSignatureHeader), gotBody, time.Minute) {
+				t.Errorf("signature %q does not verify", gotHeader.Get(HookSignatureHeader))
+			}
+			if VerifyHookSignature("wrong", timestamp, gotHeader.Get(HookSignatureHeader), gotBody, time.Minute) {
+				t.Error("signature verified with the wrong secret")
+			}
+			if gotHeader.Get("X-Team") != "payments" {
+				t.Errorf("X-Team header = %q, want payments", gotHeader.Get("X-Team"))
+			}
+		})
+	}
+}
+
+func TestJobHook(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  batchv1.JobStatus
+		wantErr bool
+	}{
+		{"succeeded", batchv1.JobStatus{Succeeded: 1}, false},
+		{"failed", batchv1.JobStatus{Failed: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			var created *batchv1.Job
+			clientset.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				created = action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+				return false, nil, nil
+			})
+			clientset.PrependReactor("get", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				job := created.DeepCopy()
+				job.Status = tt.status
+				return true, job, nil
+			})
+
+			hook := NewJobHook(clientset, JobHookConfig{
+				Namespace:    "ops",
+				Name:         "Smoke_Test",
+				Image:        "registry.example.com/smoke:1",
+				Command:      []string{"sh", "-c", "./smoke.sh"},
+				PollInterval: time.Millisecond,
+			})
+			err := hook.Run(context.Background(), testHookEvent())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if created == nil || created.Namespace != "ops" || !strings.HasPrefix(created.Name, "smoke-test-pre-rollback-") {
+				t.Fatalf("created job = %+v", created)
+			}
+			container := created.Spec.Template.Spec.Containers[0]
+			env := make(map[string]string)
+			for _, e := range container.Env {
+				env[e.Name] = e.Value
+			}
+			if container.Image != "registry.example.com/smoke:1" || env["ROLLBACK_TO_VERSION"] != "v1.0.0" || !strings.Contains(env["ROLLBACK_EVENT"], `"rollback_id":"rb-1"`) {
+				t.Errorf("job container = %+v", container)
+			}
+		})
+	}
+}
+
+func TestJobHookDeletesJobOnCancel(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	var propagation *metav1.DeletionPropagation
+	deleted := ""
+	clientset.PrependReactor("delete", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleteAction := action.(k8stesting.DeleteAction)
+		deleted = deleteAction.GetName()
+		propagation = deleteAction.GetDeleteOptions().PropagationPolicy
+		return false, nil, nil
+	})
+
+	hook := NewJobHook(clientset, JobHookConfig{Namespace: "ops", Image: "smoke:1", PollInterval: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := hook.Run(ctx, testHookEvent()); err == nil {
+		t.Fatal("Run() error = nil, want context error")
+	}
+
+	if deleted == "" || propagation == nil || *propagation != metav1.DeletePropagationForeground {
+		t.Fatalf("deleted = %q, propagation = %v, want foreground delete", deleted, propagation)
+	}
+	jobs, err := clientset.BatchV1().Jobs("ops").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.Items) != 0 {
+		t.Errorf("jobs left after cancel = %d, want 0", len(jobs.Items))
+	}
+}
+
+func TestVerifyHookSignatureRejectsReplays(t *testing.T) {
+	body := []byte(`{"phase":"pre_rollback"}`)
+	tests := []struct {
+		name string
+		age  time.Duration
+		want bool
+	}{
+		{"fresh", 0, true},
+		{"inside skew", 4 * time.Minute, true},
+		{"replayed", 10 * time.Minute, false},
+		{"from the future", -10 * time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamp := strconv.FormatInt(time.Now().Add(-tt.age).Unix(), 10)
+			signature := SignHookPayload("s3cret", timestamp, body)
+			if got := VerifyHookSignature("s3cret", timestamp, signature, body, 5*time.Minute); got != tt.want {
+				t.Errorf("VerifyHookSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if VerifyHookSignature("s3cret", "yesterday", SignHookPayload("s3cret", "yesterday", body), body, 0) {
+		t.Error("VerifyHookSignature() accepted a malformed timestamp")
+	}
+}
+
+func TestBuildHooks(t *testing.T) {
+	t.Setenv("CAB_SECRET", "s3cret")
+	specs, err := ParseHookSpecs([]byte(`[
+		{"name": "notify-cab", "type": "http", "phases": ["pre_rollback"], "url": "https://cab.example.com/hooks", "secretEnv": "CAB_SECRET", "timeout": "10s"},
+		{"name": "smoke", "type": "exec", "phases": ["post_verify", "post_rollback"], "command": "./smoke.sh", "ignoreFailure": true}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseHookSpecs() error = %v", err)
+	}
+
+	hooks, err := BuildHooks(specs, HookOptions{})
+	if err != nil {
+		t.Fatalf("BuildHooks() error = %v", err)
+	}
+	if len(hooks) != 2 || hooks[0].Timeout != 10*time.Second || hooks[0].FailurePolicy != HookFailureAbort {
+		t.Errorf("hooks[0] = %+v, want 10s aborting http hook", hooks[0])
+	}
+	if hooks[1].Timeout != defaultHookTimeout || hooks[1].FailurePolicy != HookFailureIgnore || len(hooks[1].Phases) != 2 {
+		t.Errorf("hooks[1] = %+v, want ignored exec hook with default timeout", hooks[1])
+	}
+
+	invalid := []struct {
+		name string
+		json string
+	}{
+		{"bad timeout", `[{"name": "x", "type": "exec", "phases": ["pre_rollback"], "command": "true", "timeout": "soon"}]`},
+		{"unknown phase", `[{"name": "x", "type": "exec", "phases": ["whenever"], "command": "true"}]`},
+		{"unknown type", `[{"name": "x", "type": "lambda", "phases": ["pre_rollback"]}]`},
+		{"missing url", `[{"name": "x", "type": "http", "phases": ["pre_rollback"]}]`},
+		{"unset secret variable", `[{"name": "x", "type": "http", "phases": ["pre_rollback"], "url": "https://cab.example.com", "secretEnv": "STABLE_GALAXY_UNSET_SECRET"}]`},
+		{"job without client", `[{"name": "x", "type": "kubernetes_job", "phases": ["pre_rollback"], "image": "busybox"}]`},
+		{"no phases", `[{"name": "x", "type": "exec", "command": "true"}]`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := ParseHookSpecs([]byte(tt.json))
+			if err == nil {
+				_, err = BuildHooks(specs, HookOptions{})
+			}
+			if err == nil {
+				t.Errorf("ParseHookSpecs/BuildHooks(%s) error = nil, want error", tt.json)
+			}
+		})
+	}
+}
+
+func TestHookSpecsFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.json")
+	_ = os.WriteFile(path, []byte(`[{"name": "from-file", "type": "exec", "phases": ["pre_rollback"], "command": "true"}]`), 0o644)
+	t.Setenv("ROLLBACK_HOOKS_FILE", path)
+	t.Setenv("ROLLBACK_HOOKS", `[{"name": "from-env", "type": "exec", "phases": ["on_failure"], "command": "true"}]`)
+
+	specs, err := HookSpecsFromEnv()
+	if err != nil {
+		t.Fatalf("HookSpecsFromEnv() error = %v", err)
+	}
+	if len(specs) != 2 || specs[0].Name != "from-file" || specs[1].Name != "from-env" {
+		t.Errorf("HookSpecsFromEnv() = %+v, want file hooks then env hooks", specs)
+	}
+}
@@ -0,0 +1,140 @@
+package rollback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+)
+
+type hookPayload struct {
+	Phase       HookPhase `json:"phase"`
+	RollbackID  string    `json:"rollback_id,omitempty"`
+	Strategy    string    `json:"strategy"`
+	From        string    `json:"from_version"`
+	To          string    `json:"to_version"`
+	Attempt     int       `json:"attempt"`
+	MaxAttempts int       `json:"max_attempts"`
+	Plan        string    `json:"plan,omitempty"`
+	DryRun      bool      `json:"dry_run"`
+	SchemaFrom  string    `json:"schema_from,omitempty"`
+	SchemaTo    string    `json:"schema_to,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+func (e RollbackEvent) MarshalJSON() ([]byte, error) {
+	payload := hookPayload{
+		Phase:       e.Phase,
+		RollbackID:  e.RollbackID,
+		Strategy:    e.Strategy,
+		From:        e.From,
+		To:          e.To,
+		Attempt:     e.Attempt,
+		MaxAttempts: e.MaxAttempts,
+		Plan:        e.Plan,
+		DryRun:      e.DryRun,
+		SchemaFrom:  e.SchemaFrom,
+		SchemaTo:    e.SchemaTo,
+		Time:        e.Time,
+	}
+	if e.Err != nil {
+		payload.Error = e.Err.Error()
+	}
+	return json.Marshal(payload)
+}
+
+func (e RollbackEvent) Environment() []string {
+	env := []string{
+		"ROLLBACK_PHASE=" + string(e.Phase),
+		"ROLLBACK_ID=" + e.RollbackID,
+		"ROLLBACK_STRATEGY=" + e.Strategy,
+		"ROLLBACK_FROM_VERSION=" + e.From,
+		"ROLLBACK_TO_VERSION=" + e.To,
+		"ROLLBACK_ATTEMPT=" + strconv.Itoa(e.Attempt),
+		"ROLLBACK_MAX_ATTEMPTS=" + strconv.Itoa(e.MaxAttempts),
+		"ROLLBACK_PLAN=" + e.Plan,
+		"ROLLBACK_DRY_RUN=" + strconv.FormatBool(e.DryRun),
+	}
+	if e.SchemaFrom != "" || e.SchemaTo != "" {
+		env = append(env, "ROLLBACK_SCHEMA_FROM="+e.SchemaFrom, "ROLLBACK_SCHEMA_TO="+e.SchemaTo)
+	}
+	if e.Err != nil {
+		env = append(env, "ROLLBACK_ERROR="+e.Err.Error())
+	}
+	return env
+}
+
+type ExecHookConfig struct {
+	Command      string
+	Args         []string
+	Env          map[string]string
+	Dir          string
+	VetoExitCode int
+}
+
+type ExecHook struct {
+	config   ExecHookConfig
+	executor deployment.Executor
+}
+
+func NewExecHook(config ExecHookConfig, executor deployment.Executor) *ExecHook {
+	if executor == nil {
+		executor = deployment.NewExecutor()
+	}
+	return &ExecHook{config: config, executor: executor}
+}
+
+func (h *ExecHook) command(ctx context.Context) *exec.Cmd {
+	name, args := h.config.Command, h.config.Args
+	if len(args) == 0 {
+		name, args = "sh", []string{"-c", h.config.Command}
+	}
+	if ce, ok := h.executor.(deployment.ContextExecutor); ok {
+		return ce.CommandContext(ctx, name, args...)
+	}
+	return h.executor.Command(name, args...)
+}
+
+func (h *ExecHook) Run(ctx context.Context, event RollbackEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cmd := h.command(ctx)
+	cmd.Dir = h.config.Dir
+	cmd.Env = append(os.Environ(), event.Environment()...)
+	for key, value := range h.config.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cmd.Run()
+	if err == nil {
+		return nil
+	}
+	message := strings.TrimSpace(output.String())
+	var exitErr *exec.ExitError
+	if stderrors.As(err, &exitErr) && h.config.VetoExitCode != 0 && exitErr.ExitCode() == h.config.VetoExitCode {
+		if message == "" {
+			message = fmt.Sprintf("command exited with status %d", exitErr.ExitCode())
+		}
+		return Veto(message)
+	}
+	if message != "" {
+		return fmt.Errorf("%w: %s", err, message)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package rollback
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HookSignatureHeader = "X-Stable-Galaxy-Signature"
+	HookTimestampHeader = "X-Stable-Galaxy-Timestamp"
+
+	DefaultHookSignatureSkew = 5 * time.Minute
+)
+
+type HTTPHookConfig struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	Secret  string
+}
+
+type HTTPHook struct {
+	config HTTPHookConfig
+	client *http.Client
+}
+
+func NewHTTPHook(config HTTPHookConfig, client *http.Client) *HTTPHook {
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &HTTPHook{config: config, client: client}
+}
+
+func SignHookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHookSignature checks signature against the body and rejects
+// timestamps further than maxSkew from now, so a captured request cannot be
+// replayed later. A maxSkew of zero uses DefaultHookSignatureSkew.
+func VerifyHookSignature(secret, timestamp, signature string, body []byte, maxSkew time.Duration) bool {
+	if maxSkew <= 0 {
+		maxSkew = DefaultHookSignatureSkew
+	}
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := time.Since(time.Unix(sent, 0)); skew > maxSkew || skew < -maxSkew {
+		return false
+	}
+	return hmac.Equal([]byte(SignHookPayload(secret, timestamp, body)), []byte(signature))
+}
+
+func (h *HTTPHook) Run(ctx context.Context, event RollbackEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, h.config.Method, h.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range h.config.Headers {
+		req.Header.Set(key, value)
+	}
+	if h.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HookTimestampHeader, timestamp)
+		req.Header.Set(HookSignatureHeader, SignHookPayload(h.config.Secret, timestamp, body))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := strings.TrimSpace(string(data))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusConflict:
+		if message == "" {
+			message = "rejected by " + h.config.URL
+		}
+		return Veto(message)
+	default:
+		return fmt.Errorf("hook endpoint returned status %d: %s", resp.StatusCode, message)
+	}
+}
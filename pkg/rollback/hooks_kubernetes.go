@@ -0,0 +1,162 @@
+package rollback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultJobPollInterval = 2 * time.Second
+
+type JobHookConfig struct {
+	Namespace          string
+	Name               string
+	Image              string
+	Command            []string
+	Args               []string
+	Env                map[string]string
+	ServiceAccountName string
+	TTLAfterFinished   time.Duration
+	PollInterval       time.Duration
+}
+
+type JobHook struct {
+	clientset kubernetes.Interface
+	config    JobHookConfig
+}
+
+func NewJobHook(clientset kubernetes.Interface, config JobHookConfig) *JobHook {
+	if config.Namespace == "" {
+		config.Namespace = "default"
+	}
+	if config.Name == "" {
+		config.Name = "rollback-hook"
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultJobPollInterval
+	}
+	return &JobHook{clientset: clientset, config: config}
+}
+
+func (h *JobHook) job(event RollbackEvent) (*batchv1.Job, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	env := []corev1.EnvVar{{Name: "ROLLBACK_EVENT", Value: string(payload)}}
+	for _, pair := range event.Environment() {
+		name, value, _ := strings.Cut(pair, "=")
+		env = append(env, corev1.EnvVar{Name: name, Value: value})
+	}
+	for name, value := range h.config.Env {
+		env = append(env, corev1.EnvVar{Name: name, Value: value})
+	}
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	name := leaseName(h.config.Name + "-" + string(event.Phase))
+	if len(name) > 63-len(suffix)-1 {
+		name = strings.Trim(name[:63-len(suffix)-1], "-.")
+	}
+	backoffLimit := int32(0)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-" + suffix,
+			Namespace: h.config.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "stable-galaxy",
+				"stable-galaxy/hook-phase":     strings.ReplaceAll(string(event.Phase), "_", "-"),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: h.config.ServiceAccountName,
+					Containers: []corev1.Container{{
+						Name:    "hook",
+						Image:   h.config.Image,
+						Command: h.config.Command,
+						Args:    h.config.Args,
+						Env:     env,
+					}},
+				},
+			},
+		},
+	}
+	if h.config.TTLAfterFinished > 0 {
+		ttl := int32(h.config.TTLAfterFinished / time.Second)
+		job.Spec.TTLSecondsAfterFinished = &ttl
+	}
+	return job, nil
+}
+
+func jobFinished(job *batchv1.Job) (bool, error) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return true, fmt.Errorf("job %s failed: %s", job.Name, condition.Message)
+		}
+	}
+	if job.Status.Succeeded > 0 {
+		return true, nil
+	}
+	if job.Status.Failed > 0 {
+		return true, fmt.Errorf("job %s failed", job.Name)
+	}
+	return false, nil
+}
+
+func (h *JobHook) Run(ctx context.Context, event RollbackEvent) error {
+	job, err := h.job(event)
+	if err != nil {
+		return err
+	}
+
+	jobs := h.clientset.BatchV1().Jobs(h.config.Namespace)
+	created, err := jobs.Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create hook job: %w", err)
+	}
+
+	ticker := time.NewTicker(h.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if done, err := jobFinished(created); done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			h.cancel(ctx, created.Name)
+			return fmt.Errorf("waiting for job %s: %w", created.Name, ctx.Err())
+		case <-ticker.C:
+		}
+		created, err = jobs.Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to read hook job %s: %w", job.Name, err)
+		}
+	}
+}
+
+// cancel deletes an abandoned hook Job and its pods. The caller's context is
+// already done, so the delete gets its own bounded one.
+func (h *JobHook) cancel(ctx context.Context, name string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.config.PollInterval+5*time.Second)
+	defer cancel()
+	propagation := metav1.DeletePropagationForeground
+	_ = h.clientset.BatchV1().Jobs(h.config.Namespace).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+}
@@ -0,0 +1,262 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rberrors "github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+type hookLog struct {
+	mu     sync.Mutex
+	events []string
+	seen   []RollbackEvent
+}
+
+func (h *hookLog) hook(name string, err error) HookFunc {
+	return func(_ context.Context, event RollbackEvent) error {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.events = append(h.events, fmt.Sprintf("%s:%s", event.Phase, name))
+		h.seen = append(h.seen, event)
+		return err
+	}
+}
+
+var allPhases = []HookPhase{HookPreRollback, HookPreDeploy, HookPostDeploy, HookPreVerify, HookPostVerify, HookPostRollback, HookOnFailure}
+
+func TestHookOrderAndEvents(t *testing.T) {
+	log := &hookLog{}
+	config := RollbackConfig{
+		MaxAttempts:     2,
+		BackoffDuration: time.Millisecond,
+		VerifyRollback:  func(string) error { return nil },
+		Hooks: []HookRegistration{
+			{Name: "first", Phases: allPhases, Hook: log.hook("first", nil)},
+			{Name: "second", Phases: []HookPhase{HookPreRollback, HookPostRollback}, Hook: log.hook("second", nil)},
+		},
+	}
+	svc := NewService(config, &mockStrategy{}, logging.NewLogger("error", true))
+	svc.RegisterVersion("v0.9.0")
+	svc.RegisterVersion("v1.0.0")
+
+	if err := svc.Rollback("v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	want := []string{
+		"pre_rollback:first", "pre_rollback:second",
+		"pre_deploy:first", "post_deploy:first",
+		"pre_verify:first", "post_verify:first",
+		"post_rollback:first", "post_rollback:second",
+	}
+	if strings.Join(log.events, ",") != strings.Join(want, ",") {
+		t.Errorf("hook calls = %v, want %v", log.events, want)
+	}
+	for _, event := range log.seen {
+		if event.From != "v1.0.0" || event.To != "v0.9.0" || event.Strategy != "mock" || event.RollbackID == "" || event.MaxAttempts != 2 {
+			t.Errorf("%s event = %+v, want v1.0.0 -> v0.9.0 on mock", event.Phase, event)
+		}
+		if event.Phase == HookPreDeploy && event.Attempt != 1 {
+			t.Errorf("pre_deploy attempt = %d, want 1", event.Attempt)
+		}
+	}
+}
+
+func TestHookVeto(t *testing.T) {
+	tests := []struct {
+		name      string
+		phase     HookPhase
+		wantCalls int
+	}{
+		{"pre rollback", HookPreRollback, 0},
+		{"pre deploy", HookPreDeploy, 0},
+		{"pre verify", HookPreVerify, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &hookLog{}
+			config := RollbackConfig{
+				MaxAttempts:     3,
+				BackoffDuration: time.Millisecond,
+				StateStore:      NewMemoryStateStore(),
+				Hooks: []HookRegistration{
+					{Name: "change-freeze", Phases: []HookPhase{tt.phase}, Hook: HookFunc(func(context.Context, RollbackEvent) error {
+						return Veto("change freeze in effect")
+					})},
+					{Name: "failure", Phases: []HookPhase{HookOnFailure}, Hook: log.hook("failure", nil)},
+				},
+			}
+			mock := &mockStrategy{}
+			svc := NewService(config, mock, logging.NewLogger("error", true))
+			svc.RegisterVersion("v0.9.0")
+			svc.RegisterVersion("v1.0.0")
+
+			err := svc.Rollback("v1.0.0")
+			if !IsVeto(err) {
+				t.Fatalf("Rollback() error = %v, want veto", err)
+			}
+			if !strings.Contains(err.Error(), "change-freeze") {
+				t.Errorf("Rollback() error = %v, want it to name the vetoing hook", err)
+			}
+			if len(mock.rollbackCalls) != tt.wantCalls {
+				t.Errorf("strategy calls = %d, want %d", len(mock.rollbackCalls), tt.wantCalls)
+			}
+			if len(log.events) != 0 {
+				t.Errorf("failure hooks = %v, want none for a veto", log.events)
+			}
+			records, _ := config.StateStore.List()
+			if len(records) != 1 || records[0].State != StateFailed {
+				t.Errorf("records = %+v, want one failed record", records)
+			}
+		})
+	}
+}
+
+func TestHookFailurePolicy(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name    string
+		hook    HookRegistration
+		wantErr string
+	}{
+		{
+			name: "ignored failure",
+			hook: HookRegistration{Name: "flaky", Phases: []HookPhase{HookPreRollback}, FailurePolicy: HookFailureIgnore,
+				Hook: HookFunc(func(context.Context, RollbackEvent) error { return boom })},
+		},
+		{
+			name: "aborting failure",
+			hook: HookRegistration{Name: "strict", Phases: []HookPhase{HookPostRollback},
+				Hook: HookFunc(func(context.Context, RollbackEvent) error { return boom })},
+			wantErr: "post_rollback hook strict failed",
+		},
+		{
+			name: "timeout",
+			hook: HookRegistration{Name: "slow", Phases: []HookPhase{HookPreRollback}, Timeout: 10 * time.Millisecond,
+				Hook: HookFunc(func(ctx context.Context, _ RollbackEvent) error {
+					<-ctx.Done()
+					time.Sleep(50 * time.Millisecond)
+					return nil
+				})},
+			wantErr: "timed out",
+		},
+		{
+			name: "panic",
+			hook: HookRegistration{Name: "broken", Phases: []HookPhase{HookPreRollback},
+				Hook: HookFunc(func(context.Context, RollbackEvent) error { panic("nil map") })},
+			wantErr: "panicked",
+		},
+		{
+			name: "veto ignored outside pre phases",
+			hook: HookRegistration{Name: "late", Phases: []HookPhase{HookPostDeploy}, FailurePolicy: HookFailureIgnore,
+				Hook: HookFunc(func(context.Context, RollbackEvent) error { return Veto("too late") })},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := RollbackConfig{MaxAttempts: 1, Hooks: []HookRegistration{tt.hook}}
+			svc := NewService(config, &mockStrategy{}, logging.NewLogger("error", true))
+			svc.RegisterVersion("v0.9.0")
+			svc.RegisterVersion("v1.0.0")
+
+			err := svc.Rollback("v1.0.0")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Rollback() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Rollback() error = %v, want %q", err, tt.wantErr)
+			}
+			var rollbackErr *rberrors.RollbackError
+			if !errors.As(err, &rollbackErr) || rollbackErr.Type != rberrors.ErrorTypeHook {
+				t.Errorf("Rollback() error type = %v, want %s", err, rberrors.ErrorTypeHook)
+			}
+		})
+	}
+}
+
+func TestGroupHooks(t *testing.T) {
+	log := &hookLog{}
+	config := RollbackConfig{
+		MaxAttempts:     3,
+		BackoffDuration: time.Millisecond,
+		Hooks: []HookRegistration{
+			{Name: "audit", Phases: []HookPhase{HookPreRollback, HookPostRollback, HookOnFailure}, Hook: log.hook("audit", nil)},
+			{Name: "guard", Phases: []HookPhase{HookPreDeploy}, Hook: HookFunc(func(_ context.Context, event RollbackEvent) error {
+				if event.Plan == "db" {
+					return Veto("database rollbacks need approval")
+				}
+				return nil
+			})},
+		},
+	}
+	group := NewGroup(config, GroupSequential, logging.NewLogger("error", true))
+	api := &mockStrategy{}
+	db := &mockStrategy{}
+	group.Add(GroupTarget{Name: "api", Strategy: api, From: "v2", To: "v1"})
+	group.Add(GroupTarget{Name: "db", Strategy: db, From: "v2", To: "v1"})
+
+	report, err := group.Rollback()
+	if !IsVeto(err) {
+		t.Fatalf("Rollback() error = %v, want veto", err)
+	}
+	if len(db.rollbackCalls) != 0 || report.Results[1].Attempts != 1 {
+		t.Errorf("db calls = %v attempts = %d, want no calls after one vetoed attempt", db.rollbackCalls, report.Results[1].Attempts)
+	}
+
+	want := []string{"pre_rollback:audit", "post_rollback:audit", "pre_rollback:audit"}
+	if strings.Join(log.events, ",") != strings.Join(want, ",") {
+		t.Errorf("hook calls = %v, want %v", log.events, want)
+	}
+	if log.seen[0].Plan != "api" || log.seen[2].Plan != "db" {
+		t.Errorf("hook plans = %q, %q, want api, db", log.seen[0].Plan, log.seen[2].Plan)
+	}
+}
+
+func TestGroupAndPlanLegacyHooks(t *testing.T) {
+	var mu sync.Mutex
+	calls := make([]string, 0)
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+	config := RollbackConfig{
+		MaxAttempts:      1,
+		PreRollbackHook:  func() error { record("pre"); return nil },
+		PostRollbackHook: func() error { record("post"); return nil },
+		OnFailureHook:    func(error) { record("failure") },
+	}
+	logger := logging.NewLogger("error", true)
+
+	group := NewGroup(config, GroupSequential, logger)
+	group.Add(GroupTarget{Name: "api", Strategy: &mockStrategy{}, From: "v2", To: "v1"})
+	group.Add(GroupTarget{Name: "db", Strategy: &mockStrategy{shouldFail: true}, From: "v2", To: "v1"})
+	if _, err := group.Rollback(); err == nil {
+		t.Fatal("Group.Rollback() error = nil, want error")
+	}
+	if want := "pre,post,pre,failure"; strings.Join(calls, ",") != want {
+		t.Errorf("group hook calls = %v, want %s", calls, want)
+	}
+
+	calls = calls[:0]
+	plan := NewPlan(config, FailureHalt, logger)
+	_ = plan.AddNode(PlanNode{ID: "db", Strategy: &mockStrategy{shouldFail: true}, From: "v2", To: "v1"})
+	if _, err := plan.Execute(); err == nil {
+		t.Fatal("Plan.Execute() error = nil, want error")
+	}
+	if want := "pre,failure"; strings.Join(calls, ",") != want {
+		t.Errorf("plan hook calls = %v, want %s", calls, want)
+	}
+}
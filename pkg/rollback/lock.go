@@ -0,0 +1,224 @@
+package rollback
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+const defaultLockTTL = 30 * time.Second
+
+type Lock interface {
+	Key() string
+	Holder() string
+	Release() error
+	Lost() <-chan struct{}
+}
+
+type Locker interface {
+	Acquire(ctx context.Context, key, holder string, ttl time.Duration) (Lock, error)
+}
+
+type LockHeldError struct {
+	Key       string
+	Holder    string
+	ExpiresAt time.Time
+}
+
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("lock %s is held by %s until %s", e.Key, e.Holder, e.ExpiresAt.Format(time.RFC3339))
+}
+
+// errNotHeld marks a renewal that failed because another holder owns the
+// lease; any other renewal error is retried until the lease expires.
+var errNotHeld = stderrors.New("no longer held")
+
+type leaseBackend interface {
+	tryAcquire(ctx context.Context, key, holder string, ttl time.Duration) error
+	renew(ctx context.Context, key, holder string, ttl time.Duration) error
+	release(ctx context.Context, key, holder string) error
+}
+
+type lease struct {
+	backend leaseBackend
+	key     string
+	holder  string
+	token   string
+	ttl     time.Duration
+	logger  *logging.Logger
+	stop    chan struct{}
+	lost    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+const tokenSeparator = "#"
+
+func lockToken(holder string) string {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Sprintf("%s%s%d", holder, tokenSeparator, time.Now().UnixNano())
+	}
+	return holder + tokenSeparator + hex.EncodeToString(nonce)
+}
+
+func tokenHolder(token string) string {
+	if i := strings.LastIndex(token, tokenSeparator); i >= 0 {
+		return token[:i]
+	}
+	return token
+}
+
+func acquireLease(ctx context.Context, backend leaseBackend, key, holder string, ttl time.Duration, logger *logging.Logger) (Lock, error) {
+	if ttl <= 0 {
+		ttl = defaultLockTTL
+	}
+	token := lockToken(holder)
+	if err := backend.tryAcquire(ctx, key, token, ttl); err != nil {
+		return nil, err
+	}
+
+	l := &lease{
+		backend: backend,
+		key:     key,
+		holder:  holder,
+		token:   token,
+		ttl:     ttl,
+		logger:  logger,
+		stop:    make(chan struct{}),
+		lost:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.renewLoop()
+	return l, nil
+}
+
+func (l *lease) renewLoop() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	expiresAt := time.Now().Add(l.ttl)
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			renewedAt := time.Now()
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			err := l.backend.renew(ctx, l.key, l.token, l.ttl)
+			cancel()
+			if err == nil {
+				expiresAt = renewedAt.Add(l.ttl)
+				continue
+			}
+			if !stderrors.Is(err, errNotHeld) && time.Now().Before(expiresAt) {
+				if l.logger != nil {
+					l.logger.Warn().Err(err).Str("lock", l.key).Str("holder", l.holder).Time("expires_at", expiresAt).Msg("Failed to renew rollback lock, retrying")
+				}
+				continue
+			}
+			if l.logger != nil {
+				l.logger.Warn().Err(err).Str("lock", l.key).Str("holder", l.holder).Msg("Lost rollback lock")
+			}
+			close(l.lost)
+			return
+		}
+	}
+}
+
+func (l *lease) Key() string {
+	return l.key
+}
+
+func (l *lease) Holder() string {
+	return l.holder
+}
+
+func (l *lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *lease) Release() error {
+	var err error
+	l.once.Do(func() {
+		close(l.stop)
+		<-l.done
+		ctx, cancel := context.WithTimeout(context.Background(), l.ttl)
+		defer cancel()
+		err = l.backend.release(ctx, l.key, l.token)
+	})
+	return err
+}
+
+func DefaultLockHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+type memoryLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+type MemoryLocker struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+	logger *logging.Logger
+}
+
+func NewMemoryLocker(logger *logging.Logger) *MemoryLocker {
+	return &MemoryLocker{
+		leases: make(map[string]memoryLease),
+		logger: logger,
+	}
+}
+
+func (m *MemoryLocker) Acquire(ctx context.Context, key, holder string, ttl time.Duration) (Lock, error) {
+	return acquireLease(ctx, m, key, holder, ttl, m.logger)
+}
+
+func (m *MemoryLocker) tryAcquire(_ context.Context, key, holder string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if current, ok := m.leases[key]; ok && now.Before(current.expiresAt) {
+		return &LockHeldError{Key: key, Holder: tokenHolder(current.holder), ExpiresAt: current.expiresAt}
+	}
+	m.leases[key] = memoryLease{holder: holder, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *MemoryLocker) renew(_ context.Context, key, holder string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.leases[key]
+	if !ok || current.holder != holder {
+		return fmt.Errorf("lock %s is %w by %s", key, errNotHeld, holder)
+	}
+	m.leases[key] = memoryLease{holder: holder, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *MemoryLocker) release(_ context.Context, key, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.leases[key]; ok && current.holder == holder {
+		delete(m.leases, key)
+	}
+	return nil
+}
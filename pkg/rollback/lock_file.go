@@ -0,0 +1,117 @@
+package rollback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+type fileLease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type FileLocker struct {
+	dir    string
+	logger *logging.Logger
+}
+
+func NewFileLocker(dir string, logger *logging.Logger) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileLocker{dir: dir, logger: logger}, nil
+}
+
+func (f *FileLocker) Acquire(ctx context.Context, key, holder string, ttl time.Duration) (Lock, error) {
+	return acquireLease(ctx, f, key, holder, ttl, f.logger)
+}
+
+func (f *FileLocker) path(key string) string {
+	return filepath.Join(f.dir, strings.ReplaceAll(key, string(filepath.Separator), "_")+".lock")
+}
+
+func (f *FileLocker) withGuard(key string, fn func(path string) error) error {
+	path := f.path(key)
+	guard, err := os.OpenFile(path+".guard", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer guard.Close()
+
+	if err := lockFile(guard); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", guard.Name(), err)
+	}
+	defer unlockFile(guard)
+
+	return fn(path)
+}
+
+func readFileLease(path string) (*fileLease, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var current fileLease
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, fmt.Errorf("corrupt lock file %s: %w", path, err)
+	}
+	return &current, nil
+}
+
+func writeFileLease(path, holder string, ttl time.Duration) error {
+	data, err := json.Marshal(fileLease{Holder: holder, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (f *FileLocker) tryAcquire(_ context.Context, key, holder string, ttl time.Duration) error {
+	return f.withGuard(key, func(path string) error {
+		current, err := readFileLease(path)
+		if err != nil {
+			return err
+		}
+		if current != nil && time.Now().Before(current.ExpiresAt) {
+			return &LockHeldError{Key: key, Holder: tokenHolder(current.Holder), ExpiresAt: current.ExpiresAt}
+		}
+		return writeFileLease(path, holder, ttl)
+	})
+}
+
+func (f *FileLocker) renew(_ context.Context, key, holder string, ttl time.Duration) error {
+	return f.withGuard(key, func(path string) error {
+		current, err := readFileLease(path)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Holder != holder {
+			return fmt.Errorf("lock %s is %w by %s", key, errNotHeld, holder)
+		}
+		return writeFileLease(path, holder, ttl)
+	})
+}
+
+func (f *FileLocker) release(_ context.Context, key, holder string) error {
+	return f.withGuard(key, func(path string) error {
+		current, err := readFileLease(path)
+		if err != nil || current == nil || current.Holder != holder {
+			return err
+		}
+		return os.Remove(path)
+	})
+}
@@ -0,0 +1,156 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+var invalidLeaseChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+type LeaseLocker struct {
+	clientset kubernetes.Interface
+	namespace string
+	logger    *logging.Logger
+}
+
+func NewLeaseLocker(clientset kubernetes.Interface, namespace string, logger *logging.Logger) *LeaseLocker {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &LeaseLocker{
+		clientset: clientset,
+		namespace: namespace,
+		logger:    logger,
+	}
+}
+
+func (l *LeaseLocker) Acquire(ctx context.Context, key, holder string, ttl time.Duration) (Lock, error) {
+	return acquireLease(ctx, l, key, holder, ttl, l.logger)
+}
+
+func leaseName(key string) string {
+	name := strings.Trim(invalidLeaseChars.ReplaceAllString(strings.ToLower(key), "-"), "-.")
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-.")
+	}
+	return name
+}
+
+func leaseExpiry(lease *coordinationv1.Lease) time.Time {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return time.Time{}
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+}
+
+func leaseSeconds(ttl time.Duration) *int32 {
+	seconds := int32(ttl.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &seconds
+}
+
+func (l *LeaseLocker) tryAcquire(ctx context.Context, key, holder string, ttl time.Duration) error {
+	leases := l.clientset.CoordinationV1().Leases(l.namespace)
+	now := metav1.NewMicroTime(time.Now())
+	name := leaseName(key)
+
+	current, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: l.namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: leaseSeconds(ttl),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return l.heldError(ctx, key)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	currentHolder := ""
+	if current.Spec.HolderIdentity != nil {
+		currentHolder = *current.Spec.HolderIdentity
+	}
+	if currentHolder != "" && time.Now().Before(leaseExpiry(current)) {
+		return &LockHeldError{Key: key, Holder: tokenHolder(currentHolder), ExpiresAt: leaseExpiry(current)}
+	}
+
+	current.Spec.HolderIdentity = &holder
+	current.Spec.LeaseDurationSeconds = leaseSeconds(ttl)
+	current.Spec.AcquireTime = &now
+	current.Spec.RenewTime = &now
+	if _, err := leases.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsConflict(err) {
+			return l.heldError(ctx, key)
+		}
+		return err
+	}
+	return nil
+}
+
+func (l *LeaseLocker) heldError(ctx context.Context, key string) error {
+	current, err := l.clientset.CoordinationV1().Leases(l.namespace).Get(ctx, leaseName(key), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	holder := ""
+	if current.Spec.HolderIdentity != nil {
+		holder = *current.Spec.HolderIdentity
+	}
+	return &LockHeldError{Key: key, Holder: tokenHolder(holder), ExpiresAt: leaseExpiry(current)}
+}
+
+func (l *LeaseLocker) renew(ctx context.Context, key, holder string, ttl time.Duration) error {
+	leases := l.clientset.CoordinationV1().Leases(l.namespace)
+	current, err := leases.Get(ctx, leaseName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("lease %s is %w by %s", leaseName(key), errNotHeld, holder)
+	}
+	if err != nil {
+		return err
+	}
+	if current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != holder {
+		return fmt.Errorf("lease %s is %w by %s", leaseName(key), errNotHeld, holder)
+	}
+	now := metav1.NewMicroTime(time.Now())
+	current.Spec.RenewTime = &now
+	current.Spec.LeaseDurationSeconds = leaseSeconds(ttl)
+	_, err = leases.Update(ctx, current, metav1.UpdateOptions{})
+	return err
+}
+
+func (l *LeaseLocker) release(ctx context.Context, key, holder string) error {
+	leases := l.clientset.CoordinationV1().Leases(l.namespace)
+	current, err := leases.Get(ctx, leaseName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != holder {
+		return nil
+	}
+	return leases.Delete(ctx, current.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &current.ResourceVersion},
+	})
+}
@@ -0,0 +1,305 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	rberrors "github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+func testLockers(t *testing.T) map[string]Locker {
+	logger := logging.NewLogger("error", true)
+	fileLocker, err := NewFileLocker(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("NewFileLocker() error = %v", err)
+	}
+	return map[string]Locker{
+		"memory": NewMemoryLocker(logger),
+		"file":   fileLocker,
+		"lease":  NewLeaseLocker(fake.NewSimpleClientset(), "rollouts", logger),
+	}
+}
+
+func TestLockerContention(t *testing.T) {
+	for name, locker := range testLockers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			lock, err := locker.Acquire(ctx, "payments-api", "ci-job-1", time.Minute)
+			if err != nil {
+				t.Fatalf("Acquire() error = %v", err)
+			}
+
+			_, err = locker.Acquire(ctx, "payments-api", "ci-job-2", time.Minute)
+			var held *LockHeldError
+			if !errors.As(err, &held) {
+				t.Fatalf("Acquire() error = %v, want *LockHeldError", err)
+			}
+			if held.Holder != "ci-job-1" {
+				t.Errorf("LockHeldError.Holder = %s, want ci-job-1", held.Holder)
+			}
+
+			_, err = locker.Acquire(ctx, "payments-api", "ci-job-1", time.Minute)
+			if !errors.As(err, &held) {
+				t.Fatalf("Acquire() by the same holder error = %v, want *LockHeldError", err)
+			}
+
+			if _, err := locker.Acquire(ctx, "orders-api", "ci-job-2", time.Minute); err != nil {
+				t.Errorf("Acquire() on another key error = %v", err)
+			}
+
+			if err := lock.Release(); err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+			second, err := locker.Acquire(ctx, "payments-api", "ci-job-2", time.Minute)
+			if err != nil {
+				t.Fatalf("Acquire() after release error = %v", err)
+			}
+			_ = second.Release()
+		})
+	}
+}
+
+func TestLockerExpiredLeaseIsTakenOver(t *testing.T) {
+	for name, locker := range testLockers(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := locker.(leaseBackend)
+			ctx := context.Background()
+
+			if err := backend.tryAcquire(ctx, "payments-api", "crashed-job", time.Second); err != nil {
+				t.Fatalf("tryAcquire() error = %v", err)
+			}
+			if err := backend.tryAcquire(ctx, "payments-api", "ci-job-2", time.Second); err == nil {
+				t.Fatal("tryAcquire() expected contention before expiry")
+			}
+
+			time.Sleep(1100 * time.Millisecond)
+			if err := backend.tryAcquire(ctx, "payments-api", "ci-job-2", time.Second); err != nil {
+				t.Errorf("tryAcquire() after expiry error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLockRenewal(t *testing.T) {
+	locker := NewMemoryLocker(logging.NewLogger("error", true))
+	lock, err := locker.Acquire(context.Background(), "payments-api", "ci-job-1", 60*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer lock.Release()
+
+	time.Sleep(150 * time.Millisecond)
+	if _, err := locker.Acquire(context.Background(), "payments-api", "ci-job-2", time.Minute); err == nil {
+		t.Error("lock expired despite renewal")
+	}
+	select {
+	case <-lock.Lost():
+		t.Error("lock reported as lost")
+	default:
+	}
+}
+
+type flakyLeaseBackend struct {
+	*MemoryLocker
+	mu       sync.Mutex
+	failures int
+	err      error
+}
+
+func (f *flakyLeaseBackend) renew(ctx context.Context, key, holder string, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures != 0 {
+		f.failures--
+		return f.err
+	}
+	return f.MemoryLocker.renew(ctx, key, holder, ttl)
+}
+
+func TestLockRenewalFailures(t *testing.T) {
+	const ttl = 300 * time.Millisecond
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		wantLost bool
+		minAge   time.Duration
+		maxAge   time.Duration
+	}{
+		{name: "transient failure", failures: 1, err: errors.New("connection refused")},
+		{name: "backend down", failures: -1, err: errors.New("connection refused"), wantLost: true, minAge: ttl, maxAge: 2 * ttl},
+		{name: "taken over", failures: -1, err: errNotHeld, wantLost: true, maxAge: ttl},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logging.NewLogger("error", true)
+			backend := &flakyLeaseBackend{MemoryLocker: NewMemoryLocker(logger), failures: tt.failures, err: tt.err}
+			start := time.Now()
+			lock, err := acquireLease(context.Background(), backend, "payments-api", "ci-job-1", ttl, logger)
+			if err != nil {
+				t.Fatalf("acquireLease() error = %v", err)
+			}
+			defer lock.Release()
+
+			select {
+			case <-lock.Lost():
+				age := time.Since(start)
+				if !tt.wantLost {
+					t.Fatalf("lock lost after %v, want it kept", age)
+				}
+				if age < tt.minAge || age > tt.maxAge {
+					t.Errorf("lock lost after %v, want between %v and %v", age, tt.minAge, tt.maxAge)
+				}
+			case <-time.After(3 * ttl):
+				if tt.wantLost {
+					t.Fatal("lock not reported as lost")
+				}
+			}
+		})
+	}
+}
+
+func TestServiceRollbackLockContention(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	locker := NewMemoryLocker(logger)
+	held, err := locker.Acquire(context.Background(), "stable-galaxy-mock", "auto-rollback", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer held.Release()
+
+	config := RollbackConfig{MaxAttempts: 1}
+	config.Lock.Locker = locker
+	config.Lock.Holder = "ci-job"
+	mock := &mockStrategy{}
+	svc := NewService(config, mock, logger)
+	svc.RegisterVersion("v0.9.0")
+	svc.RegisterVersion("v1.0.0")
+
+	err = svc.Rollback("v1.0.0")
+	var heldErr *LockHeldError
+	if !errors.As(err, &heldErr) || heldErr.Holder != "auto-rollback" {
+		t.Fatalf("Rollback() error = %v, want lock held by auto-rollback", err)
+	}
+	if len(mock.rollbackCalls) != 0 {
+		t.Errorf("rollback executed while lock was held: %v", mock.rollbackCalls)
+	}
+
+	_ = held.Release()
+	if err := svc.Rollback("v1.0.0"); err != nil {
+		t.Errorf("Rollback() after release error = %v", err)
+	}
+}
+
+type blockingStrategy struct {
+	mockStrategy
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingStrategy) Rollback(from, to string) error {
+	b.started <- struct{}{}
+	<-b.release
+	return b.mockStrategy.Rollback(from, to)
+}
+
+func TestServiceConcurrentRollbacks(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	config := RollbackConfig{MaxAttempts: 1}
+	config.Lock.Locker = NewMemoryLocker(logger)
+	config.Lock.Holder = "ci-job"
+	strategy := &blockingStrategy{started: make(chan struct{}, 2), release: make(chan struct{})}
+	svc := NewService(config, strategy, logger)
+	svc.RegisterVersion("v0.9.0")
+	svc.RegisterVersion("v1.0.0")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs[0] = svc.Rollback("v1.0.0")
+	}()
+	<-strategy.started
+
+	errs[1] = svc.Rollback("v1.0.0")
+	close(strategy.release)
+	wg.Wait()
+
+	if errs[0] != nil {
+		t.Errorf("first Rollback() error = %v", errs[0])
+	}
+	var held *LockHeldError
+	if !errors.As(errs[1], &held) || held.Holder != "ci-job" {
+		t.Errorf("second Rollback() error = %v, want lock held by ci-job", errs[1])
+	}
+	if len(strategy.rollbackCalls) != 1 {
+		t.Errorf("strategy calls = %v, want one", strategy.rollbackCalls)
+	}
+}
+
+type lostLock struct {
+	lost chan struct{}
+}
+
+func (l *lostLock) Key() string           { return "stable-galaxy-mock" }
+func (l *lostLock) Holder() string        { return "ci-job" }
+func (l *lostLock) Lost() <-chan struct{} { return l.lost }
+func (l *lostLock) Release() error        { return nil }
+
+type lostLocker struct {
+	lock *lostLock
+}
+
+func (l *lostLocker) Acquire(context.Context, string, string, time.Duration) (Lock, error) {
+	return l.lock, nil
+}
+
+type losingStrategy struct {
+	mockStrategy
+	lock *lostLock
+}
+
+func (s *losingStrategy) RollbackContext(ctx context.Context, from, to string) error {
+	close(s.lock.lost)
+	<-ctx.Done()
+	return s.mockStrategy.Rollback(from, to)
+}
+
+func (s *losingStrategy) DeployContext(_ context.Context, version string) error {
+	return s.mockStrategy.Deploy(version)
+}
+
+func (s *losingStrategy) GetCurrentVersionContext(context.Context) (string, error) {
+	return s.mockStrategy.GetCurrentVersion()
+}
+
+func TestServiceStopsWhenLockLost(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	lock := &lostLock{lost: make(chan struct{})}
+	config := RollbackConfig{MaxAttempts: 1}
+	config.Lock.Locker = &lostLocker{lock: lock}
+	store := NewMemoryStateStore()
+	config.StateStore = store
+	svc := NewService(config, &losingStrategy{lock: lock}, logger)
+	svc.RegisterVersion("v0.9.0")
+	svc.RegisterVersion("v1.0.0")
+
+	err := svc.Rollback("v1.0.0")
+	var rbErr *rberrors.RollbackError
+	if !errors.As(err, &rbErr) || rbErr.Type != rberrors.ErrorTypeLock {
+		t.Fatalf("Rollback() error = %v, want a lock error", err)
+	}
+	records, _ := store.List()
+	if len(records) != 1 || records[0].State != StateFailed {
+		t.Errorf("records = %+v, want one failed rollback", records)
+	}
+}
@@ -0,0 +1,102 @@
+package rollback
+
+import (
+	stderrors "errors"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/metrics"
+)
+
+var attemptBuckets = []float64{1, 2, 3, 4, 5, 7, 10}
+
+type serviceMetrics struct {
+	started   *metrics.Counter
+	succeeded *metrics.Counter
+	failed    *metrics.Counter
+	attempts  *metrics.Histogram
+	phases    *metrics.Histogram
+	duration  *metrics.Histogram
+}
+
+func newServiceMetrics(registry *metrics.Registry) *serviceMetrics {
+	if registry == nil {
+		registry = metrics.DefaultRegistry
+	}
+	return &serviceMetrics{
+		started: registry.NewCounter("stable_galaxy_rollbacks_started",
+			"Rollbacks started.", "strategy"),
+		succeeded: registry.NewCounter("stable_galaxy_rollbacks_succeeded",
+			"Rollbacks that completed successfully.", "strategy"),
+		failed: registry.NewCounter("stable_galaxy_rollbacks_failed",
+			"Rollbacks that failed, by error type.", "strategy", "error_type"),
+		attempts: registry.NewHistogram("stable_galaxy_rollback_attempts",
+			"Attempts needed to execute a rollback.", attemptBuckets, "strategy"),
+		phases: registry.NewHistogram("stable_galaxy_rollback_phase_duration_seconds",
+			"Duration of each rollback phase.", nil, "strategy", "phase"),
+		duration: registry.NewHistogram("stable_galaxy_rollback_duration_seconds",
+			"End-to-end rollback duration.", nil, "strategy"),
+	}
+}
+
+func configMetrics(config RollbackConfig) *serviceMetrics {
+	if !config.MetricsEnabled {
+		return nil
+	}
+	return newServiceMetrics(config.MetricsRegistry)
+}
+
+func wrapStrategy(config RollbackConfig, target string, strategy deployment.Strategy) deployment.Strategy {
+	if strategy == nil {
+		return nil
+	}
+	if config.MetricsEnabled && !deployment.IsInstrumented(strategy) {
+		strategy = deployment.InstrumentWithTarget(strategy, config.MetricsRegistry, target)
+	}
+	if !deployment.IsTraced(strategy) {
+		strategy = deployment.Trace(strategy, config.TracerProvider)
+	}
+	return strategy
+}
+
+func errorType(err error) string {
+	var rollbackErr *errors.RollbackError
+	if stderrors.As(err, &rollbackErr) {
+		return string(rollbackErr.Type)
+	}
+	return "unknown"
+}
+
+func (m *serviceMetrics) start(strategy string) {
+	if m == nil {
+		return
+	}
+	m.started.Inc(strategy)
+}
+
+func (m *serviceMetrics) finish(strategy string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.duration.Observe(time.Since(start).Seconds(), strategy)
+	if err != nil {
+		m.failed.Inc(strategy, errorType(err))
+		return
+	}
+	m.succeeded.Inc(strategy)
+}
+
+func (m *serviceMetrics) phase(strategy, phase string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.phases.Observe(time.Since(start).Seconds(), strategy, phase)
+}
+
+func (m *serviceMetrics) attempt(strategy string, attempts int) {
+	if m == nil {
+		return
+	}
+	m.attempts.Observe(float64(attempts), strategy)
+}
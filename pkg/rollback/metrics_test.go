@@ -0,0 +1,133 @@
+package rollback
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/metrics"
+)
+
+func TestServiceMetrics(t *testing.T) {
+	tests := []struct {
+		name          string
+		fail          bool
+		wantSucceeded float64
+		wantFailed    float64
+		wantAttempts  uint64
+	}{
+		{"successful rollback", false, 1, 0, 1},
+		{"failed rollback", true, 0, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := metrics.NewRegistry()
+			svc := NewService(RollbackConfig{
+				MaxAttempts:     2,
+				BackoffDuration: time.Millisecond,
+				MetricsEnabled:  true,
+				MetricsRegistry: registry,
+			}, &mockStrategy{shouldFail: tt.fail}, logging.NewLogger("error", true))
+			svc.RegisterVersion("v0.9.0")
+			svc.RegisterVersion("v1.0.0")
+
+			_ = svc.Rollback("v1.0.0")
+
+			m := svc.metrics
+			if got := m.started.Value("mock"); got != 1 {
+				t.Errorf("started = %v, want 1", got)
+			}
+			if got := m.succeeded.Value("mock"); got != tt.wantSucceeded {
+				t.Errorf("succeeded = %v, want %v", got, tt.wantSucceeded)
+			}
+			if got := m.failed.Value("mock", "DeploymentError"); got != tt.wantFailed {
+				t.Errorf("failed = %v, want %v", got, tt.wantFailed)
+			}
+			if got := m.attempts.Count("mock"); got != tt.wantAttempts {
+				t.Errorf("attempts observations = %v, want %v", got, tt.wantAttempts)
+			}
+			for _, phase := range []string{"pre_hook", "execute"} {
+				if got := m.phases.Count("mock", phase); got != 1 {
+					t.Errorf("phase %s observations = %v, want 1", phase, got)
+				}
+			}
+
+			var b strings.Builder
+			_ = registry.WriteOpenMetrics(&b)
+			wantVersion := `stable_galaxy_current_version_info{strategy="mock",target="mock",version="v0.9.0"} 1`
+			if strings.Contains(b.String(), wantVersion) == tt.fail {
+				t.Errorf("exposition contains current version = %v, want %v:\n%s", !tt.fail, !tt.fail, b.String())
+			}
+			if !strings.Contains(b.String(), `stable_galaxy_strategy_operations_total{strategy="mock",operation="rollback"`) {
+				t.Errorf("exposition is missing strategy operations:\n%s", b.String())
+			}
+		})
+	}
+}
+
+func TestGroupAndPlanMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	config := RollbackConfig{MaxAttempts: 1, MetricsEnabled: true, MetricsRegistry: registry}
+	logger := logging.NewLogger("error", true)
+
+	group := NewGroup(config, GroupParallel, logger)
+	group.Add(GroupTarget{Strategy: &mockStrategy{}, From: "v2", To: "v1"})
+	_, _ = group.Rollback()
+
+	plan := NewPlan(config, FailureHalt, logger)
+	_ = plan.AddNode(PlanNode{ID: "api", Strategy: &mockStrategy{shouldFail: true}, From: "v2", To: "v1"})
+	_, _ = plan.Execute()
+
+	m := newServiceMetrics(registry)
+	if got := m.started.Value("mock"); got != 2 {
+		t.Errorf("started = %v, want 2", got)
+	}
+	if got := m.succeeded.Value("mock"); got != 1 {
+		t.Errorf("succeeded = %v, want 1", got)
+	}
+	if got := m.failed.Value("mock", "unknown"); got != 1 {
+		t.Errorf("failed = %v, want 1", got)
+	}
+	if got := m.attempts.Count("mock"); got != 2 {
+		t.Errorf("attempts observations = %v, want 2", got)
+	}
+}
+
+func TestServiceDoesNotInstrumentTwice(t *testing.T) {
+	registry := metrics.NewRegistry()
+	config := RollbackConfig{MaxAttempts: 1, MetricsEnabled: true, MetricsRegistry: registry}
+	strategy := deployment.Instrument(&mockStrategy{}, registry)
+	svc := NewService(config, strategy, logging.NewLogger("error", true))
+	svc.RegisterVersion("v0.9.0")
+	svc.RegisterVersion("v1.0.0")
+
+	if err := svc.Rollback("v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	operations := registry.NewCounter("stable_galaxy_strategy_operations", "", "strategy", "operation", "result")
+	if got := operations.Value("mock", "rollback", "success"); got != 1 {
+		t.Errorf("rollback operations = %v, want 1", got)
+	}
+}
+
+func TestGroupTargetsKeepSeparateVersionSeries(t *testing.T) {
+	registry := metrics.NewRegistry()
+	config := RollbackConfig{MaxAttempts: 1, MetricsEnabled: true, MetricsRegistry: registry}
+
+	group := NewGroup(config, GroupSequential, logging.NewLogger("error", true))
+	group.Add(GroupTarget{Name: "checkout", Strategy: &versionedStrategy{name: "docker", current: "v2"}, To: "v1"})
+	group.Add(GroupTarget{Name: "payments", Strategy: &versionedStrategy{name: "docker", current: "v5"}, To: "v4"})
+	if _, err := group.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	current := registry.NewGauge("stable_galaxy_current_version_info", "", "strategy", "target", "version")
+	for _, series := range [][]string{{"docker", "checkout", "v1"}, {"docker", "payments", "v4"}} {
+		if got := current.Value(series...); got != 1 {
+			t.Errorf("current version %v = %v, want 1", series, got)
+		}
+	}
+}
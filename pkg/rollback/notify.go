@@ -0,0 +1,96 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/notify"
+)
+
+func newDispatcher(config RollbackConfig, logger *logging.Logger) *notify.Dispatcher {
+	if !config.Notifications.Enabled {
+		return nil
+	}
+	dispatcher, err := notify.NewDispatcherFromConfig(config.Notifications, logger)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to set up notifications")
+		return nil
+	}
+	return dispatcher
+}
+
+func sendNotification(dispatcher *notify.Dispatcher, logger *logging.Logger, event notify.Event) {
+	if err := dispatcher.Notify(event); err != nil {
+		logger.Warn().Err(err).Str("event", string(event.Type)).Msg("Failed to queue notification")
+	}
+}
+
+func recordEvent(eventType notify.EventType, record *Record, start time.Time, err error) notify.Event {
+	event := notify.Event{
+		Type:       eventType,
+		RollbackID: record.ID,
+		Strategy:   record.Strategy,
+		From:       record.FromVersion,
+		To:         record.ToVersion,
+		Attempts:   record.Attempt,
+		Duration:   time.Since(start).Round(time.Millisecond),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+func startedEvent(target GroupTarget) notify.Event {
+	return notify.Event{
+		Type:     notify.EventStarted,
+		Strategy: target.Strategy.StrategyName(),
+		From:     target.From,
+		To:       target.To,
+		Labels:   map[string]string{"target": target.Name},
+	}
+}
+
+func resultEvent(result TargetResult) notify.Event {
+	event := notify.Event{
+		Type:     notify.EventSucceeded,
+		Strategy: result.Strategy,
+		From:     result.From,
+		To:       result.To,
+		Attempts: result.Attempts,
+		Duration: result.Duration.Round(time.Millisecond),
+		Labels:   map[string]string{"target": result.Name},
+	}
+	if result.Err != nil {
+		event.Type = notify.EventFailed
+		event.Error = result.Err.Error()
+		event.Labels["compensated"] = fmt.Sprint(result.Compensated)
+	}
+	return event
+}
+
+func notifyResults(dispatcher *notify.Dispatcher, logger *logging.Logger, results []TargetResult) {
+	for _, result := range results {
+		if !result.Skipped {
+			sendNotification(dispatcher, logger, resultEvent(result))
+		}
+	}
+}
+
+func (s *Service) notify(event notify.Event) {
+	sendNotification(s.notifier, s.logger, event)
+}
+
+func (s *Service) Close(ctx context.Context) error {
+	return s.notifier.Close(ctx)
+}
+
+func (g *Group) Close(ctx context.Context) error {
+	return g.notifier.Close(ctx)
+}
+
+func (p *Plan) Close(ctx context.Context) error {
+	return p.notifier.Close(ctx)
+}
@@ -0,0 +1,335 @@
+package rollback
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/notify"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
+)
+
+type FailurePolicy string
+
+const (
+	FailureHalt       FailurePolicy = "halt"
+	FailureContinue   FailurePolicy = "continue"
+	FailureCompensate FailurePolicy = "compensate"
+)
+
+type PlanNode struct {
+	ID        string
+	Strategy  deployment.Strategy
+	From      string
+	To        string
+	DependsOn []string
+}
+
+type PlanReport struct {
+	Policy  FailurePolicy
+	Results []TargetResult
+}
+
+func (r *PlanReport) Succeeded() bool {
+	return allSucceeded(r.Results)
+}
+
+func (r *PlanReport) Failed() []TargetResult {
+	return failedResults(r.Results)
+}
+
+type Plan struct {
+	config   RollbackConfig
+	policy   FailurePolicy
+	nodes    []PlanNode
+	index    map[string]int
+	logger   *logging.Logger
+	metrics  *serviceMetrics
+	notifier *notify.Dispatcher
+}
+
+type planCompletion struct {
+	node   int
+	result TargetResult
+}
+
+func NewPlan(config RollbackConfig, policy FailurePolicy, logger *logging.Logger) *Plan {
+	if logger == nil {
+		logger = logging.NewLogger("info", false)
+	}
+	if policy == "" {
+		policy = FailureHalt
+	}
+	return &Plan{
+		config:   config,
+		policy:   policy,
+		nodes:    make([]PlanNode, 0),
+		index:    make(map[string]int),
+		logger:   logger,
+		metrics:  configMetrics(config),
+		notifier: newDispatcher(config, logger),
+	}
+}
+
+func (p *Plan) AddNode(node PlanNode) error {
+	if node.ID == "" {
+		return errors.NewValidationError("plan node has no ID", nil)
+	}
+	if node.Strategy == nil {
+		return errors.NewValidationError(fmt.Sprintf("plan node %s has no strategy", node.ID), nil)
+	}
+	if _, exists := p.index[node.ID]; exists {
+		return errors.NewValidationError(fmt.Sprintf("plan node %s already exists", node.ID), nil)
+	}
+	node.Strategy = wrapStrategy(p.config, node.ID, node.Strategy)
+	p.index[node.ID] = len(p.nodes)
+	p.nodes = append(p.nodes, node)
+	return nil
+}
+
+func (p *Plan) dependents() [][]int {
+	dependents := make([][]int, len(p.nodes))
+	for i, node := range p.nodes {
+		for _, dep := range node.DependsOn {
+			if j, ok := p.index[dep]; ok {
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+	return dependents
+}
+
+func (p *Plan) Stages() ([][]string, error) {
+	for _, node := range p.nodes {
+		for _, dep := range node.DependsOn {
+			if _, ok := p.index[dep]; !ok {
+				return nil, errors.NewValidationError(fmt.Sprintf("plan node %s depends on unknown node %s", node.ID, dep), nil)
+			}
+		}
+	}
+
+	remaining := make([]int, len(p.nodes))
+	for i, node := range p.nodes {
+		remaining[i] = len(node.DependsOn)
+	}
+	dependents := p.dependents()
+
+	stages := make([][]string, 0)
+	ready := make([]int, 0)
+	for i := range p.nodes {
+		if remaining[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	visited := 0
+	for len(ready) > 0 {
+		stage := make([]string, 0, len(ready))
+		next := make([]int, 0)
+		for _, i := range ready {
+			stage = append(stage, p.nodes[i].ID)
+			visited++
+			for _, d := range dependents[i] {
+				remaining[d]--
+				if remaining[d] == 0 {
+					next = append(next, d)
+				}
+			}
+		}
+		sort.Strings(stage)
+		stages = append(stages, stage)
+		ready = next
+	}
+
+	if visited != len(p.nodes) {
+		cyclic := make([]string, 0)
+		for i, node := range p.nodes {
+			if remaining[i] > 0 {
+				cyclic = append(cyclic, node.ID)
+			}
+		}
+		return nil, errors.NewValidationError(fmt.Sprintf("plan has a dependency cycle involving %s", strings.Join(cyclic, ", ")), nil)
+	}
+	return stages, nil
+}
+
+func (p *Plan) Validate() error {
+	_, err := p.Stages()
+	return err
+}
+
+func planVersion(version string) string {
+	if version == "" {
+		return "current"
+	}
+	return version
+}
+
+func (p *Plan) RenderText() (string, error) {
+	stages, err := p.Stages()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rollback plan (failure policy: %s)\n", p.policy)
+	for i, stage := range stages {
+		fmt.Fprintf(&b, "Stage %d:\n", i+1)
+		for _, id := range stage {
+			node := p.nodes[p.index[id]]
+			fmt.Fprintf(&b, "  - %s [%s] %s -> %s", node.ID, node.Strategy.StrategyName(), planVersion(node.From), node.To)
+			if len(node.DependsOn) > 0 {
+				fmt.Fprintf(&b, " (after %s)", strings.Join(node.DependsOn, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func (p *Plan) RenderDOT() (string, error) {
+	if _, err := p.Stages(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph rollback_plan {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, node := range p.nodes {
+		label := fmt.Sprintf("%s\n%s\n%s -> %s", node.ID, node.Strategy.StrategyName(), planVersion(node.From), node.To)
+		fmt.Fprintf(&b, "  %s [label=%s];\n", strconv.Quote(node.ID), strconv.Quote(label))
+	}
+	for _, node := range p.nodes {
+		for _, dep := range node.DependsOn {
+			fmt.Fprintf(&b, "  %s -> %s;\n", strconv.Quote(dep), strconv.Quote(node.ID))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+func (p *Plan) Execute() (_ *PlanReport, err error) {
+	report := &PlanReport{Policy: p.policy}
+	ctx, span := tracing.Tracer(p.config.TracerProvider).Start(configContext(p.config), "rollback.Plan", trace.WithAttributes(
+		attribute.String("rollback.failure_policy", string(p.policy)),
+		attribute.Int("rollback.targets", len(p.nodes)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	stages, err := p.Stages()
+	if err != nil {
+		return report, err
+	}
+
+	targets := make([]GroupTarget, len(p.nodes))
+	for i, node := range p.nodes {
+		target, err := resolveTarget(ctx, GroupTarget{Name: node.ID, Strategy: node.Strategy, From: node.From, To: node.To})
+		if err != nil {
+			return report, err
+		}
+		targets[i] = target
+	}
+
+	p.logger.Info().Int("nodes", len(p.nodes)).Int("stages", len(stages)).Str("policy", string(p.policy)).Msg("Executing rollback plan")
+
+	results := make([]TargetResult, len(p.nodes))
+	done := make([]bool, len(p.nodes))
+	blocked := make([]bool, len(p.nodes))
+	remaining := make([]int, len(p.nodes))
+	for i, node := range p.nodes {
+		remaining[i] = len(node.DependsOn)
+	}
+	dependents := p.dependents()
+	completions := make(chan planCompletion)
+	running := 0
+	halted := false
+
+	start := func(i int) {
+		running++
+		sendNotification(p.notifier, p.logger, startedEvent(targets[i]))
+		go func() {
+			completions <- planCompletion{node: i, result: rollbackTarget(ctx, p.config, p.logger, p.metrics, targets[i])}
+		}()
+	}
+
+	var finish func(i int, ok bool)
+	finish = func(i int, ok bool) {
+		done[i] = true
+		for _, d := range dependents[i] {
+			remaining[d]--
+			if !ok {
+				blocked[d] = true
+			}
+			if remaining[d] > 0 {
+				continue
+			}
+			if blocked[d] || halted {
+				results[d] = skippedResult(targets[d])
+				finish(d, false)
+				continue
+			}
+			start(d)
+		}
+	}
+
+	for i := range p.nodes {
+		if remaining[i] == 0 {
+			start(i)
+		}
+	}
+
+	for running > 0 {
+		c := <-completions
+		running--
+		results[c.node] = c.result
+		ok := c.result.Err == nil
+		if !ok && p.policy != FailureContinue {
+			halted = true
+		}
+		finish(c.node, ok)
+	}
+
+	for i := range p.nodes {
+		if !done[i] {
+			results[i] = skippedResult(targets[i])
+		}
+	}
+
+	report.Results = results
+	failed := report.Failed()
+	if len(failed) == 0 {
+		p.logger.Info().Int("nodes", len(p.nodes)).Msg("Rollback plan completed successfully")
+		notifyResults(p.notifier, p.logger, results)
+		return report, nil
+	}
+
+	if p.policy == FailureCompensate {
+		for s := len(stages) - 1; s >= 0; s-- {
+			for _, id := range stages[s] {
+				i := p.index[id]
+				if !results[i].Skipped {
+					compensateTarget(ctx, p.config, p.logger, targets[i], &results[i])
+				}
+			}
+		}
+	}
+	notifyResults(p.notifier, p.logger, results)
+
+	names := make([]string, len(failed))
+	for i, result := range failed {
+		names[i] = result.Name
+	}
+	err = errors.NewDeploymentError("rollback plan failed", failed[0].Err, map[string]interface{}{
+		"failed_nodes": names,
+		"policy":       string(p.policy),
+	})
+	p.logger.Error().Err(err).Strs("failed_nodes", names).Msg("Rollback plan failed")
+	return report, err
+}
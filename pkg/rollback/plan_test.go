@@ -0,0 +1,203 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/notify"
+)
+
+type executionLog struct {
+	mu    sync.Mutex
+	order []string
+}
+
+type loggedStrategy struct {
+	*versionedStrategy
+	log *executionLog
+}
+
+func (l *loggedStrategy) Rollback(from, to string) error {
+	l.log.mu.Lock()
+	l.log.order = append(l.log.order, l.name)
+	l.log.mu.Unlock()
+	return l.versionedStrategy.Rollback(from, to)
+}
+
+func TestPlanExecute(t *testing.T) {
+	tests := []struct {
+		policy       FailurePolicy
+		failing      string
+		wantSkipped  []string
+		wantVersions map[string]string
+	}{
+		{
+			policy:       FailureHalt,
+			wantSkipped:  []string{},
+			wantVersions: map[string]string{"worker": "v1", "api": "v1", "schema": "v1", "cron": "v1"},
+		},
+		{
+			policy:       FailureContinue,
+			failing:      "api",
+			wantSkipped:  []string{"schema"},
+			wantVersions: map[string]string{"worker": "v1", "api": "v2", "schema": "v2", "cron": "v1"},
+		},
+		{
+			policy:       FailureCompensate,
+			failing:      "api",
+			wantSkipped:  []string{"schema"},
+			wantVersions: map[string]string{"worker": "v2", "api": "v2", "schema": "v2", "cron": "v2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			log := &executionLog{}
+			strategies := newVersionedStrategies("v2", tt.failing, "worker", "api", "schema", "cron")
+			plan := NewPlan(RollbackConfig{MaxAttempts: 1, BackoffDuration: time.Millisecond}, tt.policy, logging.NewLogger("error", true))
+			for _, node := range []PlanNode{
+				{ID: "worker"},
+				{ID: "api"},
+				{ID: "schema", DependsOn: []string{"api", "worker"}},
+				{ID: "cron"},
+			} {
+				node.Strategy = &loggedStrategy{versionedStrategy: strategies[node.ID], log: log}
+				node.To = "v1"
+				if err := plan.AddNode(node); err != nil {
+					t.Fatalf("AddNode() error = %v", err)
+				}
+			}
+
+			report, err := plan.Execute()
+			if (err != nil) != (tt.failing != "") {
+				t.Fatalf("Execute() error = %v, want failure of %q", err, tt.failing)
+			}
+			if report.Succeeded() != (tt.failing == "") {
+				t.Errorf("report.Succeeded() = %v, want %v", report.Succeeded(), tt.failing == "")
+			}
+			if tt.failing == "" {
+				position := make(map[string]int, len(log.order))
+				for i, name := range log.order {
+					position[name] = i
+				}
+				if position["schema"] < position["api"] || position["schema"] < position["worker"] {
+					t.Errorf("execution order = %v, want schema after api and worker", log.order)
+				}
+			}
+
+			skipped := make([]string, 0)
+			for _, result := range report.Results {
+				if result.Skipped {
+					skipped = append(skipped, result.Name)
+				}
+			}
+			if strings.Join(skipped, ",") != strings.Join(tt.wantSkipped, ",") {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+			for id, want := range tt.wantVersions {
+				if got := strategies[id].current; got != want {
+					t.Errorf("%s version = %s, want %s", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanNotifications(t *testing.T) {
+	notifier := &eventNotifier{}
+	config := RollbackConfig{MaxAttempts: 1, BackoffDuration: time.Millisecond}
+	config.Notifications = notify.Config{Enabled: true, Notifiers: []notify.Notifier{notifier}}
+
+	strategies := newVersionedStrategies("v2", "api", "worker", "api", "schema")
+	plan := NewPlan(config, FailureHalt, logging.NewLogger("error", true))
+	_ = plan.AddNode(PlanNode{ID: "worker", Strategy: strategies["worker"], To: "v1"})
+	_ = plan.AddNode(PlanNode{ID: "api", Strategy: strategies["api"], To: "v1"})
+	_ = plan.AddNode(PlanNode{ID: "schema", Strategy: strategies["schema"], To: "v1", DependsOn: []string{"api"}})
+
+	if _, err := plan.Execute(); err == nil {
+		t.Fatal("Execute() expected failure")
+	}
+	if err := plan.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	got := make(map[string][]notify.EventType)
+	for _, event := range notifier.events {
+		target := event.Labels["target"]
+		got[target] = append(got[target], event.Type)
+	}
+	want := map[string][]notify.EventType{
+		"worker": {notify.EventStarted, notify.EventSucceeded},
+		"api":    {notify.EventStarted, notify.EventFailed},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %+v, want %v", notifier.events, want)
+	}
+	for target, types := range want {
+		if fmt.Sprint(got[target]) != fmt.Sprint(types) {
+			t.Errorf("%s events = %v, want %v", target, got[target], types)
+		}
+	}
+}
+
+func TestPlanValidation(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+
+	plan := NewPlan(RollbackConfig{MaxAttempts: 1}, FailureHalt, logger)
+	_ = plan.AddNode(PlanNode{ID: "a", Strategy: &versionedStrategy{name: "a"}, To: "v1", DependsOn: []string{"b"}})
+	_ = plan.AddNode(PlanNode{ID: "b", Strategy: &versionedStrategy{name: "b"}, To: "v1", DependsOn: []string{"a"}})
+	if err := plan.Validate(); err == nil {
+		t.Error("Validate() expected cycle error")
+	}
+
+	plan = NewPlan(RollbackConfig{MaxAttempts: 1}, FailureHalt, logger)
+	_ = plan.AddNode(PlanNode{ID: "a", Strategy: &versionedStrategy{name: "a"}, To: "v1", DependsOn: []string{"missing"}})
+	if err := plan.Validate(); err == nil {
+		t.Error("Validate() expected unknown dependency error")
+	}
+
+	if err := plan.AddNode(PlanNode{ID: "a", Strategy: &versionedStrategy{name: "a"}, To: "v1"}); err == nil {
+		t.Error("AddNode() expected duplicate node error")
+	}
+}
+
+func TestPlanRender(t *testing.T) {
+	plan := NewPlan(RollbackConfig{MaxAttempts: 1}, FailureHalt, logging.NewLogger("error", true))
+	_ = plan.AddNode(PlanNode{ID: "worker", Strategy: &versionedStrategy{name: "worker"}, To: "v1"})
+	_ = plan.AddNode(PlanNode{ID: "api", Strategy: &versionedStrategy{name: "api"}, To: "v1"})
+	_ = plan.AddNode(PlanNode{ID: "schema", Strategy: &versionedStrategy{name: "schema"}, To: "v1", DependsOn: []string{"api", "worker"}})
+
+	text, err := plan.RenderText()
+	if err != nil {
+		t.Fatalf("RenderText() error = %v", err)
+	}
+	if !strings.Contains(text, "Stage 1:\n  - api [api] current -> v1\n") ||
+		!strings.Contains(text, "Stage 2:\n  - schema [schema] current -> v1 (after api, worker)\n") {
+		t.Errorf("RenderText() = \n%s", text)
+	}
+
+	dot, err := plan.RenderDOT()
+	if err != nil {
+		t.Fatalf("RenderDOT() error = %v", err)
+	}
+	if !strings.HasPrefix(dot, "digraph rollback_plan {") || !strings.Contains(dot, `"api" -> "schema";`) {
+		t.Errorf("RenderDOT() = \n%s", dot)
+	}
+
+	plan = NewPlan(RollbackConfig{MaxAttempts: 1}, FailureHalt, logging.NewLogger("error", true))
+	_ = plan.AddNode(PlanNode{ID: `db "primary"`, Strategy: &versionedStrategy{name: "db-primary"}, To: "v1"})
+	_ = plan.AddNode(PlanNode{ID: "web-api", Strategy: &versionedStrategy{name: "web"}, To: "v1", DependsOn: []string{`db "primary"`}})
+	dot, err = plan.RenderDOT()
+	if err != nil {
+		t.Fatalf("RenderDOT() error = %v", err)
+	}
+	if !strings.Contains(dot, `"db \"primary\"" [label="db \"primary\"\ndb-primary\ncurrent -> v1"];`) ||
+		!strings.Contains(dot, `"db \"primary\"" -> "web-api";`) {
+		t.Errorf("RenderDOT() = \n%s", dot)
+	}
+}
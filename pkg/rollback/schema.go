@@ -0,0 +1,237 @@
+package rollback
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
+)
+
+const DefaultSchemaQuery = "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1"
+
+type SchemaPolicy string
+
+const (
+	SchemaBlock   SchemaPolicy = "block"
+	SchemaMigrate SchemaPolicy = "migrate"
+)
+
+func ParseSchemaPolicy(value string) (SchemaPolicy, error) {
+	switch policy := SchemaPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case SchemaBlock, SchemaMigrate:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown schema policy %q, want %q or %q", value, SchemaBlock, SchemaMigrate)
+	}
+}
+
+type VersionMetadata struct {
+	SchemaVersion    string
+	MaxSchemaVersion string
+}
+
+type SchemaConfig struct {
+	Inspector SchemaInspector
+	Versions  map[string]VersionMetadata
+	Policy    SchemaPolicy
+}
+
+type SchemaInspector interface {
+	SchemaVersion(ctx context.Context) (string, error)
+}
+
+type SQLSchemaInspector struct {
+	db    *sql.DB
+	query string
+}
+
+func NewSQLSchemaInspector(db *sql.DB, query string) *SQLSchemaInspector {
+	if query == "" {
+		query = DefaultSchemaQuery
+	}
+	return &SQLSchemaInspector{db: db, query: query}
+}
+
+func (i *SQLSchemaInspector) SchemaVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := i.db.QueryRowContext(ctx, i.query).Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to query schema version: %w", err)
+	}
+	return strings.TrimSpace(version), nil
+}
+
+type FileSchemaInspector struct {
+	path string
+}
+
+func NewFileSchemaInspector(path string) *FileSchemaInspector {
+	return &FileSchemaInspector{path: path}
+}
+
+func (i *FileSchemaInspector) SchemaVersion(context.Context) (string, error) {
+	data, err := os.ReadFile(i.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func CompareSchemaVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if c := compareSchemaPart(schemaPart(as, i), schemaPart(bs, i)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func schemaPart(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return "0"
+}
+
+func compareSchemaPart(a, b string) int {
+	if numeric(a) && numeric(b) {
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func numeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type SchemaCheck struct {
+	Current       string
+	Required      string
+	Compatible    bool
+	NeedsDown     bool
+	DownMigrateTo string
+}
+
+func (m VersionMetadata) Check(current string) SchemaCheck {
+	check := SchemaCheck{Current: current, Required: m.SchemaVersion, Compatible: true}
+	if m.SchemaVersion == "" {
+		return check
+	}
+	if CompareSchemaVersions(current, m.SchemaVersion) < 0 {
+		check.Compatible = false
+		return check
+	}
+	max := m.MaxSchemaVersion
+	if max == "" {
+		max = m.SchemaVersion
+	}
+	if CompareSchemaVersions(current, max) > 0 {
+		check.Compatible = false
+		check.NeedsDown = true
+		check.DownMigrateTo = max
+	}
+	return check
+}
+
+type schemaGuard struct {
+	config SchemaConfig
+	hooks  *hookRunner
+	logger *logging.Logger
+	tracer trace.Tracer
+}
+
+func newSchemaGuard(config SchemaConfig, hooks *hookRunner, logger *logging.Logger, tracer trace.Tracer) *schemaGuard {
+	return &schemaGuard{config: config, hooks: hooks, logger: logger, tracer: tracer}
+}
+
+func (g *schemaGuard) inspect(ctx context.Context) (string, error) {
+	current, err := g.config.Inspector.SchemaVersion(ctx)
+	if err != nil {
+		return "", errors.NewSchemaError("failed to inspect schema version", err, nil)
+	}
+	return current, nil
+}
+
+func (g *schemaGuard) ensure(ctx context.Context, event RollbackEvent) (err error) {
+	if g.config.Inspector == nil {
+		return nil
+	}
+	metadata, ok := g.config.Versions[event.To]
+	if !ok || metadata.SchemaVersion == "" {
+		g.logger.Debug().Str("version", event.To).Msg("No schema metadata for version, skipping schema check")
+		return nil
+	}
+
+	ctx, span := g.tracer.Start(ctx, "rollback.schema", trace.WithAttributes(
+		attribute.String("rollback.to_version", event.To),
+		attribute.String("rollback.schema_required", metadata.SchemaVersion),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	current, err := g.inspect(ctx)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("rollback.schema_current", current))
+
+	check := metadata.Check(current)
+	if check.Compatible {
+		g.logger.Debug().Str("version", event.To).Str("schema", current).Msg("Schema is compatible with rollback target")
+		return nil
+	}
+
+	meta := map[string]interface{}{
+		"to_version":      event.To,
+		"schema_current":  current,
+		"schema_required": metadata.SchemaVersion,
+	}
+	if !check.NeedsDown {
+		return errors.NewSchemaError(fmt.Sprintf("version %s requires schema %s, database is at %s", event.To, metadata.SchemaVersion, current), nil, meta)
+	}
+	if g.config.Policy != SchemaMigrate || !g.hooks.has(HookDownMigrate) {
+		g.logger.Error().Str("version", event.To).Str("schema", current).Str("required", check.DownMigrateTo).Msg("Rollback requires a down-migration")
+		return errors.NewSchemaError(fmt.Sprintf("rolling back to %s requires migrating schema %s down to %s", event.To, current, check.DownMigrateTo), nil, meta)
+	}
+
+	g.logger.Warn().Str("from", current).Str("to", check.DownMigrateTo).Msg("Running down-migration before rollback")
+	event.Phase = HookDownMigrate
+	event.SchemaFrom = current
+	event.SchemaTo = check.DownMigrateTo
+	if err := g.hooks.run(ctx, event); err != nil {
+		return errors.NewSchemaError("down-migration failed", err, meta)
+	}
+
+	current, err = g.inspect(ctx)
+	if err != nil {
+		return err
+	}
+	if !metadata.Check(current).Compatible {
+		meta["schema_current"] = current
+		return errors.NewSchemaError(fmt.Sprintf("schema is at %s after down-migration, version %s needs %s", current, event.To, check.DownMigrateTo), nil, meta)
+	}
+	g.logger.Info().Str("schema", current).Str("version", event.To).Msg("Down-migration completed")
+	return nil
+}
@@ -0,0 +1,303 @@
+package rollback
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rberrors "github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+func TestCompareSchemaVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"42", "42", 0},
+		{"9", "10", -1},
+		{"0010", "9", 1},
+		{"20240501120000", "20231231235959", 1},
+		{"v3", "v12", -1},
+		{"2024_05_add_users", "2024_06_drop_email", -1},
+		{"1.10", "1.9", 1},
+		{"v2.0.1", "v2.0.10", -1},
+		{"1.2", "1.2.0", 0},
+	}
+
+	for _, tt := range tests {
+		if got := CompareSchemaVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareSchemaVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseSchemaPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    SchemaPolicy
+		wantErr bool
+	}{
+		{"block", SchemaBlock, false},
+		{" Migrate ", SchemaMigrate, false},
+		{"migrat", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSchemaPolicy(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseSchemaPolicy(%q) = %q, %v, want %q, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestVersionMetadataCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		metadata       VersionMetadata
+		current        string
+		wantCompatible bool
+		wantDownTo     string
+	}{
+		{"exact", VersionMetadata{SchemaVersion: "42"}, "42", true, ""},
+		{"no metadata", VersionMetadata{}, "42", true, ""},
+		{"newer schema", VersionMetadata{SchemaVersion: "40"}, "42", false, "40"},
+		{"within range", VersionMetadata{SchemaVersion: "40", MaxSchemaVersion: "43"}, "42", true, ""},
+		{"beyond range", VersionMetadata{SchemaVersion: "40", MaxSchemaVersion: "41"}, "42", false, "41"},
+		{"older schema", VersionMetadata{SchemaVersion: "44"}, "42", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := tt.metadata.Check(tt.current)
+			if check.Compatible != tt.wantCompatible || check.DownMigrateTo != tt.wantDownTo {
+				t.Errorf("Check(%q) = %+v, want compatible %v, down to %q", tt.current, check, tt.wantCompatible, tt.wantDownTo)
+			}
+		})
+	}
+}
+
+type fakeSchemaDriver struct{ version string }
+
+func (d fakeSchemaDriver) Open(string) (driver.Conn, error) { return fakeSchemaConn(d), nil }
+
+type fakeSchemaConn fakeSchemaDriver
+
+func (c fakeSchemaConn) Prepare(query string) (driver.Stmt, error) { return fakeSchemaStmt(c), nil }
+func (c fakeSchemaConn) Close() error                              { return nil }
+func (c fakeSchemaConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeSchemaStmt fakeSchemaConn
+
+func (s fakeSchemaStmt) Close() error  { return nil }
+func (s fakeSchemaStmt) NumInput() int { return 0 }
+func (s fakeSchemaStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s fakeSchemaStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeSchemaRows{version: s.version}, nil
+}
+
+type fakeSchemaRows struct {
+	version string
+	done    bool
+}
+
+func (r *fakeSchemaRows) Columns() []string { return []string{"version"} }
+func (r *fakeSchemaRows) Close() error      { return nil }
+func (r *fakeSchemaRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.version
+	return nil
+}
+
+func init() {
+	sql.Register("fakeschema", fakeSchemaDriver{version: "20240501120000"})
+}
+
+func TestSchemaInspectors(t *testing.T) {
+	db, err := sql.Open("fakeschema", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "schema_version")
+	_ = os.WriteFile(path, []byte("42\n"), 0o644)
+
+	tests := []struct {
+		name      string
+		inspector SchemaInspector
+		want      string
+		wantErr   bool
+	}{
+		{"sql", NewSQLSchemaInspector(db, ""), "20240501120000", false},
+		{"file", NewFileSchemaInspector(path), "42", false},
+		{"missing file", NewFileSchemaInspector(filepath.Join(t.TempDir(), "missing")), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.inspector.SchemaVersion(context.Background())
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("SchemaVersion() = %q, %v, want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func migrateHook(path, to string) HookFunc {
+	return func(_ context.Context, event RollbackEvent) error {
+		if to == "" {
+			to = event.SchemaTo
+		}
+		return os.WriteFile(path, []byte(to), 0o644)
+	}
+}
+
+func TestRollbackSchemaCompatibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     string
+		policy     SchemaPolicy
+		hook       func(path string) Hook
+		wantErr    bool
+		wantCalls  int
+		wantSchema string
+	}{
+		{"compatible", "41", SchemaBlock, nil, false, 1, "41"},
+		{"blocked", "42", SchemaBlock, nil, true, 0, "42"},
+		{"block ignores hook", "42", SchemaBlock, func(path string) Hook { return migrateHook(path, "") }, true, 0, "42"},
+		{"migrate without hook", "42", SchemaMigrate, nil, true, 0, "42"},
+		{"migrated", "42", SchemaMigrate, func(path string) Hook { return migrateHook(path, "") }, false, 1, "41"},
+		{"migration incomplete", "43", SchemaMigrate, func(path string) Hook { return migrateHook(path, "42") }, true, 0, "42"},
+		{"migration failed", "42", SchemaMigrate, func(string) Hook {
+			return HookFunc(func(context.Context, RollbackEvent) error { return errors.New("lock timeout") })
+		}, true, 0, "42"},
+		{"needs newer schema", "40", SchemaMigrate, func(path string) Hook { return migrateHook(path, "") }, true, 0, "40"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema_version")
+			_ = os.WriteFile(path, []byte(tt.schema), 0o644)
+
+			log := &hookLog{}
+			config := RollbackConfig{
+				MaxAttempts:     1,
+				BackoffDuration: time.Millisecond,
+				Schema: SchemaConfig{
+					Inspector: NewFileSchemaInspector(path),
+					Policy:    tt.policy,
+				},
+				Hooks: []HookRegistration{{Name: "failure", Phases: []HookPhase{HookOnFailure}, Hook: log.hook("failure", nil)}},
+			}
+			if tt.hook != nil {
+				config.Hooks = append(config.Hooks, HookRegistration{Name: "migrate", Phases: []HookPhase{HookDownMigrate}, Hook: tt.hook(path)})
+			}
+			mock := &mockStrategy{}
+			svc := NewService(config, mock, logging.NewLogger("error", true))
+			svc.RegisterVersionMetadata("v0.9.0", VersionMetadata{SchemaVersion: "41"})
+			svc.RegisterVersionMetadata("v1.0.0", VersionMetadata{SchemaVersion: "42"})
+
+			err := svc.Rollback("v1.0.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rollback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var rbErr *rberrors.RollbackError
+			if tt.wantErr && (!errors.As(err, &rbErr) || rbErr.Type != rberrors.ErrorTypeSchema) {
+				t.Errorf("Rollback() error = %v, want a schema error", err)
+			}
+			if tt.wantErr && len(log.events) != 1 {
+				t.Errorf("failure hooks = %v, want one call", log.events)
+			}
+			if len(mock.rollbackCalls) != tt.wantCalls {
+				t.Errorf("strategy calls = %d, want %d", len(mock.rollbackCalls), tt.wantCalls)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.wantSchema {
+				t.Errorf("schema after rollback = %q, want %q", data, tt.wantSchema)
+			}
+		})
+	}
+}
+
+func TestDownMigrateEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema_version")
+	_ = os.WriteFile(path, []byte("20240601000000"), 0o644)
+
+	log := &hookLog{}
+	config := RollbackConfig{
+		MaxAttempts: 1,
+		Schema: SchemaConfig{
+			Inspector: NewFileSchemaInspector(path),
+			Policy:    SchemaMigrate,
+			Versions: map[string]VersionMetadata{
+				"v1.0.0": {SchemaVersion: "20240401000000", MaxSchemaVersion: "20240501000000"},
+			},
+		},
+		Hooks: []HookRegistration{
+			{Name: "record", Phases: []HookPhase{HookDownMigrate}, Hook: log.hook("record", nil)},
+			{Name: "migrate", Phases: []HookPhase{HookDownMigrate}, Hook: migrateHook(path, "")},
+		},
+	}
+	group := NewGroup(config, GroupSequential, logging.NewLogger("error", true))
+	group.Add(GroupTarget{Name: "api", Strategy: &mockStrategy{}, From: "v1.1.0", To: "v1.0.0"})
+
+	if _, err := group.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if len(log.seen) != 1 {
+		t.Fatalf("down_migrate events = %d, want 1", len(log.seen))
+	}
+	event := log.seen[0]
+	if event.SchemaFrom != "20240601000000" || event.SchemaTo != "20240501000000" || event.To != "v1.0.0" || event.Plan != "api" {
+		t.Errorf("down_migrate event = %+v", event)
+	}
+	env := event.Environment()
+	if env[len(env)-1] != "ROLLBACK_SCHEMA_TO=20240501000000" {
+		t.Errorf("Environment() = %v, want schema versions", env)
+	}
+}
+
+func TestSchemaCheckRunsBeforePreRollbackHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema_version")
+	_ = os.WriteFile(path, []byte("42"), 0o644)
+
+	versions := map[string]VersionMetadata{"v1.0.0": {SchemaVersion: "41"}}
+	log := &hookLog{}
+	config := RollbackConfig{
+		MaxAttempts: 1,
+		Schema:      SchemaConfig{Inspector: NewFileSchemaInspector(path), Policy: SchemaBlock, Versions: versions},
+		Hooks:       []HookRegistration{{Name: "pre", Phases: []HookPhase{HookPreRollback}, Hook: log.hook("pre", nil)}},
+	}
+	logger := logging.NewLogger("error", true)
+
+	svc := NewService(config, &mockStrategy{}, logger)
+	svc.RegisterVersionMetadata("v1.1.0", VersionMetadata{SchemaVersion: "42"})
+	if err := svc.Rollback("v1.1.0"); err == nil {
+		t.Fatal("Service.Rollback() error = nil, want schema error")
+	}
+
+	group := NewGroup(config, GroupSequential, logger)
+	group.Add(GroupTarget{Name: "api", Strategy: &mockStrategy{}, From: "v1.1.0", To: "v1.0.0"})
+	if _, err := group.Rollback(); err == nil {
+		t.Fatal("Group.Rollback() error = nil, want schema error")
+	}
+
+	if len(log.events) != 0 {
+		t.Errorf("pre_rollback hooks = %v, want none before a blocked schema check", log.events)
+	}
+	if len(versions) != 1 {
+		t.Errorf("config versions = %v, want the caller's map left untouched", versions)
+	}
+}
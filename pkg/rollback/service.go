@@ -1,12 +1,20 @@
 package rollback
 
 import (
+	"context"
+	stderrors "errors"
+	"fmt"
 	"sort"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/deployment"
 	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/errors"
 	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/notify"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/tracing"
 )
 
 type Service struct {
@@ -14,18 +22,39 @@ type Service struct {
 	versions []string
 	strategy deployment.Strategy
 	logger   *logging.Logger
+	metrics  *serviceMetrics
+	tracer   trace.Tracer
+	notifier *notify.Dispatcher
+	hooks    *hookRunner
+	schema   *schemaGuard
 }
 
 func NewService(config RollbackConfig, strategy deployment.Strategy, logger *logging.Logger) *Service {
 	if logger == nil {
 		logger = logging.NewLogger("info", false)
 	}
-	return &Service{
+	s := &Service{
 		config:   config,
 		versions: make([]string, 0),
 		strategy: strategy,
 		logger:   logger,
+		tracer:   tracing.Tracer(config.TracerProvider),
+		notifier: newDispatcher(config, logger),
+	}
+	s.metrics = configMetrics(config)
+	s.strategy = wrapStrategy(config, "", strategy)
+	s.hooks = newHookRunner(append(legacyHooks(config), config.Hooks...), logger, s.tracer)
+	schema := config.Schema
+	schema.Versions = make(map[string]VersionMetadata, len(config.Schema.Versions))
+	for version, metadata := range config.Schema.Versions {
+		schema.Versions[version] = metadata
 	}
+	s.schema = newSchemaGuard(schema, s.hooks, logger, s.tracer)
+	return s
+}
+
+func (s *Service) AddHook(registration HookRegistration) {
+	s.hooks.hooks = append(s.hooks.hooks, registration)
 }
 
 func (s *Service) RegisterVersion(version string) {
@@ -34,6 +63,11 @@ func (s *Service) RegisterVersion(version string) {
 	sort.Strings(s.versions)
 }
 
+func (s *Service) RegisterVersionMetadata(version string, metadata VersionMetadata) {
+	s.schema.config.Versions[version] = metadata
+	s.RegisterVersion(version)
+}
+
 func (s *Service) findPreviousStableVersion(currentVersion string) (string, error) {
 	s.logger.Debug().Str("current_version", currentVersion).Msg("Finding previous stable version")
 	for i := len(s.versions) - 1; i >= 0; i-- {
@@ -49,7 +83,36 @@ func (s *Service) findPreviousStableVersion(currentVersion string) (string, erro
 	return "", errors.NewValidationError("no stable previous version found", nil)
 }
 
-func (s *Service) executeRollback(from, to string) error {
+func (s *Service) hookEvent(phase HookPhase, record *Record, err error) RollbackEvent {
+	return RollbackEvent{
+		Phase:       phase,
+		RollbackID:  record.ID,
+		Strategy:    record.Strategy,
+		From:        record.FromVersion,
+		To:          record.ToVersion,
+		Attempt:     record.Attempt,
+		MaxAttempts: s.config.MaxAttempts,
+		DryRun:      s.config.DryRun,
+		Err:         err,
+	}
+}
+
+func (s *Service) runFailureHooks(ctx context.Context, record *Record, err error) {
+	_ = s.hooks.run(ctx, s.hookEvent(HookOnFailure, record, err))
+}
+
+func (s *Service) executeRollback(ctx context.Context, record *Record) (err error) {
+	from, to := record.FromVersion, record.ToVersion
+	ctx, span := s.tracer.Start(ctx, "rollback.attempt", trace.WithAttributes(
+		attribute.Int("rollback.attempt", record.Attempt),
+		attribute.String("rollback.strategy", s.strategy.StrategyName()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.hooks.run(ctx, s.hookEvent(HookPreDeploy, record, nil)); err != nil {
+		return err
+	}
+
 	s.logger.Debug().Str("from", from).Str("to", to).Msg("Executing rollback")
 	meta := map[string]interface{}{
 		"from_version": from,
@@ -57,14 +120,286 @@ func (s *Service) executeRollback(from, to string) error {
 		"strategy":     s.strategy.StrategyName(),
 	}
 
-	if err := s.strategy.Rollback(from, to); err != nil {
+	if err := deployment.RollbackWithContext(ctx, s.strategy, from, to); err != nil {
 		return errors.NewDeploymentError("rollback execution failed", err, meta)
 	}
+	return s.hooks.run(ctx, s.hookEvent(HookPostDeploy, record, nil))
+}
+
+func (s *Service) backoff(ctx context.Context, attempt int) {
+	_, span := s.tracer.Start(ctx, "rollback.backoff", trace.WithAttributes(
+		attribute.Int("rollback.attempt", attempt),
+		attribute.String("rollback.backoff", s.config.BackoffDuration.String()),
+	))
+	defer span.End()
+	time.Sleep(s.config.BackoffDuration)
+}
+
+func (s *Service) verify(ctx context.Context, record *Record) (err error) {
+	ctx, span := s.tracer.Start(ctx, "rollback.verify", trace.WithAttributes(attribute.String("rollback.to_version", record.ToVersion)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.hooks.run(ctx, s.hookEvent(HookPreVerify, record, nil)); err != nil {
+		return err
+	}
+	if s.config.VerifyRollback != nil {
+		s.logger.Debug().Str("version", record.ToVersion).Msg("Verifying rollback")
+		if err := s.config.VerifyRollback(record.ToVersion); err != nil {
+			return errors.NewHealthCheckError("rollback verification failed", err)
+		}
+	}
+	return s.hooks.run(ctx, s.hookEvent(HookPostVerify, record, nil))
+}
+
+func (s *Service) context() context.Context {
+	return configContext(s.config)
+}
+
+func (s *Service) acquireLock() (Lock, error) {
+	if s.config.Lock.Locker == nil {
+		return nil, nil
+	}
+
+	key := s.config.Lock.Key
+	if key == "" {
+		key = "stable-galaxy-" + s.strategy.StrategyName()
+	}
+	holder := s.config.Lock.Holder
+	if holder == "" {
+		holder = DefaultLockHolder()
+	}
+
+	lock, err := s.config.Lock.Locker.Acquire(s.context(), key, holder, s.config.Lock.TTL)
+	if err != nil {
+		s.logger.Error().Err(err).Str("lock", key).Msg("Failed to acquire rollback lock")
+		return nil, errors.NewLockError("failed to acquire rollback lock", err)
+	}
+	s.logger.Debug().Str("lock", key).Str("holder", holder).Msg("Acquired rollback lock")
+	return lock, nil
+}
+
+var errLockLost = stderrors.New("rollback lock lost")
+
+func (s *Service) watchLock(ctx context.Context, lock Lock) (context.Context, func()) {
+	if lock == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-lock.Lost():
+			s.logger.Error().Str("lock", lock.Key()).Msg("Rollback lock lost, stopping rollback")
+			cancel(errLockLost)
+		case <-stop:
+		}
+	}()
+	return ctx, func() {
+		close(stop)
+		cancel(nil)
+	}
+}
+
+func (s *Service) stopped(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	cause := context.Cause(ctx)
+	if stderrors.Is(cause, errLockLost) {
+		return errors.NewLockError("rollback stopped", cause)
+	}
+	return errors.NewDeploymentError("rollback cancelled", cause, nil)
+}
+
+func (s *Service) releaseLock(lock Lock) {
+	if lock == nil {
+		return
+	}
+	if err := lock.Release(); err != nil {
+		s.logger.Warn().Err(err).Str("lock", lock.Key()).Msg("Failed to release rollback lock")
+	}
+}
+
+func (s *Service) saveRecord(record *Record) {
+	if s.config.StateStore == nil {
+		return
+	}
+	if err := s.config.StateStore.Save(record); err != nil {
+		s.logger.Warn().Err(err).Str("rollback_id", record.ID).Msg("Failed to persist rollback state")
+	}
+}
+
+func (s *Service) transition(record *Record, next State, message string) {
+	if err := record.transition(next, message); err != nil {
+		s.logger.Error().Err(err).Str("rollback_id", record.ID).Msg("Invalid rollback state transition")
+		return
+	}
+	s.logger.Debug().Str("rollback_id", record.ID).Str("state", string(next)).Msg("Rollback state changed")
+	s.saveRecord(record)
+}
+
+func (s *Service) fail(record *Record, err error) {
+	record.Error = err.Error()
+	s.transition(record, StateFailed, err.Error())
+}
+
+var phaseNames = map[State]string{
+	StatePreHook:      "pre_hook",
+	StateExecuting:    "execute",
+	StateVerifying:    "verify",
+	StatePostHook:     "post_hook",
+	StateCompensating: "compensate",
+}
+
+func (s *Service) observePhase(state State, start time.Time) {
+	if phase, ok := phaseNames[state]; ok {
+		s.metrics.phase(s.strategy.StrategyName(), phase, start)
+	}
+}
+
+func (s *Service) run(ctx context.Context, record *Record) error {
+	from, to := record.FromVersion, record.ToVersion
+
+	var phase State
+	var phaseStart time.Time
+	defer func() {
+		s.observePhase(phase, phaseStart)
+	}()
+
+	for !record.State.Terminal() {
+		if err := s.stopped(ctx); err != nil {
+			s.fail(record, err)
+			return err
+		}
+		s.observePhase(phase, phaseStart)
+		phase, phaseStart = record.State, time.Now()
+
+		switch record.State {
+		case StatePending:
+			s.transition(record, StatePreHook, "")
+
+		case StatePreHook:
+			if err := s.schema.ensure(ctx, s.hookEvent(HookPreRollback, record, nil)); err != nil {
+				s.runFailureHooks(ctx, record, err)
+				s.fail(record, err)
+				return err
+			}
+			if err := s.hooks.run(ctx, s.hookEvent(HookPreRollback, record, nil)); err != nil {
+				s.fail(record, err)
+				return err
+			}
+			s.transition(record, StateExecuting, "")
+
+		case StateExecuting:
+			attempt := record.Attempt
+			if attempt < 1 {
+				attempt = 1
+			}
+			for ; ; attempt++ {
+				record.Attempt = attempt
+				s.saveRecord(record)
+				s.logger.Info().Int("attempt", attempt).Int("max_attempts", s.config.MaxAttempts).Msg("Attempting rollback")
+
+				err := s.executeRollback(ctx, record)
+				if err == nil {
+					s.metrics.attempt(s.strategy.StrategyName(), attempt)
+					break
+				}
+				if IsVeto(err) {
+					s.metrics.attempt(s.strategy.StrategyName(), attempt)
+					s.fail(record, err)
+					return err
+				}
+				if stopErr := s.stopped(ctx); stopErr != nil {
+					s.metrics.attempt(s.strategy.StrategyName(), attempt)
+					s.fail(record, stopErr)
+					return stopErr
+				}
+				if attempt >= s.config.MaxAttempts {
+					s.metrics.attempt(s.strategy.StrategyName(), attempt)
+					s.logger.Error().Err(err).Int("attempts", attempt).Msg("Rollback failed after all attempts")
+					s.runFailureHooks(ctx, record, err)
+					s.fail(record, err)
+					return errors.NewDeploymentError("rollback failed after all attempts", err, nil)
+				}
+				s.logger.Warn().Err(err).Int("attempt", attempt).Dur("backoff", s.config.BackoffDuration).Msg("Retrying after backoff")
+				s.backoff(ctx, attempt)
+			}
+			s.transition(record, StateVerifying, "")
+
+		case StateVerifying:
+			if err := s.verify(ctx, record); err != nil {
+				s.logger.Error().Err(err).Str("version", to).Msg("Rollback verification failed")
+				if !IsVeto(err) {
+					s.runFailureHooks(ctx, record, err)
+				}
+				s.fail(record, err)
+				return err
+			}
+			s.transition(record, StatePostHook, "")
+
+		case StatePostHook:
+			if err := s.hooks.run(ctx, s.hookEvent(HookPostRollback, record, nil)); err != nil {
+				s.fail(record, err)
+				return err
+			}
+			s.transition(record, StateSucceeded, "")
+
+		case StateCompensating:
+			if err := s.compensate(ctx, record); err != nil {
+				return err
+			}
+
+		default:
+			return errors.NewValidationError(fmt.Sprintf("unknown rollback state %q", record.State), nil)
+		}
+	}
+
+	s.logger.Info().Str("from", from).Str("to", to).Msg("Rollback completed successfully")
+	return nil
+}
+
+func (s *Service) compensate(ctx context.Context, record *Record) (err error) {
+	ctx, span := s.tracer.Start(ctx, "rollback.compensate", trace.WithAttributes(
+		attribute.String("rollback.id", record.ID),
+		attribute.String("rollback.from_version", record.FromVersion),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	s.logger.Warn().Str("rollback_id", record.ID).Str("version", record.FromVersion).Msg("Compensating by re-deploying original version")
+	if err := deployment.DeployWithContext(ctx, s.strategy, record.FromVersion); err != nil {
+		s.fail(record, err)
+		return errors.NewDeploymentError("compensation failed", err, map[string]interface{}{
+			"rollback_id":  record.ID,
+			"from_version": record.FromVersion,
+		})
+	}
+	s.transition(record, StateFailed, "aborted and compensated")
 	return nil
 }
 
-func (s *Service) Rollback(currentVersion string) error {
+func (s *Service) Rollback(currentVersion string) (err error) {
 	s.logger.Info().Str("from_version", currentVersion).Msg("Starting rollback")
+	start := time.Now()
+	s.metrics.start(s.strategy.StrategyName())
+	ctx, span := s.tracer.Start(s.context(), "rollback.Rollback", trace.WithAttributes(
+		attribute.String("rollback.strategy", s.strategy.StrategyName()),
+		attribute.String("rollback.from_version", currentVersion),
+	))
+	record := &Record{Strategy: s.strategy.StrategyName(), FromVersion: currentVersion}
+	defer func() {
+		s.metrics.finish(s.strategy.StrategyName(), start, err)
+		tracing.End(span, err)
+		s.notifyFinished(record, start, err)
+	}()
+
+	lock, err := s.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer s.releaseLock(lock)
+	ctx, stopWatch := s.watchLock(ctx, lock)
+	defer stopWatch()
 
 	targetVersion, err := s.findPreviousStableVersion(currentVersion)
 	if err != nil {
@@ -72,40 +407,122 @@ func (s *Service) Rollback(currentVersion string) error {
 		return errors.NewValidationError("failed to find stable version", err)
 	}
 
-	if s.config.PreRollbackHook != nil {
-		s.logger.Debug().Msg("Executing pre-rollback hook")
-		if err := s.config.PreRollbackHook(); err != nil {
-			s.logger.Error().Err(err).Msg("Pre-rollback hook failed")
-			return errors.NewDeploymentError("pre-rollback hook failed", err, nil)
+	record = newRecord(s.strategy.StrategyName(), currentVersion, targetVersion)
+	span.SetAttributes(attribute.String("rollback.to_version", targetVersion), attribute.String("rollback.id", record.ID))
+	if s.config.StateStore != nil {
+		if err := s.config.StateStore.Save(record); err != nil {
+			return errors.NewDeploymentError("failed to persist rollback state", err, nil)
 		}
 	}
+	s.logger.Debug().Str("rollback_id", record.ID).Msg("Created rollback record")
+	s.notify(recordEvent(notify.EventStarted, record, start, nil))
+	return s.run(ctx, record)
+}
 
-	for attempt := 1; attempt <= s.config.MaxAttempts; attempt++ {
-		s.logger.Info().Int("attempt", attempt).Int("max_attempts", s.config.MaxAttempts).Msg("Attempting rollback")
+func (s *Service) notifyFinished(record *Record, start time.Time, err error) {
+	if err != nil {
+		s.notify(recordEvent(notify.EventFailed, record, start, err))
+		return
+	}
+	s.notify(recordEvent(notify.EventSucceeded, record, start, nil))
+}
 
-		if err := s.executeRollback(currentVersion, targetVersion); err != nil {
-			if attempt == s.config.MaxAttempts {
-				s.logger.Error().Err(err).Int("attempts", attempt).Msg("Rollback failed after all attempts")
-				if s.config.OnFailureHook != nil {
-					s.config.OnFailureHook(err)
-				}
-				return errors.NewDeploymentError("rollback failed after all attempts", err, nil)
-			}
-			s.logger.Warn().Err(err).Int("attempt", attempt).Dur("backoff", s.config.BackoffDuration).Msg("Retrying after backoff")
-			time.Sleep(s.config.BackoffDuration)
-			continue
+func (s *Service) loadRecord(id string) (*Record, error) {
+	if s.config.StateStore == nil {
+		return nil, errors.NewValidationError("no state store configured", nil)
+	}
+	record, err := s.config.StateStore.Load(id)
+	if err != nil {
+		return nil, errors.NewValidationError("failed to load rollback record", err)
+	}
+	if record.Strategy != s.strategy.StrategyName() {
+		return nil, errors.NewValidationError(fmt.Sprintf("rollback %s belongs to strategy %s, not %s", id, record.Strategy, s.strategy.StrategyName()), nil)
+	}
+	return record, nil
+}
+
+func (s *Service) InFlight() ([]*Record, error) {
+	if s.config.StateStore == nil {
+		return nil, errors.NewValidationError("no state store configured", nil)
+	}
+	records, err := s.config.StateStore.List()
+	if err != nil {
+		return nil, err
+	}
+	inFlight := make([]*Record, 0)
+	for _, record := range records {
+		if !record.State.Terminal() && record.Strategy == s.strategy.StrategyName() {
+			inFlight = append(inFlight, record)
 		}
-		break
 	}
+	return inFlight, nil
+}
 
-	if s.config.PostRollbackHook != nil {
-		s.logger.Debug().Msg("Executing post-rollback hook")
-		if err := s.config.PostRollbackHook(); err != nil {
-			s.logger.Error().Err(err).Msg("Post-rollback hook failed")
-			return errors.NewDeploymentError("post-rollback hook failed", err, nil)
+func (s *Service) Resume(id string) (err error) {
+	start := time.Now()
+	s.metrics.start(s.strategy.StrategyName())
+	ctx, span := s.tracer.Start(s.context(), "rollback.Resume", trace.WithAttributes(
+		attribute.String("rollback.strategy", s.strategy.StrategyName()),
+		attribute.String("rollback.id", id),
+	))
+	var record *Record
+	defer func() {
+		s.metrics.finish(s.strategy.StrategyName(), start, err)
+		tracing.End(span, err)
+		if record != nil {
+			s.notifyFinished(record, start, err)
 		}
+	}()
+
+	lock, err := s.acquireLock()
+	if err != nil {
+		return err
 	}
+	defer s.releaseLock(lock)
+	ctx, stopWatch := s.watchLock(ctx, lock)
+	defer stopWatch()
 
-	s.logger.Info().Str("from", currentVersion).Str("to", targetVersion).Msg("Rollback completed successfully")
-	return nil
+	loaded, err := s.loadRecord(id)
+	if err != nil {
+		return err
+	}
+	if loaded.State.Terminal() {
+		s.logger.Info().Str("rollback_id", id).Str("state", string(loaded.State)).Msg("Rollback already finished")
+		return nil
+	}
+	record = loaded
+	span.SetAttributes(tracing.Versions(record.FromVersion, record.ToVersion)...)
+	s.logger.Info().Str("rollback_id", id).Str("state", string(record.State)).Int("attempt", record.Attempt).Msg("Resuming rollback")
+	event := recordEvent(notify.EventStarted, record, start, nil)
+	event.Labels = map[string]string{"resumed": "true"}
+	s.notify(event)
+	return s.run(ctx, record)
+}
+
+func (s *Service) Abort(id string) error {
+	lock, err := s.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer s.releaseLock(lock)
+
+	record, err := s.loadRecord(id)
+	if err != nil {
+		return err
+	}
+
+	switch record.State {
+	case StateSucceeded, StateFailed:
+		return nil
+	case StatePending, StatePreHook:
+		s.logger.Info().Str("rollback_id", id).Msg("Aborting rollback before execution")
+		s.transition(record, StateFailed, "aborted")
+		return nil
+	case StateCompensating:
+		return s.compensate(s.context(), record)
+	default:
+		s.logger.Info().Str("rollback_id", id).Str("state", string(record.State)).Msg("Aborting in-flight rollback")
+		s.transition(record, StateCompensating, "aborted")
+		return s.compensate(s.context(), record)
+	}
 }
@@ -1,11 +1,17 @@
 package rollback
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
 	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/notify"
 )
 
 type mockStrategy struct {
@@ -29,6 +35,10 @@ func (m *mockStrategy) GetCurrentVersion() (string, error) {
 	return "v1.0.0", nil
 }
 
+func (m *mockStrategy) StrategyName() string {
+	return "mock"
+}
+
 func TestRollbackService(t *testing.T) {
 	logger := logging.NewLogger("error", true)
 
@@ -131,3 +141,148 @@ func TestRollbackHooks(t *testing.T) {
 		t.Error("Failure hook was called unexpectedly")
 	}
 }
+
+type flakyStrategy struct {
+	mockStrategy
+	failures int
+}
+
+func (f *flakyStrategy) Rollback(from, to string) error {
+	f.rollbackCalls = append(f.rollbackCalls, from+"->"+to)
+	if len(f.rollbackCalls) <= f.failures {
+		return errors.New("mock rollback failed")
+	}
+	return nil
+}
+
+func TestRollbackTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	config := RollbackConfig{
+		MaxAttempts:      3,
+		BackoffDuration:  time.Millisecond,
+		TracerProvider:   provider,
+		PreRollbackHook:  func() error { return nil },
+		PostRollbackHook: func() error { return nil },
+		VerifyRollback:   func(string) error { return nil },
+	}
+	svc := NewService(config, &flakyStrategy{failures: 1}, logging.NewLogger("error", true))
+	svc.RegisterVersion("v0.9.0")
+	svc.RegisterVersion("v1.0.0")
+
+	if err := svc.Rollback("v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	counts := make(map[string]int)
+	var root sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		counts[span.Name()]++
+		if span.Name() == "rollback.Rollback" {
+			root = span
+		}
+	}
+	want := map[string]int{
+		"rollback.Rollback":   1,
+		"rollback.hook":       2,
+		"rollback.attempt":    2,
+		"rollback.backoff":    1,
+		"deployment.rollback": 2,
+		"rollback.verify":     1,
+	}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("span %s count = %d, want %d", name, counts[name], n)
+		}
+	}
+	if root == nil {
+		t.Fatal("rollback.Rollback span not recorded")
+	}
+
+	for _, span := range spans {
+		if span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("span %s trace = %v, want %v", span.Name(), span.SpanContext().TraceID(), root.SpanContext().TraceID())
+		}
+		if span.Name() == "deployment.rollback" {
+			continue
+		}
+		if span != root && span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("span %s parent = %v, want rollback.Rollback", span.Name(), span.Parent().SpanID())
+		}
+	}
+	attrs := make(map[string]string)
+	for _, kv := range root.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["rollback.from_version"] != "v1.0.0" || attrs["rollback.to_version"] != "v0.9.0" || attrs["rollback.strategy"] != "mock" {
+		t.Errorf("rollback.Rollback attributes = %v", attrs)
+	}
+}
+
+type eventNotifier struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (e *eventNotifier) Name() string {
+	return "events"
+}
+
+func (e *eventNotifier) Notify(_ context.Context, message notify.Message) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, message.Event)
+	return nil
+}
+
+func TestRollbackNotifications(t *testing.T) {
+	tests := []struct {
+		name     string
+		mockFail bool
+		want     []notify.EventType
+	}{
+		{"success", false, []notify.EventType{notify.EventStarted, notify.EventSucceeded}},
+		{"failure", true, []notify.EventType{notify.EventStarted, notify.EventFailed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &eventNotifier{}
+			config := RollbackConfig{MaxAttempts: 2, BackoffDuration: time.Millisecond}
+			config.Notifications = notify.Config{Enabled: true, Notifiers: []notify.Notifier{notifier}}
+
+			svc := NewService(config, &mockStrategy{shouldFail: tt.mockFail}, logging.NewLogger("error", true))
+			svc.RegisterVersion("v0.9.0")
+			svc.RegisterVersion("v1.0.0")
+			_ = svc.Rollback("v1.0.0")
+			if err := svc.Close(context.Background()); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if len(notifier.events) != len(tt.want) {
+				t.Fatalf("events = %+v, want %v", notifier.events, tt.want)
+			}
+			for i, event := range notifier.events {
+				if event.Type != tt.want[i] || event.From != "v1.0.0" || event.To != "v0.9.0" || event.RollbackID == "" {
+					t.Errorf("event %d = %+v, want %s for v1.0.0 -> v0.9.0", i, event, tt.want[i])
+				}
+			}
+			last := notifier.events[len(notifier.events)-1]
+			if tt.mockFail && (last.Attempts != 2 || last.Error == "") {
+				t.Errorf("failed event = %+v, want 2 attempts and an error", last)
+			}
+		})
+	}
+}
+
+func TestDefaultConfigStartsNoNotifier(t *testing.T) {
+	logger := logging.NewLogger("error", true)
+	svc := NewService(DefaultConfig(), &mockStrategy{}, logger)
+	group := NewGroup(DefaultConfig(), GroupParallel, logger)
+
+	if svc.notifier != nil || group.notifier != nil {
+		t.Errorf("notifiers = %v, %v, want none without a configured channel", svc.notifier, group.notifier)
+	}
+}
@@ -0,0 +1,230 @@
+package rollback
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	StatePending      State = "pending"
+	StatePreHook      State = "pre_hook"
+	StateExecuting    State = "executing"
+	StateVerifying    State = "verifying"
+	StatePostHook     State = "post_hook"
+	StateSucceeded    State = "succeeded"
+	StateFailed       State = "failed"
+	StateCompensating State = "compensating"
+)
+
+var ErrRecordNotFound = stderrors.New("rollback record not found")
+
+var allowedTransitions = map[State][]State{
+	StatePending:      {StatePreHook, StateFailed},
+	StatePreHook:      {StateExecuting, StateFailed},
+	StateExecuting:    {StateVerifying, StateFailed, StateCompensating},
+	StateVerifying:    {StatePostHook, StateFailed, StateCompensating},
+	StatePostHook:     {StateSucceeded, StateFailed, StateCompensating},
+	StateCompensating: {StateFailed},
+}
+
+func (s State) Terminal() bool {
+	return s == StateSucceeded || s == StateFailed
+}
+
+func (s State) CanTransitionTo(next State) bool {
+	for _, allowed := range allowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type Transition struct {
+	From    State     `json:"from"`
+	To      State     `json:"to"`
+	At      time.Time `json:"at"`
+	Message string    `json:"message,omitempty"`
+}
+
+type Record struct {
+	ID          string       `json:"id"`
+	Strategy    string       `json:"strategy"`
+	FromVersion string       `json:"from_version"`
+	ToVersion   string       `json:"to_version"`
+	State       State        `json:"state"`
+	Attempt     int          `json:"attempt"`
+	Error       string       `json:"error,omitempty"`
+	StartedAt   time.Time    `json:"started_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Transitions []Transition `json:"transitions"`
+}
+
+func newRecord(strategy, from, to string) *Record {
+	now := time.Now()
+	return &Record{
+		ID:          fmt.Sprintf("%s-%s-%d", strategy, from, now.UnixNano()),
+		Strategy:    strategy,
+		FromVersion: from,
+		ToVersion:   to,
+		State:       StatePending,
+		StartedAt:   now,
+		UpdatedAt:   now,
+		Transitions: make([]Transition, 0),
+	}
+}
+
+func (r *Record) transition(next State, message string) error {
+	if !r.State.CanTransitionTo(next) {
+		return fmt.Errorf("invalid rollback state transition %s -> %s", r.State, next)
+	}
+	now := time.Now()
+	r.Transitions = append(r.Transitions, Transition{From: r.State, To: next, At: now, Message: message})
+	r.State = next
+	r.UpdatedAt = now
+	return nil
+}
+
+func (r *Record) clone() *Record {
+	c := *r
+	c.Transitions = append([]Transition(nil), r.Transitions...)
+	return &c
+}
+
+type StateStore interface {
+	Save(record *Record) error
+	Load(id string) (*Record, error)
+	List() ([]*Record, error)
+	Delete(id string) error
+}
+
+type MemoryStateStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{records: make(map[string]*Record)}
+}
+
+func (m *MemoryStateStore) Save(record *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[record.ID] = record.clone()
+	return nil
+}
+
+func (m *MemoryStateStore) Load(id string) (*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record, ok := m.records[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+	return record.clone(), nil
+}
+
+func (m *MemoryStateStore) List() ([]*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := make([]*Record, 0, len(m.records))
+	for _, record := range m.records {
+		records = append(records, record.clone())
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (m *MemoryStateStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, id)
+	return nil
+}
+
+type FileStateStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewFileStateStore(dir string) (*FileStateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStateStore{dir: dir}, nil
+}
+
+func (f *FileStateStore) path(id string) string {
+	return filepath.Join(f.dir, strings.ReplaceAll(id, string(filepath.Separator), "_")+".json")
+}
+
+func (f *FileStateStore) Save(record *Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := f.path(record.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (f *FileStateStore) Load(id string) (*Record, error) {
+	data, err := os.ReadFile(f.path(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode rollback record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+func (f *FileStateStore) List() ([]*Record, error) {
+	matches, err := filepath.Glob(filepath.Join(f.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(matches))
+	for _, match := range matches {
+		record, err := f.Load(strings.TrimSuffix(filepath.Base(match), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (f *FileStateStore) Delete(id string) error {
+	err := os.Remove(f.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func sortRecords(records []*Record) {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].StartedAt.Before(records[j].StartedAt)
+	})
+}
@@ -0,0 +1,196 @@
+package rollback
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BaderEddineBenhirt/stable-galaxy/pkg/logging"
+)
+
+func TestRecordTransitions(t *testing.T) {
+	record := newRecord("mock", "v1.1.0", "v1.0.0")
+
+	if err := record.transition(StateExecuting, ""); err == nil {
+		t.Error("transition(pending -> executing) expected error")
+	}
+	for _, next := range []State{StatePreHook, StateExecuting, StateVerifying, StatePostHook, StateSucceeded} {
+		if err := record.transition(next, ""); err != nil {
+			t.Fatalf("transition(%s) error = %v", next, err)
+		}
+	}
+	if !record.State.Terminal() {
+		t.Error("succeeded state should be terminal")
+	}
+	if len(record.Transitions) != 5 {
+		t.Errorf("expected 5 transitions, got %d", len(record.Transitions))
+	}
+}
+
+func TestStateStores(t *testing.T) {
+	fileStore, err := NewFileStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+
+	stores := map[string]StateStore{
+		"memory": NewMemoryStateStore(),
+		"file":   fileStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			record := newRecord("mock", "v1.1.0", "v1.0.0")
+			_ = record.transition(StatePreHook, "")
+			if err := store.Save(record); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			loaded, err := store.Load(record.ID)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if loaded.State != StatePreHook || loaded.ToVersion != "v1.0.0" || len(loaded.Transitions) != 1 {
+				t.Errorf("Load() = %+v", loaded)
+			}
+
+			records, err := store.List()
+			if err != nil || len(records) != 1 {
+				t.Fatalf("List() = %v, %v", records, err)
+			}
+
+			if err := store.Delete(record.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Load(record.ID); !errors.Is(err, ErrRecordNotFound) {
+				t.Errorf("Load() after delete error = %v, want ErrRecordNotFound", err)
+			}
+		})
+	}
+}
+
+func TestRollbackPersistsState(t *testing.T) {
+	store := NewMemoryStateStore()
+	config := RollbackConfig{MaxAttempts: 1, StateStore: store}
+	svc := NewService(config, &mockStrategy{}, logging.NewLogger("error", true))
+	svc.RegisterVersion("v0.9.0")
+	svc.RegisterVersion("v1.0.0")
+
+	if err := svc.Rollback("v1.0.0"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	records, _ := store.List()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].State != StateSucceeded {
+		t.Errorf("record state = %s, want %s", records[0].State, StateSucceeded)
+	}
+	if records[0].Attempt != 1 {
+		t.Errorf("record attempt = %d, want 1", records[0].Attempt)
+	}
+}
+
+func TestResumeInFlightRollback(t *testing.T) {
+	store := NewMemoryStateStore()
+	record := newRecord("mock", "v1.0.0", "v0.9.0")
+	for _, next := range []State{StatePreHook, StateExecuting} {
+		_ = record.transition(next, "")
+	}
+	record.Attempt = 2
+	_ = store.Save(record)
+
+	preHookCalls := 0
+	postHookCalls := 0
+	config := RollbackConfig{
+		MaxAttempts:      3,
+		BackoffDuration:  time.Millisecond,
+		StateStore:       store,
+		PreRollbackHook:  func() error { preHookCalls++; return nil },
+		PostRollbackHook: func() error { postHookCalls++; return nil },
+	}
+	mock := &mockStrategy{}
+	svc := NewService(config, mock, logging.NewLogger("error", true))
+
+	inFlight, err := svc.InFlight()
+	if err != nil || len(inFlight) != 1 {
+		t.Fatalf("InFlight() = %v, %v", inFlight, err)
+	}
+
+	if err := svc.Resume(record.ID); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if preHookCalls != 0 {
+		t.Errorf("pre-hook ran %d times on resume, want 0", preHookCalls)
+	}
+	if postHookCalls != 1 {
+		t.Errorf("post-hook ran %d times, want 1", postHookCalls)
+	}
+	if len(mock.rollbackCalls) != 1 || mock.rollbackCalls[0] != "v1.0.0->v0.9.0" {
+		t.Errorf("rollback calls = %v, want [v1.0.0->v0.9.0]", mock.rollbackCalls)
+	}
+
+	loaded, _ := store.Load(record.ID)
+	if loaded.State != StateSucceeded {
+		t.Errorf("record state = %s, want %s", loaded.State, StateSucceeded)
+	}
+}
+
+func TestResumeRejectsOtherStrategy(t *testing.T) {
+	store := NewMemoryStateStore()
+	record := newRecord("kubernetes", "v1.0.0", "v0.9.0")
+	for _, next := range []State{StatePreHook, StateExecuting} {
+		_ = record.transition(next, "")
+	}
+	_ = store.Save(record)
+
+	mock := &mockStrategy{}
+	svc := NewService(RollbackConfig{MaxAttempts: 1, StateStore: store}, mock, logging.NewLogger("error", true))
+	if err := svc.Resume(record.ID); err == nil {
+		t.Fatal("Resume() expected error for a record from another strategy")
+	}
+	if len(mock.rollbackCalls) != 0 {
+		t.Errorf("rollback calls = %v, want none", mock.rollbackCalls)
+	}
+	loaded, _ := store.Load(record.ID)
+	if loaded.State != StateExecuting {
+		t.Errorf("record state = %s, want %s", loaded.State, StateExecuting)
+	}
+}
+
+func TestAbortInFlightRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		states      []State
+		wantDeploys int
+	}{
+		{name: "before execution", states: []State{StatePreHook}, wantDeploys: 0},
+		{name: "during execution", states: []State{StatePreHook, StateExecuting}, wantDeploys: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStateStore()
+			record := newRecord("docker", "v1.1.0", "v1.0.0")
+			for _, next := range tt.states {
+				_ = record.transition(next, "")
+			}
+			_ = store.Save(record)
+
+			strategy := &versionedStrategy{name: "docker", current: "v1.0.0"}
+			svc := NewService(RollbackConfig{MaxAttempts: 1, StateStore: store}, strategy, logging.NewLogger("error", true))
+
+			if err := svc.Abort(record.ID); err != nil {
+				t.Fatalf("Abort() error = %v", err)
+			}
+			if len(strategy.deploys) != tt.wantDeploys {
+				t.Errorf("deploys = %v, want %d", strategy.deploys, tt.wantDeploys)
+			}
+			loaded, _ := store.Load(record.ID)
+			if loaded.State != StateFailed {
+				t.Errorf("record state = %s, want %s", loaded.State, StateFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+/*
+Package tracing configures OpenTelemetry tracing for stable-galaxy.
+
+Spans can be exported over OTLP/HTTP, pretty-printed to stdout, or appended
+as JSON to a local file, which is convenient in tests:
+
+	shutdown, err := tracing.Setup(ctx, tracing.Config{
+	    Exporter: tracing.ExporterOTLP,
+	    Endpoint: "http://otel-collector:4318",
+	})
+	defer shutdown(ctx)
+
+Setup installs the provider globally. Components that accept a
+trace.TracerProvider fall back to that global provider when none is given.
+*/
+package tracing
@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const InstrumentationName = "github.com/BaderEddineBenhirt/stable-galaxy"
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+type Config struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	Headers     map[string]string
+	FilePath    string
+	ServiceName string
+	SampleRatio float64
+}
+
+func newExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch config.Exporter {
+	case ExporterOTLP:
+		options := make([]otlptracehttp.Option, 0)
+		if config.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(config.Endpoint))
+		}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		if len(config.Headers) > 0 {
+			options = append(options, otlptracehttp.WithHeaders(config.Headers))
+		}
+		exporter, err := otlptracehttp.New(ctx, options...)
+		return exporter, nil, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, err
+	case ExporterFile:
+		if config.FilePath == "" {
+			return nil, nil, fmt.Errorf("file trace exporter needs a file path")
+		}
+		file, err := os.OpenFile(config.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q", config.Exporter)
+	}
+}
+
+func NewProvider(ctx context.Context, config Config) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	exporter, closer, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "stable-galaxy"
+	}
+	sampler := sdktrace.AlwaysSample()
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	shutdown := func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+	return provider, shutdown, nil
+}
+
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	if config.Exporter == "" || config.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider, shutdown, err := NewProvider(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return shutdown, nil
+}
+
+func Tracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(InstrumentationName)
+}
+
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func Versions(from, to string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if from != "" {
+		attrs = append(attrs, attribute.String("rollback.from_version", from))
+	}
+	if to != "" {
+		attrs = append(attrs, attribute.String("rollback.to_version", to))
+	}
+	return attrs
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"stdout", Config{Exporter: ExporterStdout}, false},
+		{"file", Config{Exporter: ExporterFile, FilePath: filepath.Join(t.TempDir(), "spans.json")}, false},
+		{"file without path", Config{Exporter: ExporterFile}, true},
+		{"unknown", Config{Exporter: "zipkin"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, shutdown, err := NewProvider(context.Background(), tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if err := shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	provider, shutdown, err := NewProvider(context.Background(), Config{Exporter: ExporterFile, FilePath: path, ServiceName: "test"})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	_, span := Tracer(provider).Start(context.Background(), "rollback.Rollback")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"Name":"rollback.Rollback"`) {
+		t.Errorf("exported spans = %s, want rollback.Rollback span", data)
+	}
+}
+
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, ok := Tracer(provider).Start(context.Background(), "ok")
+	End(ok, nil)
+	_, failed := Tracer(provider).Start(context.Background(), "failed")
+	End(failed, errors.New("boom"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	if spans[0].Status().Code != codes.Unset {
+		t.Errorf("status of ok span = %v, want Unset", spans[0].Status().Code)
+	}
+	if spans[1].Status().Code != codes.Error || len(spans[1].Events()) != 1 {
+		t.Errorf("failed span status = %v with %d events, want Error with 1 event", spans[1].Status().Code, len(spans[1].Events()))
+	}
+}